@@ -21,7 +21,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		AllowOrigins:     origins,
 		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
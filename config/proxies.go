@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadTrustedProxies sets where the router reads the client IP from, which rate limits and login
+// throttling are keyed by. Behind a reverse proxy (e.g. Render's), every request comes from the
+// proxy, so without this all anonymous clients would share one IP:
+//   - TRUSTED_PROXIES lists the addresses or CIDR ranges of the proxies (comma-separated, e.g.
+//     "10.0.0.0/8"); the client IP is then taken from the X-Forwarded-For they set
+//   - CLIENT_IP_HEADER names a header the platform sets to the client IP (e.g. "CF-Connecting-IP"),
+//     trusted as is: only set it when every request goes through that platform
+//
+// Without either, the peer address is the client IP and forwarding headers are ignored.
+func LoadTrustedProxies(router *gin.Engine) error {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	router.TrustedPlatform = strings.TrimSpace(os.Getenv("CLIENT_IP_HEADER"))
+	return nil
+}
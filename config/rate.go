@@ -1,19 +1,218 @@
 package config
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-func RateLimit(rps int) gin.HandlerFunc {
-	limiter := rate.NewLimiter(rate.Limit(rps), rps)
+// RateRule allows Requests per Period to each client, in bursts of up to Requests.
+// A zero rule does not limit.
+type RateRule struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (r RateRule) String() string {
+	if r.Requests == 0 {
+		return "off"
+	}
+	unit := map[time.Duration]string{time.Second: "s", time.Minute: "m", time.Hour: "h"}[r.Period]
+	return fmt.Sprintf("%d/%s", r.Requests, unit)
+}
+
+// ParseRateRule reads a rule written "<requests>/<s|m|h>", such as "20/s" or "10/m", or "off".
+func ParseRateRule(text string) (RateRule, error) {
+	text = strings.TrimSpace(text)
+	if text == "off" {
+		return RateRule{}, nil
+	}
+	count, unit, found := strings.Cut(text, "/")
+	requests, err := strconv.Atoi(count)
+	if !found || err != nil || requests <= 0 {
+		return RateRule{}, fmt.Errorf("'%s' is not of the form <requests>/<s|m|h>", text)
+	}
+	periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	period, ok := periods[unit]
+	if !ok {
+		return RateRule{}, fmt.Errorf("'%s': unknown period '%s' (use s, m or h)", text, unit)
+	}
+	return RateRule{Requests: requests, Period: period}, nil
+}
+
+// RateLimits are the rules of the rate limiters, by name. "default" applies to every request;
+// a route group with its own rule (see RateLimitGroup) is limited by that rule instead. Each can be
+// overridden with RATE_LIMIT_<NAME> (e.g. RATE_LIMIT_LOGIN=5/m), see LoadRateLimits.
+var RateLimits = map[string]RateRule{
+	"default": {Requests: 20, Period: time.Second},
+	"login":   {Requests: 10, Period: time.Minute}, // Login and token refresh
+	"orders":  {Requests: 50, Period: time.Second}, // Order creation and edits: a busy counter at rush hour
+}
+
+// LoadRateLimits overrides RateLimits from the RATE_LIMIT_<NAME> environment variables.
+// It must run before the routes are registered.
+func LoadRateLimits() error {
+	for name := range RateLimits {
+		value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(name))
+		if value == "" {
+			continue
+		}
+		rule, err := ParseRateRule(value)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_%s: %w", strings.ToUpper(name), err)
+		}
+		RateLimits[name] = rule
+	}
+	return nil
+}
+
+// rateNow is the clock of the rate limiters, replaced in tests.
+var rateNow = time.Now
+
+// rateIdleTTL is how long a client's bucket is kept without requests. A bucket idle for longer
+// than its rule's period is full again, so dropping it changes nothing for the client.
+var rateIdleTTL = 10 * time.Minute
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter keeps one token bucket per client key. Buckets idle for longer than idleTTL are
+// evicted, checked at most once per idleTTL.
+type rateLimiter struct {
+	rule      RateRule
+	idleTTL   time.Duration
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+func newRateLimiter(rule RateRule) *rateLimiter {
+	return &rateLimiter{rule: rule, idleTTL: max(rateIdleTTL, rule.Period), clients: make(map[string]*rateClient), lastSweep: rateNow()}
+}
+
+// bucket returns the bucket of a client, creating it full, and evicts the idle ones.
+func (l *rateLimiter) bucket(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.idleTTL {
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) >= l.idleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[key]
+	if !ok {
+		every := rate.Every(l.rule.Period / time.Duration(l.rule.Requests))
+		client = &rateClient{limiter: rate.NewLimiter(every, l.rule.Requests)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	return client.limiter
+}
+
+// size returns the number of clients with a bucket.
+func (l *rateLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.clients)
+}
+
+// RateLimit limits each client, as identified by key (e.g. user ID or IP), to the rule named name
+// in RateLimits. Used globally with "default", it skips the routes of the groups with their own
+// limit (see RateLimitGroup). Responses carry X-RateLimit-Limit (requests per period),
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is full again); refused
+// requests get 429 with Retry-After.
+func RateLimit(name string, key func(*gin.Context) string) gin.HandlerFunc {
+	rule, ok := RateLimits[name]
+	if !ok {
+		panic("unknown rate limit '" + name + "'")
+	}
+	var limiter *rateLimiter
+	if rule.Requests > 0 {
+		limiter = newRateLimiter(rule)
+	}
+
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too Many Requests"})
-			return
+		if name != "default" || !groupRateLimited(c) {
+			limitClient(c, rule, limiter, key)
 		}
 		c.Next()
 	}
 }
+
+// groupRoutes holds the routes ("<method> <path>") of the groups with their own limit, which the
+// default limiter skips. Filled by RateLimitGroup while the routes are registered.
+var groupRoutes = struct {
+	sync.RWMutex
+	routes map[string]bool
+}{routes: make(map[string]bool)}
+
+// RateLimitGroup limits the routes of group to the rule named name instead of the default one.
+// The limit is added to the group's handlers before register runs; register adds the group's
+// routes (and any further middleware), which are then recorded for the default limiter to skip.
+func RateLimitGroup(router *gin.Engine, group *gin.RouterGroup, name string, key func(*gin.Context) string, register func()) {
+	group.Use(RateLimit(name, key))
+
+	before := make(map[string]bool)
+	for _, route := range router.Routes() {
+		before[route.Method+" "+route.Path] = true
+	}
+	register()
+
+	groupRoutes.Lock()
+	defer groupRoutes.Unlock()
+	for _, route := range router.Routes() {
+		if id := route.Method + " " + route.Path; !before[id] {
+			groupRoutes.routes[id] = true
+		}
+	}
+}
+
+// groupRateLimited reports whether the route of a request belongs to a group with its own limit.
+func groupRateLimited(c *gin.Context) bool {
+	groupRoutes.RLock()
+	defer groupRoutes.RUnlock()
+	return groupRoutes.routes[c.Request.Method+" "+c.FullPath()]
+}
+
+// limitClient takes a token from the client's bucket, or aborts with 429 when it is empty.
+// A nil limiter does not limit.
+func limitClient(c *gin.Context, rule RateRule, limiter *rateLimiter, key func(*gin.Context) string) {
+	if limiter == nil {
+		return
+	}
+	now := rateNow()
+	bucket := limiter.bucket(key(c), now)
+	reservation := bucket.ReserveN(now, 1)
+	if wait := reservation.DelayFrom(now); wait > 0 {
+		reservation.CancelAt(now)
+		setRateHeaders(c, rule, bucket, now)
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too Many Requests"})
+		return
+	}
+	setRateHeaders(c, rule, bucket, now)
+}
+
+func setRateHeaders(c *gin.Context, rule RateRule, bucket *rate.Limiter, now time.Time) {
+	tokens := max(bucket.TokensAt(now), 0)
+	missing := float64(rule.Requests) - tokens
+	reset := math.Ceil(missing / float64(bucket.Limit()))
+	c.Header("X-RateLimit-Limit", rule.String())
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Floor(tokens))))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(reset)))
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// freezeRateClock sets the clock of the rate limiters for the duration of a test.
+func freezeRateClock(t *testing.T, at time.Time) {
+	t.Helper()
+	previous := rateNow
+	rateNow = func() time.Time { return at }
+	t.Cleanup(func() { rateNow = previous })
+}
+
+// withRateLimits replaces the rules of the rate limiters for the duration of a test.
+func withRateLimits(t *testing.T, rules map[string]RateRule) {
+	t.Helper()
+	previous := RateLimits
+	RateLimits = rules
+	t.Cleanup(func() { RateLimits = previous })
+}
+
+// headerKey identifies clients by the X-Client header.
+func headerKey(c *gin.Context) string {
+	return c.GetHeader("X-Client")
+}
+
+func rateRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit("default", headerKey))
+	r.GET("/menu", func(c *gin.Context) { c.Status(http.StatusOK) })
+	login := r.Group("/login")
+	RateLimitGroup(r, login, "login", headerKey, func() {
+		login.POST("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	})
+	r.GET("/login", func(c *gin.Context) { c.Status(http.StatusOK) }) // Same path, outside the group
+	return r
+}
+
+func request(r *gin.Engine, method, path, client string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Client", client)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestParseRateRule(t *testing.T) {
+	tests := []struct {
+		text string
+		want RateRule
+		ok   bool
+	}{
+		{"20/s", RateRule{20, time.Second}, true},
+		{" 10/m ", RateRule{10, time.Minute}, true},
+		{"1000/h", RateRule{1000, time.Hour}, true},
+		{"off", RateRule{}, true},
+		{"20", RateRule{}, false},
+		{"0/s", RateRule{}, false},
+		{"-1/s", RateRule{}, false},
+		{"20/d", RateRule{}, false},
+		{"a/s", RateRule{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateRule(tt.text)
+		if !tt.ok {
+			assert.Error(t, err, tt.text)
+			continue
+		}
+		assert.NoError(t, err, tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+	}
+	assert.Equal(t, "10/m", RateRule{10, time.Minute}.String())
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	withRateLimits(t, map[string]RateRule{"default": {20, time.Second}, "login": {10, time.Minute}})
+
+	t.Setenv("RATE_LIMIT_LOGIN", "5/m")
+	assert.NoError(t, LoadRateLimits())
+	assert.Equal(t, RateRule{5, time.Minute}, RateLimits["login"])
+	assert.Equal(t, RateRule{20, time.Second}, RateLimits["default"], "unset variables keep the default")
+
+	t.Setenv("RATE_LIMIT_DEFAULT", "fast")
+	assert.ErrorContains(t, LoadRateLimits(), "RATE_LIMIT_DEFAULT")
+}
+
+func TestRateLimit_PerClient(t *testing.T) {
+	withRateLimits(t, map[string]RateRule{"default": {2, time.Second}, "login": {1, time.Minute}})
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	freezeRateClock(t, start)
+	r := rateRouter()
+
+	w := request(r, "GET", "/menu", "kiosk-1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2/s", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	w = request(r, "GET", "/menu", "kiosk-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// A busy client does not lock the others out
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-2").Code)
+
+	// Tokens come back over time
+	freezeRateClock(t, start.Add(500*time.Millisecond))
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(r, "GET", "/menu", "kiosk-1").Code)
+}
+
+func TestRateLimit_RouteGroup(t *testing.T) {
+	withRateLimits(t, map[string]RateRule{"default": {2, time.Second}, "login": {3, time.Minute}})
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	freezeRateClock(t, start)
+	r := rateRouter()
+
+	// The group's rule replaces the default one: three logins although the default allows two
+	for i := 0; i < 3; i++ {
+		w := request(r, "POST", "/login", "kiosk-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3/m", w.Header().Get("X-RateLimit-Limit"))
+	}
+	w := request(r, "POST", "/login", "kiosk-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Reset"))
+
+	// Logins did not use up the default limit
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(r, "GET", "/menu", "kiosk-1").Code)
+
+	// Routes outside the group keep the default limit, even on the group's path
+	w = request(r, "GET", "/login", "kiosk-2")
+	assert.Equal(t, "2/s", w.Header().Get("X-RateLimit-Limit"))
+
+	// Nor does the default limit hold logins back
+	freezeRateClock(t, start.Add(20*time.Second))
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+	assert.Equal(t, http.StatusOK, request(r, "POST", "/login", "kiosk-1").Code)
+}
+
+func TestRateLimit_Off(t *testing.T) {
+	withRateLimits(t, map[string]RateRule{"default": {1, time.Minute}, "login": {}})
+	freezeRateClock(t, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	r := rateRouter()
+
+	for i := 0; i < 5; i++ {
+		w := request(r, "POST", "/login", "kiosk-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	}
+	assert.Equal(t, http.StatusOK, request(r, "GET", "/menu", "kiosk-1").Code)
+}
+
+func TestRateLimit_EvictsIdleClients(t *testing.T) {
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	freezeRateClock(t, start)
+	limiter := newRateLimiter(RateRule{1, time.Second})
+
+	limiter.bucket("kiosk-1", start)
+	limiter.bucket("kiosk-2", start.Add(rateIdleTTL/2))
+	assert.Equal(t, 2, limiter.size())
+
+	// kiosk-1 has been idle for the TTL; kiosk-2 has not
+	limiter.bucket("kiosk-3", start.Add(rateIdleTTL))
+	assert.Equal(t, 2, limiter.size())
+	_, kept := limiter.clients["kiosk-2"]
+	assert.True(t, kept)
+
+	// Rules refilling slower than the TTL keep buckets until they are full again
+	slow := newRateLimiter(RateRule{1, time.Hour})
+	slow.bucket("kiosk-1", start)
+	slow.bucket("kiosk-2", start.Add(rateIdleTTL))
+	assert.Equal(t, 2, slow.size())
+	slow.bucket("kiosk-2", start.Add(time.Hour))
+	assert.Equal(t, 1, slow.size())
+}
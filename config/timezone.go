@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// Location is the restaurant's timezone. Availability schedules and promotion time windows are read in it,
+// so "07:00" means seven in the morning at the restaurant whatever the server's clock is set to.
+var Location = time.Local
+
+// LoadLocation sets Location from RESTAURANT_TIMEZONE, an IANA name such as "Europe/Paris".
+// When it is not set the server's local timezone is kept.
+func LoadLocation() error {
+	name := os.Getenv("RESTAURANT_TIMEZONE")
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	Location = loc
+	return nil
+}
@@ -0,0 +1,818 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CatalogVersion is the version of the catalog format written by ExportCatalog.
+// ImportCatalog refuses files of another version.
+const CatalogVersion = 1
+
+// Catalog is the exchange format of the catalog. Everything is referenced by name (categories, products and
+// menus by their unique name, options by name within their product, values by value within their option),
+// so a file exported from one installation imports into another whatever their IDs.
+// Stock is not part of the catalog: it goes through the stock ledger of each installation.
+type Catalog struct {
+	Version    int               `json:"version"`
+	ExportedAt *time.Time        `json:"exported_at,omitempty"`
+	Categories []CatalogCategory `json:"categories"`
+	Products   []CatalogProduct  `json:"products"`
+	Menus      []CatalogMenu     `json:"menus"`
+}
+
+// CatalogCategory is a category in a Catalog. VAT rates left out keep their current value (the defaults for a new category).
+type CatalogCategory struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	DisplayOrder    uint   `json:"display_order"`
+	ImageURL        string `json:"image_url"`
+	TaxRateOnSite   *uint  `json:"tax_rate_on_site"`
+	TaxRateTakeaway *uint  `json:"tax_rate_takeaway"`
+}
+
+// CatalogProduct is a product in a Catalog, with its options. is_available is false only for a product
+// switched off by hand; left out it keeps its current value (available for a new product).
+// The VAT rates are the product's overrides: null follows the category.
+type CatalogProduct struct {
+	Name              string          `json:"name"`
+	Category          string          `json:"category"` // Category name
+	Description       string          `json:"description"`
+	Price             models.Money    `json:"price"`
+	IsAvailable       *bool           `json:"is_available"`
+	ImageURL          string          `json:"image_url"`
+	PreparationTime   uint            `json:"preparation_time"`
+	LowStockThreshold uint            `json:"low_stock_threshold"`
+	TaxRateOnSite     *uint           `json:"tax_rate_on_site"`
+	TaxRateTakeaway   *uint           `json:"tax_rate_takeaway"`
+	Options           []CatalogOption `json:"options,omitempty"`
+}
+
+// CatalogOption is an option group of a product in a Catalog.
+type CatalogOption struct {
+	Name       string                 `json:"name"`
+	IsUnique   models.OptionSelection `json:"is_unique"`
+	IsRequired bool                   `json:"is_required"`
+	Values     []CatalogOptionValue   `json:"values"`
+}
+
+// CatalogOptionValue is a value of an option group in a Catalog.
+type CatalogOptionValue struct {
+	Value string       `json:"value"`
+	Price models.Money `json:"price"`
+}
+
+// CatalogMenu is a menu in a Catalog, with its components. is_available works as for products.
+type CatalogMenu struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Price       models.Money         `json:"price"`
+	IsAvailable *bool                `json:"is_available"`
+	Products    []CatalogMenuProduct `json:"products"`
+}
+
+// CatalogMenuProduct is a component of a menu in a Catalog. Quantity defaults to 1.
+type CatalogMenuProduct struct {
+	Product      string              `json:"product"` // Product name
+	Quantity     uint                `json:"quantity"`
+	IsOptional   bool                `json:"is_optional"`
+	DisplayOrder uint                `json:"display_order"`
+	Substitutes  []CatalogSubstitute `json:"substitutes,omitempty"`
+}
+
+// CatalogSubstitute is an allowed replacement of an optional menu component in a Catalog.
+type CatalogSubstitute struct {
+	Product    string       `json:"product"` // Product name
+	PriceDelta models.Money `json:"price_delta"`
+}
+
+// CatalogChange is one row an import creates or updates. Fields lists the columns an update changes.
+type CatalogChange struct {
+	Entity string   `json:"entity"` // category, product, option, option_value, menu, menu_product or menu_substitute
+	Key    string   `json:"key"`    // Natural key, e.g. "Big Mac Menu / Fries"
+	Action string   `json:"action"` // create or update
+	Fields []string `json:"fields,omitempty"`
+}
+
+// CatalogRowError is a row of an import that cannot be applied, with the reason.
+type CatalogRowError struct {
+	Row   string `json:"row"` // Where the row is, e.g. "products[2].options[0]" or "line 5" for CSV
+	Error string `json:"error"`
+}
+
+// CatalogImportReport is the result of ImportCatalog: what the import changes (or would change, in a dry run),
+// or the rows that prevent it. Rows that would not change anything are only counted.
+type CatalogImportReport struct {
+	Error     string            `json:"error,omitempty"`
+	DryRun    bool              `json:"dry_run"`
+	Applied   bool              `json:"applied"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Changes   []CatalogChange   `json:"changes"`
+	Errors    []CatalogRowError `json:"errors"`
+}
+
+// catalogImport applies a Catalog inside a transaction and fills in the report as it goes.
+type catalogImport struct {
+	tx       *gorm.DB
+	userID   *uint
+	report   *CatalogImportReport
+	products []uint // IDs of the products imported, to re-evaluate their availability
+	menus    []uint
+	prices   duePrices // Scheduled prices that came due, compared with as the prices in effect
+}
+
+// errCatalogRejected and errCatalogDryRun roll back the import transaction.
+var (
+	errCatalogRejected = errors.New("catalog import rejected")
+	errCatalogDryRun   = errors.New("catalog import dry run")
+)
+
+func (imp *catalogImport) fail(row, format string, args ...interface{}) {
+	imp.report.Errors = append(imp.report.Errors, CatalogRowError{Row: row, Error: fmt.Sprintf(format, args...)})
+}
+
+// changes compares the columns of a stored row with their imported values, and returns the ones that differ.
+func changes(columns map[string][2]interface{}) map[string]interface{} {
+	diff := map[string]interface{}{}
+	for column, values := range columns {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			diff[column] = values[1]
+		}
+	}
+	return diff
+}
+
+// save creates a row (when it has no ID yet) or applies the changed columns to it, and records the change.
+func (imp *catalogImport) save(entity, key string, row interface{}, isNew bool, diff map[string]interface{}) error {
+	if isNew {
+		if err := imp.tx.Create(row).Error; err != nil {
+			return err
+		}
+		imp.report.Created++
+		imp.report.Changes = append(imp.report.Changes, CatalogChange{Entity: entity, Key: key, Action: "create"})
+		return nil
+	}
+	if len(diff) == 0 {
+		imp.report.Unchanged++
+		return nil
+	}
+	if err := imp.tx.Model(row).Updates(diff).Error; err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(diff))
+	for column := range diff {
+		fields = append(fields, column)
+	}
+	sort.Strings(fields)
+	imp.report.Updated++
+	imp.report.Changes = append(imp.report.Changes, CatalogChange{Entity: entity, Key: key, Action: "update", Fields: fields})
+	return nil
+}
+
+// availability returns the stored flag and reason for an imported is_available, given the current ones.
+// Left out, the current state is kept; true clears a manual switch-off (stock decides the rest).
+func availability(imported *bool, isAvailable bool, reason string) (bool, string) {
+	switch {
+	case imported == nil:
+		return isAvailable, reason
+	case !*imported:
+		return false, models.UnavailableManual
+	case reason == models.UnavailableOutOfStock:
+		return false, reason
+	}
+	return true, ""
+}
+
+// duplicate reports whether a name was already seen in the same list (names are compared as stored).
+func duplicate(seen map[string]bool, name string) bool {
+	if seen[name] {
+		return true
+	}
+	seen[name] = true
+	return false
+}
+
+func (imp *catalogImport) category(row string, in CatalogCategory) error {
+	if !taxRatesValid(in.TaxRateOnSite, in.TaxRateTakeaway) {
+		imp.fail(row, "tax rates are in basis points and cannot exceed 10000 (100%%)")
+		return nil
+	}
+
+	var category models.Category
+	err := imp.tx.Where("name = ?", in.Name).First(&category).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if isNew {
+		category = models.Category{Name: in.Name, TaxRateOnSite: models.DefaultTaxRateOnSite, TaxRateTakeaway: models.DefaultTaxRateTakeaway}
+	}
+	onSite, takeaway := category.TaxRateOnSite, category.TaxRateTakeaway
+	if in.TaxRateOnSite != nil {
+		onSite = *in.TaxRateOnSite
+	}
+	if in.TaxRateTakeaway != nil {
+		takeaway = *in.TaxRateTakeaway
+	}
+
+	diff := changes(map[string][2]interface{}{
+		"description":       {category.Description, in.Description},
+		"display_order":     {category.DisplayOrder, in.DisplayOrder},
+		"image_url":         {category.ImageURL, in.ImageURL},
+		"tax_rate_on_site":  {category.TaxRateOnSite, onSite},
+		"tax_rate_takeaway": {category.TaxRateTakeaway, takeaway},
+	})
+	if isNew {
+		category.Description, category.DisplayOrder, category.ImageURL = in.Description, in.DisplayOrder, in.ImageURL
+		category.TaxRateOnSite, category.TaxRateTakeaway = onSite, takeaway
+	}
+	if err := imp.save("category", in.Name, &category, isNew, diff); err != nil {
+		return err
+	}
+	if isNew && (onSite == 0 || takeaway == 0) {
+		// gorm applies the column defaults in place of a zero rate on create
+		return imp.tx.Model(&category).Updates(map[string]interface{}{"tax_rate_on_site": onSite, "tax_rate_takeaway": takeaway}).Error
+	}
+	return nil
+}
+
+func (imp *catalogImport) product(row string, in CatalogProduct) error {
+	if in.Price < 0 {
+		imp.fail(row, "price cannot be negative")
+		return nil
+	}
+	if !taxRatesValid(in.TaxRateOnSite, in.TaxRateTakeaway) {
+		imp.fail(row, "tax rates are in basis points and cannot exceed 10000 (100%%)")
+		return nil
+	}
+	var category models.Category
+	if err := imp.tx.Where("name = ?", in.Category).First(&category).Error; err != nil {
+		imp.fail(row, "category '%s' not found", in.Category)
+		return nil
+	}
+
+	var product models.Products
+	err := imp.tx.Where("name = ?", in.Name).First(&product).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if isNew {
+		product = models.Products{Name: in.Name, IsAvailable: true}
+	}
+	imp.prices.product(&product)
+	isAvailable, reason := availability(in.IsAvailable, product.IsAvailable, product.UnavailableReason)
+
+	diff := changes(map[string][2]interface{}{
+		"category_id":         {product.CategoryID, category.ID},
+		"description":         {product.Description, in.Description},
+		"price":               {product.Price, in.Price},
+		"is_available":        {product.IsAvailable, isAvailable},
+		"unavailable_reason":  {product.UnavailableReason, reason},
+		"image_url":           {product.ImageURL, in.ImageURL},
+		"preparation_time":    {product.PreparationTime, in.PreparationTime},
+		"low_stock_threshold": {product.LowStockThreshold, in.LowStockThreshold},
+		"tax_rate_on_site":    {product.TaxRateOnSite, in.TaxRateOnSite},
+		"tax_rate_takeaway":   {product.TaxRateTakeaway, in.TaxRateTakeaway},
+	})
+	if isNew {
+		product.CategoryID, product.Description, product.Price = category.ID, in.Description, in.Price
+		product.IsAvailable, product.UnavailableReason = isAvailable, reason
+		product.ImageURL, product.PreparationTime, product.LowStockThreshold = in.ImageURL, in.PreparationTime, in.LowStockThreshold
+		product.TaxRateOnSite, product.TaxRateTakeaway = in.TaxRateOnSite, in.TaxRateTakeaway
+	}
+	if err := imp.save("product", in.Name, &product, isNew, diff); err != nil {
+		return err
+	}
+	if _, priced := diff["price"]; isNew || priced {
+		if err := recordPrice(imp.tx, models.PriceChange{ProductID: &product.ID, Price: in.Price}, imp.userID); err != nil {
+			return err
+		}
+	}
+	imp.products = append(imp.products, product.ID)
+
+	seen := map[string]bool{}
+	for i, option := range in.Options {
+		optionRow := fmt.Sprintf("%s.options[%d]", row, i)
+		switch {
+		case option.Name == "":
+			imp.fail(optionRow, "name is required")
+		case duplicate(seen, option.Name):
+			imp.fail(optionRow, "option '%s' appears twice for product '%s'", option.Name, in.Name)
+		case !option.IsUnique.Valid():
+			imp.fail(optionRow, "is_unique must be 'single' or 'multiple'")
+		default:
+			if err := imp.option(optionRow, product, option); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (imp *catalogImport) option(row string, product models.Products, in CatalogOption) error {
+	key := product.Name + " / " + in.Name
+
+	var option models.ProductOptions
+	err := imp.tx.Where("product_id = ? AND name = ?", product.ID, in.Name).First(&option).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	diff := changes(map[string][2]interface{}{
+		"is_unique":   {option.IsUnique, in.IsUnique},
+		"is_required": {option.IsRequired, in.IsRequired},
+	})
+	if isNew {
+		option = models.ProductOptions{ProductID: product.ID, Name: in.Name, IsUnique: in.IsUnique, IsRequired: in.IsRequired}
+	}
+	if err := imp.save("option", key, &option, isNew, diff); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for i, value := range in.Values {
+		valueRow := fmt.Sprintf("%s.values[%d]", row, i)
+		switch {
+		case value.Value == "":
+			imp.fail(valueRow, "value is required")
+		case duplicate(seen, value.Value):
+			imp.fail(valueRow, "value '%s' appears twice for option '%s'", value.Value, key)
+		default:
+			if err := imp.optionValue(option, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (imp *catalogImport) optionValue(option models.ProductOptions, optionKey string, in CatalogOptionValue) error {
+	var value models.OptionValues
+	err := imp.tx.Where("option_id = ? AND value = ?", option.ID, in.Value).First(&value).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	imp.prices.optionValue(&value)
+	diff := changes(map[string][2]interface{}{
+		"option_price": {value.OptionPrice, in.Price},
+	})
+	if isNew {
+		value = models.OptionValues{OptionID: option.ID, Value: in.Value, OptionPrice: in.Price}
+	}
+	if err := imp.save("option_value", optionKey+" / "+in.Value, &value, isNew, diff); err != nil {
+		return err
+	}
+	if _, priced := diff["option_price"]; isNew || priced {
+		return recordPrice(imp.tx, models.PriceChange{OptionValueID: &value.ID, Price: in.Price}, imp.userID)
+	}
+	return nil
+}
+
+func (imp *catalogImport) menu(row string, in CatalogMenu) error {
+	if in.Price < 0 {
+		imp.fail(row, "price cannot be negative")
+		return nil
+	}
+
+	var menu models.Menu
+	err := imp.tx.Where("name = ?", in.Name).First(&menu).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if isNew {
+		menu = models.Menu{Name: in.Name, IsAvailable: true}
+	}
+	imp.prices.menu(&menu)
+	isAvailable, reason := availability(in.IsAvailable, menu.IsAvailable, menu.UnavailableReason)
+
+	diff := changes(map[string][2]interface{}{
+		"description":        {menu.Description, in.Description},
+		"price":              {menu.Price, in.Price},
+		"is_available":       {menu.IsAvailable, isAvailable},
+		"unavailable_reason": {menu.UnavailableReason, reason},
+	})
+	if isNew {
+		menu.Description, menu.Price = in.Description, in.Price
+		menu.IsAvailable, menu.UnavailableReason = isAvailable, reason
+	}
+	if err := imp.save("menu", in.Name, &menu, isNew, diff); err != nil {
+		return err
+	}
+	if isNew && !isAvailable {
+		// gorm applies the column default (true) in place of false on create
+		if err := imp.tx.Model(&menu).Update("is_available", false).Error; err != nil {
+			return err
+		}
+	}
+	if _, priced := diff["price"]; isNew || priced {
+		if err := recordPrice(imp.tx, models.PriceChange{MenuID: &menu.ID, Price: in.Price}, imp.userID); err != nil {
+			return err
+		}
+	}
+	imp.menus = append(imp.menus, menu.ID)
+
+	seen := map[string]bool{}
+	for i, component := range in.Products {
+		componentRow := fmt.Sprintf("%s.products[%d]", row, i)
+		if duplicate(seen, component.Product) {
+			imp.fail(componentRow, "product '%s' appears twice in menu '%s'", component.Product, in.Name)
+			continue
+		}
+		if err := imp.menuProduct(componentRow, menu, component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *catalogImport) menuProduct(row string, menu models.Menu, in CatalogMenuProduct) error {
+	var product models.Products
+	if err := imp.tx.Where("name = ?", in.Product).First(&product).Error; err != nil {
+		imp.fail(row, "product '%s' not found", in.Product)
+		return nil
+	}
+	if len(in.Substitutes) > 0 && !in.IsOptional {
+		imp.fail(row, "only optional menu components can be replaced")
+		return nil
+	}
+	if in.Quantity == 0 {
+		in.Quantity = 1
+	}
+	key := menu.Name + " / " + in.Product
+
+	var mp models.MenuProduct
+	err := imp.tx.Where("menu_id = ? AND product_id = ?", menu.ID, product.ID).First(&mp).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	diff := changes(map[string][2]interface{}{
+		"quantity":      {mp.Quantity, in.Quantity},
+		"is_optional":   {mp.IsOptional, in.IsOptional},
+		"display_order": {mp.DisplayOrder, in.DisplayOrder},
+	})
+	if isNew {
+		mp = models.MenuProduct{MenuID: menu.ID, ProductID: product.ID, Quantity: in.Quantity, IsOptional: in.IsOptional, DisplayOrder: in.DisplayOrder}
+	}
+	if err := imp.save("menu_product", key, &mp, isNew, diff); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for i, sub := range in.Substitutes {
+		subRow := fmt.Sprintf("%s.substitutes[%d]", row, i)
+		var replacement models.Products
+		switch {
+		case duplicate(seen, sub.Product):
+			imp.fail(subRow, "substitute '%s' appears twice for '%s'", sub.Product, key)
+		case imp.tx.Where("name = ?", sub.Product).First(&replacement).Error != nil:
+			imp.fail(subRow, "product '%s' not found", sub.Product)
+		case replacement.ID == product.ID:
+			imp.fail(subRow, "a product cannot replace itself")
+		default:
+			if err := imp.menuSubstitute(mp, key, replacement, sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (imp *catalogImport) menuSubstitute(mp models.MenuProduct, componentKey string, product models.Products, in CatalogSubstitute) error {
+	var sub models.MenuProductSubstitute
+	err := imp.tx.Where("menu_product_id = ? AND product_id = ?", mp.ID, product.ID).First(&sub).Error
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	diff := changes(map[string][2]interface{}{
+		"price_delta": {sub.PriceDelta, in.PriceDelta},
+	})
+	if isNew {
+		sub = models.MenuProductSubstitute{MenuProductID: mp.ID, ProductID: product.ID, PriceDelta: in.PriceDelta}
+	}
+	return imp.save("menu_substitute", componentKey+" / "+product.Name, &sub, isNew, diff)
+}
+
+// run imports a catalog: categories, then products with their options, then menus with their components,
+// so each row can refer to rows earlier in the file. Invalid rows are reported and skipped; the caller
+// rolls back when any row was reported.
+func (imp *catalogImport) run(catalog Catalog, rows catalogRows) error {
+	// A scheduled price that came due is the price in effect: importing another one supersedes it
+	var err error
+	if imp.prices, err = loadDuePrices(imp.tx, now()); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for i, category := range catalog.Categories {
+		row := rows.label("categories", i)
+		switch {
+		case category.Name == "":
+			imp.fail(row, "name is required")
+		case duplicate(seen, category.Name):
+			imp.fail(row, "category '%s' appears twice", category.Name)
+		default:
+			if err := imp.category(row, category); err != nil {
+				return err
+			}
+		}
+	}
+
+	seen = map[string]bool{}
+	for i, product := range catalog.Products {
+		row := rows.label("products", i)
+		switch {
+		case product.Name == "":
+			imp.fail(row, "name is required")
+		case duplicate(seen, product.Name):
+			imp.fail(row, "product '%s' appears twice", product.Name)
+		default:
+			if err := imp.product(row, product); err != nil {
+				return err
+			}
+		}
+	}
+
+	seen = map[string]bool{}
+	for i, menu := range catalog.Menus {
+		row := rows.label("menus", i)
+		switch {
+		case menu.Name == "":
+			imp.fail(row, "name is required")
+		case duplicate(seen, menu.Name):
+			imp.fail(row, "menu '%s' appears twice", menu.Name)
+		default:
+			if err := imp.menu(row, menu); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Stock decides whether imported products and menus can really be ordered
+	for _, id := range imp.products {
+		if err := syncStockAvailability(imp.tx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range imp.menus {
+		if err := syncMenuAvailability(imp.tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catalogRows names the rows of an imported file in error messages: "products[2]" for JSON,
+// or the line of the CSV file (lines[i] is the line of the i-th product).
+type catalogRows struct {
+	lines []int
+}
+
+func (r catalogRows) label(list string, i int) string {
+	if r.lines != nil {
+		return "line " + strconv.Itoa(r.lines[i])
+	}
+	return fmt.Sprintf("%s[%d]", list, i)
+}
+
+// exportCatalog reads the whole catalog in the exchange format, at the prices in effect now.
+func exportCatalog(db *gorm.DB) (Catalog, error) {
+	exportedAt := now()
+	catalog := Catalog{Version: CatalogVersion, ExportedAt: &exportedAt}
+	prices, err := loadDuePrices(db, exportedAt)
+	if err != nil {
+		return catalog, err
+	}
+
+	var categories []models.Category
+	if err := db.Order("display_order, id").Find(&categories).Error; err != nil {
+		return catalog, err
+	}
+	categoryNames := make(map[uint]string, len(categories))
+	catalog.Categories = make([]CatalogCategory, 0, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+		onSite, takeaway := category.TaxRateOnSite, category.TaxRateTakeaway
+		catalog.Categories = append(catalog.Categories, CatalogCategory{
+			Name:            category.Name,
+			Description:     category.Description,
+			DisplayOrder:    category.DisplayOrder,
+			ImageURL:        category.ImageURL,
+			TaxRateOnSite:   &onSite,
+			TaxRateTakeaway: &takeaway,
+		})
+	}
+
+	var products []models.Products
+	if err := db.Order("id").Find(&products).Error; err != nil {
+		return catalog, err
+	}
+	var options []models.ProductOptions
+	if err := db.Order("id").Find(&options).Error; err != nil {
+		return catalog, err
+	}
+	var values []models.OptionValues
+	if err := db.Order("id").Find(&values).Error; err != nil {
+		return catalog, err
+	}
+	valuesByOption := make(map[uint][]CatalogOptionValue)
+	for _, value := range values {
+		prices.optionValue(&value)
+		valuesByOption[value.OptionID] = append(valuesByOption[value.OptionID], CatalogOptionValue{Value: value.Value, Price: value.OptionPrice})
+	}
+	optionsByProduct := make(map[uint][]CatalogOption)
+	for _, option := range options {
+		optionsByProduct[option.ProductID] = append(optionsByProduct[option.ProductID], CatalogOption{
+			Name:       option.Name,
+			IsUnique:   option.IsUnique,
+			IsRequired: option.IsRequired,
+			Values:     append([]CatalogOptionValue{}, valuesByOption[option.ID]...),
+		})
+	}
+	productNames := make(map[uint]string, len(products))
+	catalog.Products = make([]CatalogProduct, 0, len(products))
+	for _, product := range products {
+		prices.product(&product)
+		productNames[product.ID] = product.Name
+		catalog.Products = append(catalog.Products, catalogProduct(product, categoryNames[product.CategoryID], optionsByProduct[product.ID]))
+	}
+
+	var menus []models.Menu
+	if err := db.Preload("MenuProducts", func(db *gorm.DB) *gorm.DB { return db.Order("display_order, id") }).
+		Preload("MenuProducts.Substitutes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("id").Find(&menus).Error; err != nil {
+		return catalog, err
+	}
+	catalog.Menus = make([]CatalogMenu, 0, len(menus))
+	for _, menu := range menus {
+		prices.menu(&menu)
+		isAvailable := menu.UnavailableReason != models.UnavailableManual
+		out := CatalogMenu{Name: menu.Name, Description: menu.Description, Price: menu.Price, IsAvailable: &isAvailable, Products: []CatalogMenuProduct{}}
+		for _, mp := range menu.MenuProducts {
+			component := CatalogMenuProduct{Product: productNames[mp.ProductID], Quantity: mp.Quantity, IsOptional: mp.IsOptional, DisplayOrder: mp.DisplayOrder}
+			for _, sub := range mp.Substitutes {
+				component.Substitutes = append(component.Substitutes, CatalogSubstitute{Product: productNames[sub.ProductID], PriceDelta: sub.PriceDelta})
+			}
+			out.Products = append(out.Products, component)
+		}
+		catalog.Menus = append(catalog.Menus, out)
+	}
+	return catalog, nil
+}
+
+// catalogProduct converts a product to the exchange format. Only a manual switch-off is exported
+// as unavailable: running out of stock is not part of the catalog.
+func catalogProduct(product models.Products, category string, options []CatalogOption) CatalogProduct {
+	isAvailable := product.UnavailableReason != models.UnavailableManual
+	return CatalogProduct{
+		Name:              product.Name,
+		Category:          category,
+		Description:       product.Description,
+		Price:             product.Price,
+		IsAvailable:       &isAvailable,
+		ImageURL:          product.ImageURL,
+		PreparationTime:   product.PreparationTime,
+		LowStockThreshold: product.LowStockThreshold,
+		TaxRateOnSite:     product.TaxRateOnSite,
+		TaxRateTakeaway:   product.TaxRateTakeaway,
+		Options:           options,
+	}
+}
+
+// ExportCatalog downloads the catalog: categories, products with their options and values, and menus
+// with their components and substitutes, referenced by name (see Catalog). ?format=csv exports the
+// products only, one per line, without their options (see catalogCSVHeader).
+//
+// @Summary Export the catalog
+// @Description Download the catalog as versioned JSON, or the products as CSV
+// @Tags Catalog
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} Catalog
+// @Failure 400 {object} map[string]string "Unknown format"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /catalog/export [get]
+func ExportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be 'json' or 'csv'"})
+		return
+	}
+
+	catalog, err := exportCatalog(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export catalog"})
+		return
+	}
+
+	filename := "catalog-" + now().Format("2006-01-02")
+	if format == "csv" {
+		data, err := writeCatalogCSV(catalog.Products)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export catalog"})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	c.JSON(http.StatusOK, catalog)
+}
+
+// ImportCatalog loads a catalog file (see Catalog), or products as CSV when sent as text/csv
+// (see catalogCSVHeader). Rows are matched by name: missing ones are created, existing ones updated;
+// nothing is deleted. The whole file is applied in one transaction: if any row is invalid, nothing
+// is changed and every invalid row is reported. ?dry_run=true reports what would change without
+// changing anything. Price changes are recorded in the price history.
+//
+// @Summary Import the catalog
+// @Description Create or update categories, products, options, values, menus and menu products by name, in one transaction
+// @Tags Catalog
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param catalog body Catalog true "Catalog (JSON), or products as CSV"
+// @Param dry_run query bool false "Report the changes without applying them"
+// @Success 200 {object} CatalogImportReport
+// @Failure 400 {object} CatalogImportReport "Invalid file or rows, nothing changed"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /catalog/import [post]
+func ImportCatalog(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+	report := CatalogImportReport{DryRun: dryRun, Changes: []CatalogChange{}, Errors: []CatalogRowError{}}
+
+	var catalog Catalog
+	var rows catalogRows
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		products, lines, rowErrors, err := readCatalogCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		catalog = Catalog{Version: CatalogVersion, Products: products}
+		rows = catalogRows{lines: lines}
+		report.Errors = append(report.Errors, rowErrors...)
+	} else {
+		if err := c.ShouldBindJSON(&catalog); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+			return
+		}
+		if catalog.Version != CatalogVersion {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported catalog version %d, expected %d", catalog.Version, CatalogVersion)})
+			return
+		}
+	}
+
+	imp := &catalogImport{userID: currentUserID(c), report: &report}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		imp.tx = tx
+		if err := imp.run(catalog, rows); err != nil {
+			return err
+		}
+		if len(report.Errors) > 0 {
+			return errCatalogRejected
+		}
+		if dryRun {
+			return errCatalogDryRun
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errCatalogRejected):
+		report.Error = "Some rows are invalid, nothing was imported"
+		c.JSON(http.StatusBadRequest, report)
+		return
+	case err != nil && !errors.Is(err, errCatalogDryRun):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import catalog"})
+		return
+	}
+
+	report.Applied = !dryRun
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"wacdo/models"
+)
+
+// catalogCSVHeader lists the columns of the products CSV, in the order ExportCatalog writes them.
+// An import may put them in any order and leave out the optional ones; name, category and price are required.
+// Prices are in euros ("9.99"), is_available is true or false, and an empty tax rate follows the category.
+var catalogCSVHeader = []string{
+	"name", "category", "description", "price", "is_available", "image_url",
+	"preparation_time", "low_stock_threshold", "tax_rate_on_site", "tax_rate_takeaway",
+}
+
+// writeCatalogCSV writes products in the CSV format of catalogCSVHeader.
+func writeCatalogCSV(products []CatalogProduct) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(catalogCSVHeader); err != nil {
+		return nil, err
+	}
+
+	rate := func(r *uint) string {
+		if r == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*r), 10)
+	}
+	for _, p := range products {
+		isAvailable := p.IsAvailable == nil || *p.IsAvailable
+		record := []string{
+			p.Name, p.Category, p.Description, p.Price.String(), strconv.FormatBool(isAvailable), p.ImageURL,
+			strconv.FormatUint(uint64(p.PreparationTime), 10), strconv.FormatUint(uint64(p.LowStockThreshold), 10),
+			rate(p.TaxRateOnSite), rate(p.TaxRateTakeaway),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// readCatalogCSV reads products in the CSV format of catalogCSVHeader. It returns the products with the line
+// each one comes from, and the lines that cannot be read. The error is for a file that cannot be read at all.
+func readCatalogCSV(r io.Reader) ([]CatalogProduct, []int, []CatalogRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // short lines are reported with their line number below
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, nil, errors.New("Invalid CSV: a header line is required")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		known := false
+		for _, column := range catalogCSVHeader {
+			known = known || column == name
+		}
+		if !known {
+			return nil, nil, nil, fmt.Errorf("Invalid CSV: unknown column '%s'", name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, nil, nil, fmt.Errorf("Invalid CSV: column '%s' appears twice", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "category", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, nil, fmt.Errorf("Invalid CSV: column '%s' is required", required)
+		}
+	}
+
+	var products []CatalogProduct
+	var lines []int
+	var rowErrors []CatalogRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+			rowErrors = append(rowErrors, CatalogRowError{Row: "line " + strconv.Itoa(line), Error: "Invalid CSV line"})
+			continue
+		}
+		if len(record) != len(header) {
+			rowErrors = append(rowErrors, CatalogRowError{Row: "line " + strconv.Itoa(line), Error: fmt.Sprintf("expected %d fields, got %d", len(header), len(record))})
+			continue
+		}
+
+		product, err := catalogCSVProduct(record, columns)
+		if err != nil {
+			rowErrors = append(rowErrors, CatalogRowError{Row: "line " + strconv.Itoa(line), Error: err.Error()})
+			continue
+		}
+		products = append(products, product)
+		lines = append(lines, line)
+	}
+	if lines == nil {
+		lines = []int{} // non-nil, so catalogRows labels rows by line
+	}
+	return products, lines, rowErrors, nil
+}
+
+// catalogCSVProduct converts a CSV record to a product. Errors are meant to be shown to the user.
+func catalogCSVProduct(record []string, columns map[string]int) (CatalogProduct, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	count := func(name string) (uint, error) {
+		value := field(name)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be a whole number", name)
+		}
+		return uint(n), nil
+	}
+	rate := func(name string) (*uint, error) {
+		if field(name) == "" {
+			return nil, nil
+		}
+		n, err := count(name)
+		return &n, err
+	}
+
+	product := CatalogProduct{
+		Name:        field("name"),
+		Category:    field("category"),
+		Description: field("description"),
+		ImageURL:    field("image_url"),
+	}
+	price, err := models.ParseMoney(field("price"))
+	if err != nil {
+		return product, errors.New("price must be an amount in euros (e.g. 9.99)")
+	}
+	product.Price = price
+	if value := field("is_available"); value != "" {
+		isAvailable, err := strconv.ParseBool(value)
+		if err != nil {
+			return product, errors.New("is_available must be true or false")
+		}
+		product.IsAvailable = &isAvailable
+	}
+	if product.PreparationTime, err = count("preparation_time"); err != nil {
+		return product, err
+	}
+	if product.LowStockThreshold, err = count("low_stock_threshold"); err != nil {
+		return product, err
+	}
+	if product.TaxRateOnSite, err = rate("tax_rate_on_site"); err != nil {
+		return product, err
+	}
+	if product.TaxRateTakeaway, err = rate("tax_rate_takeaway"); err != nil {
+		return product, err
+	}
+	return product, nil
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func catalogRouter() *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(1, "admin"))
+	r.GET("/catalog/export", ExportCatalog)
+	r.POST("/catalog/import", ImportCatalog)
+	return r
+}
+
+func exportCatalogJSON(t *testing.T, r *gin.Engine) Catalog {
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/catalog/export", nil))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var catalog Catalog
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &catalog))
+	catalog.ExportedAt = nil
+	return catalog
+}
+
+func importCatalog(r *gin.Engine, query string, catalog interface{}) (int, CatalogImportReport) {
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/catalog/import"+query, catalog))
+	var report CatalogImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report
+}
+
+func csvRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/catalog/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	return req
+}
+
+func TestCatalog_RoundTrip(t *testing.T) {
+	db := testutils.SetupTestDB()
+	burgers := testutils.SeedCategory(db, "Burgers")
+	sides := testutils.SeedCategory(db, "Sides")
+	db.Model(&sides).Updates(map[string]interface{}{"display_order": 2, "tax_rate_takeaway": 1000})
+	bigMac := testutils.SeedProduct(db, "Big Mac", 5.20, burgers.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.50, sides.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, sides.ID, false)
+	db.Model(&salad).Update("unavailable_reason", models.UnavailableManual)
+	noVAT := uint(0)
+	db.Model(&fries).Update("tax_rate_on_site", &noVAT)
+	size := seedOptionDirect(bigMac.ID, "Size", string(models.OptionSingle))
+	seedOptionValue(size.ID, "Large", 0.80)
+	seedOptionValue(size.ID, "Small", 0)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.50, true)
+	testutils.SeedMenuProduct(db, menu.ID, bigMac.ID, 1, false)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: salad.ID, PriceDelta: 50})
+
+	r := catalogRouter()
+	exported := exportCatalogJSON(t, r)
+	assert.Equal(t, CatalogVersion, exported.Version)
+	assert.Equal(t, []string{"Burgers", "Sides"}, []string{exported.Categories[0].Name, exported.Categories[1].Name})
+	assert.Equal(t, uint(1000), *exported.Categories[1].TaxRateTakeaway)
+	assert.Len(t, exported.Products, 3)
+	assert.True(t, *exported.Products[0].IsAvailable)
+	assert.False(t, *exported.Products[2].IsAvailable, "switched off by hand")
+	assert.Equal(t, models.Money(80), exported.Products[0].Options[0].Values[0].Price)
+	assert.Equal(t, "Salad", exported.Menus[0].Products[1].Substitutes[0].Product)
+
+	// Imported into an empty installation, the catalog exports the same
+	testutils.SetupTestDB()
+	code, report := importCatalog(r, "", exported)
+	assert.Equal(t, http.StatusOK, code, "%+v", report)
+	assert.True(t, report.Applied)
+	assert.Equal(t, 12, report.Created) // 2 categories, 3 products, 1 option, 2 values, 1 menu, 2 components, 1 substitute
+	assert.Equal(t, exported, exportCatalogJSON(t, r))
+
+	var history int64
+	config.DB.Model(&models.PriceChange{}).Count(&history)
+	assert.Equal(t, int64(6), history, "opening prices of the products, values and menu")
+
+	// Importing it again changes nothing
+	code, report = importCatalog(r, "", exported)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 0, report.Created+report.Updated)
+	assert.Equal(t, 12, report.Unchanged)
+	assert.Empty(t, report.Changes)
+}
+
+func TestImportCatalog_DryRun(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	product := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+
+	r := catalogRouter()
+	catalog := exportCatalogJSON(t, r)
+	catalog.Products[0].Price = 550
+	catalog.Products[0].Description = "Two patties"
+	catalog.Products = append(catalog.Products, CatalogProduct{Name: "Cheeseburger", Category: "Burgers", Price: 250})
+
+	code, report := importCatalog(r, "?dry_run=true", catalog)
+	assert.Equal(t, http.StatusOK, code, "%+v", report)
+	assert.True(t, report.DryRun)
+	assert.False(t, report.Applied)
+	assert.Equal(t, []CatalogChange{
+		{Entity: "product", Key: "Big Mac", Action: "update", Fields: []string{"description", "price"}},
+		{Entity: "product", Key: "Cheeseburger", Action: "create"},
+	}, report.Changes)
+	assert.Equal(t, 1, report.Unchanged)
+
+	// Nothing was written
+	var count int64
+	config.DB.Model(&models.Products{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	config.DB.First(&product, product.ID)
+	assert.Equal(t, models.Money(500), product.Price)
+	config.DB.Model(&models.PriceChange{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	code, report = importCatalog(r, "", catalog)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, report.Applied)
+	config.DB.First(&product, product.ID)
+	assert.Equal(t, models.Money(550), product.Price)
+	assert.Equal(t, "Two patties", product.Description)
+	assert.Equal(t, uint(testutils.DefaultStock), product.StockQuantity, "stock is not part of the catalog")
+	config.DB.Model(&models.PriceChange{}).Where("product_id = ?", product.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "the new price is in the history")
+}
+
+func TestImportCatalog_RowErrorsRollBack(t *testing.T) {
+	db := testutils.SetupTestDB()
+	testutils.SeedCategory(db, "Burgers")
+
+	tooHigh := uint(20000)
+	catalog := Catalog{
+		Version:    CatalogVersion,
+		Categories: []CatalogCategory{{Name: "Drinks"}, {Name: "Desserts", TaxRateOnSite: &tooHigh}},
+		Products: []CatalogProduct{
+			{Name: "Coca-Cola", Category: "Drinks", Price: 200},
+			{Name: "Sundae", Category: "Ice creams", Price: 300},
+			{Name: "Big Mac", Category: "Burgers", Price: -1},
+			{Name: "Coca-Cola", Category: "Drinks", Price: 250},
+			{Name: "Fries", Category: "Burgers", Price: 250, Options: []CatalogOption{{Name: "Size", IsUnique: "some"}}},
+		},
+		Menus: []CatalogMenu{{Name: "Menu", Price: 900, Products: []CatalogMenuProduct{
+			{Product: "Coca-Cola", Substitutes: []CatalogSubstitute{{Product: "Fries"}}},
+			{Product: "Filet-O-Fish"},
+		}}},
+	}
+
+	r := catalogRouter()
+	code, report := importCatalog(r, "", catalog)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.NotEmpty(t, report.Error)
+	var rows []string
+	for _, rowError := range report.Errors {
+		rows = append(rows, rowError.Row)
+	}
+	assert.Equal(t, []string{"categories[1]", "products[1]", "products[2]", "products[3]", "products[4].options[0]", "menus[0].products[0]", "menus[0].products[1]"}, rows)
+
+	// The valid rows were rolled back with the rest
+	var count int64
+	config.DB.Model(&models.Category{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	config.DB.Model(&models.Products{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	code, _ = importCatalog(r, "", map[string]interface{}{"version": 2})
+	assert.Equal(t, http.StatusBadRequest, code, "unknown version")
+}
+
+func TestImportCatalog_Availability(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	product := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	testutils.SeedMenuProduct(db, menu.ID, product.ID, 1, false)
+
+	r := catalogRouter()
+	off, on := false, true
+	catalog := exportCatalogJSON(t, r)
+	catalog.Products[0].IsAvailable = &off
+	code, _ := importCatalog(r, "", catalog)
+	assert.Equal(t, http.StatusOK, code)
+	config.DB.First(&product, product.ID)
+	assert.False(t, product.IsAvailable)
+	assert.Equal(t, models.UnavailableManual, product.UnavailableReason)
+
+	// Switched back on, but out of stock: stock has the last word
+	testutils.SetStock(db, product.ID, 0)
+	catalog.Products[0].IsAvailable = &on
+	code, _ = importCatalog(r, "", catalog)
+	assert.Equal(t, http.StatusOK, code)
+	config.DB.First(&product, product.ID)
+	assert.Equal(t, models.UnavailableOutOfStock, product.UnavailableReason)
+	config.DB.First(&menu, menu.ID)
+	assert.Equal(t, models.UnavailableOutOfStock, menu.UnavailableReason)
+}
+
+func TestCatalog_CSV(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	testutils.SeedProduct(db, "Big Mac", 5.20, cat.ID, true)
+
+	r := catalogRouter()
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/catalog/export?format=csv", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, strings.Join(catalogCSVHeader, ",")+"\nBig Mac,Burgers,,5.20,true,,0,0,,\n", w.Body.String())
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/catalog/export?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Columns in any order; bad lines are reported by line number
+	body := "price,name,category,tax_rate_takeaway\n" +
+		"5.40,Big Mac,Burgers,\n" +
+		"2.5,Cheeseburger,Burgers,1000\n" +
+		"abc,Fries,Burgers,\n" +
+		"3.00,Sundae,Desserts,\n"
+	w = testutils.PerformRequest(r, csvRequest(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var report CatalogImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Equal(t, []CatalogRowError{
+		{Row: "line 4", Error: "price must be an amount in euros (e.g. 9.99)"},
+		{Row: "line 5", Error: "category 'Desserts' not found"},
+	}, report.Errors)
+
+	body = strings.Join(strings.Split(body, "\n")[:3], "\n")
+	w = testutils.PerformRequest(r, csvRequest(body))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var cheeseburger models.Products
+	config.DB.Where("name = ?", "Cheeseburger").First(&cheeseburger)
+	assert.Equal(t, models.Money(250), cheeseburger.Price)
+	assert.Equal(t, uint(1000), *cheeseburger.TaxRateTakeaway)
+	var bigMac models.Products
+	config.DB.Where("name = ?", "Big Mac").First(&bigMac)
+	assert.Equal(t, models.Money(540), bigMac.Price)
+
+	w = testutils.PerformRequest(r, csvRequest("name,colour\nBig Mac,red\n"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid CSV: unknown column 'colour'", testutils.ParseResponse(w)["error"])
+}
@@ -49,25 +49,45 @@ func CreateCustomer(c *gin.Context) {
 	c.JSON(http.StatusOK, customer)
 }
 
-// GetCustomers returns all customers. Supports GDPR right of consultation.
+// customerListSpec lists the sort fields accepted by GetCustomers.
+var customerListSpec = listSpec{
+	Sort: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	DefaultSort: "name ASC, id ASC",
+}
+
+// GetCustomers returns a page of customers. Supports GDPR right of consultation.
+// Paging and sorting: ?limit=, ?offset=, ?sort=name.
 //
 // @Summary Get all customers
-// @Description Retrieve a list of all customers
+// @Description Retrieve a page of customers
 // @Tags Customers
 // @Produce json
-// @Success 200 {array} models.Customer
+// @Param sort query string false "Sort fields: id, name, created_at (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of customers to skip"
+// @Success 200 {object} Page{data=[]models.Customer}
+// @Failure 400 {object} map[string]string "Invalid sort or paging parameter"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /customers [get]
 func GetCustomers(c *gin.Context) {
 	var customers []models.Customer
 
-	if err := config.DB.Find(&customers).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Customer{}), customerListSpec, nil, &customers)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve customers"})
 		return
 	}
 
-	c.JSON(http.StatusOK, customers)
+	c.JSON(http.StatusOK, page)
 }
 
 // GetCustomer returns a single customer by ID.
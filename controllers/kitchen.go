@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// now is the clock used for time-dependent computations. Tests replace it to freeze time.
+var now = time.Now
+
+// atRiskMargin is how close to its due time an order may be estimated ready before it is flagged at risk.
+const atRiskMargin = 5 * time.Minute
+
+// KitchenQueueEntry is one order of the preparation queue with its timing estimates.
+type KitchenQueueEntry struct {
+	Position         int          `json:"position"` // 1 = prepare first
+	Order            models.Order `json:"order"`
+	PrepMinutes      uint         `json:"prep_minutes"`       // Estimated preparation time of the whole order
+	DueAt            time.Time    `json:"due_at"`             // Scheduled time, or creation time + prep time when not scheduled
+	StartBy          time.Time    `json:"start_by"`           // Latest start that still meets DueAt
+	EstimatedReadyAt time.Time    `json:"estimated_ready_at"` // When the order should be ready given its current status
+	Late             bool         `json:"late"`               // Estimated ready after DueAt
+	AtRisk           bool         `json:"at_risk"`            // Estimated ready within atRiskMargin of DueAt
+}
+
+// itemPrepMinutes returns the preparation time of one order item.
+// A menu takes as long as its slowest component, since components are prepared in parallel;
+// a replaced component counts with its substitute.
+// OrderItems must be preloaded with Product, Menu.MenuProducts.Product and Substitutions.Product.
+func itemPrepMinutes(item models.OrderItem) uint {
+	if item.ProductID != nil {
+		return item.Product.PreparationTime
+	}
+	served := make(map[uint]models.Products)
+	for _, sub := range item.Substitutions {
+		served[sub.MenuProductID] = sub.Product
+	}
+	var minutes uint
+	for _, mp := range item.Menu.MenuProducts {
+		product, replaced := served[mp.ID]
+		if !replaced {
+			product = mp.Product
+		}
+		minutes = max(minutes, product.PreparationTime)
+	}
+	return minutes
+}
+
+// orderPrepMinutes estimates the preparation time of an order as its slowest item:
+// stations (grill, fryer, drinks) work in parallel, so items do not add up.
+func orderPrepMinutes(order models.Order) uint {
+	var minutes uint
+	for _, item := range order.OrderItems {
+		minutes = max(minutes, itemPrepMinutes(item))
+	}
+	return minutes
+}
+
+// preparationStarts returns when each of the given orders last entered preparing, from their status history.
+// UpdatedAt cannot be used for this: it also moves when an item is marked done or the order is edited.
+func preparationStarts(orderIDs []uint) (map[uint]time.Time, error) {
+	starts := make(map[uint]time.Time, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return starts, nil
+	}
+
+	var events []models.OrderStatusEvent
+	if err := config.DB.Where("order_id IN ? AND to_status = ?", orderIDs, "preparing").
+		Order("created_at, id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		starts[event.OrderID] = event.CreatedAt // latest wins
+	}
+	return starts, nil
+}
+
+// kitchenQueueEntry computes the timing of an order at the given time.
+// A pending order is assumed to start now; a preparing order started at preparingSince, when it
+// entered preparing (zero when that is not recorded, in which case it is also assumed to start now).
+func kitchenQueueEntry(order models.Order, preparingSince time.Time, at time.Time) KitchenQueueEntry {
+	prepMinutes := orderPrepMinutes(order)
+	prep := time.Duration(prepMinutes) * time.Minute
+
+	dueAt := order.CreatedAt.Add(prep)
+	if order.ScheduledTime != nil {
+		dueAt = *order.ScheduledTime
+	}
+
+	start := at
+	if order.Status == "preparing" && !preparingSince.IsZero() && preparingSince.Before(at) {
+		start = preparingSince
+	}
+	readyAt := start.Add(prep)
+	if readyAt.Before(at) {
+		readyAt = at // overdue preparation: ready at the earliest now
+	}
+
+	late := readyAt.After(dueAt)
+	return KitchenQueueEntry{
+		Order:            order,
+		PrepMinutes:      prepMinutes,
+		DueAt:            dueAt,
+		StartBy:          dueAt.Add(-prep),
+		EstimatedReadyAt: readyAt,
+		Late:             late,
+		AtRisk:           !late && readyAt.After(dueAt.Add(-atRiskMargin)),
+	}
+}
+
+// sortKitchenQueue orders entries by latest start time, so an order scheduled later but slower
+// to prepare can come before a quicker one. Ties keep the oldest order first.
+func sortKitchenQueue(entries []KitchenQueueEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].StartBy.Equal(entries[j].StartBy) {
+			return entries[i].StartBy.Before(entries[j].StartBy)
+		}
+		return entries[i].Order.ID < entries[j].Order.ID
+	})
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+}
+
+// GetKitchenQueue returns the pending and preparing orders in the order the kitchen should work on them.
+// Each order is due at its scheduled time, or as soon as it can be prepared when no time was given.
+// The estimated ready time comes from the preparation time of each product (slowest component for menus),
+// counted from when a preparing order entered preparing in its status history, and entries are flagged late or at risk against their due time.
+//
+// @Summary Get the kitchen preparation queue
+// @Description Pending and preparing orders in priority order, with prep estimates and late/at-risk flags
+// @Tags Kitchen
+// @Produce json
+// @Success 200 {array} KitchenQueueEntry
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /kitchen/queue [get]
+func GetKitchenQueue(c *gin.Context) {
+	var orders []models.Order
+
+	if err := orderPreloads(config.DB).
+		Preload("OrderItems.Menu.MenuProducts.Product").
+		Where("status IN ?", []string{"pending", "preparing"}).
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve kitchen queue"})
+		return
+	}
+
+	var preparing []uint
+	for _, order := range orders {
+		if order.Status == "preparing" {
+			preparing = append(preparing, order.ID)
+		}
+	}
+	starts, err := preparationStarts(preparing)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve kitchen queue"})
+		return
+	}
+
+	at := now()
+	queue := make([]KitchenQueueEntry, 0, len(orders))
+	for _, order := range orders {
+		queue = append(queue, kitchenQueueEntry(order, starts[order.ID], at))
+	}
+	sortKitchenQueue(queue)
+
+	c.JSON(http.StatusOK, queue)
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// freezeTime sets the controllers clock to t for the duration of the test.
+func freezeTime(t *testing.T, at time.Time) {
+	t.Helper()
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+// seedKitchenOrder creates an order with one item (product or menu) created at createdAt.
+func seedKitchenOrder(userID uint, status string, createdAt time.Time, scheduled *time.Time, productID, menuID *uint) models.Order {
+	order := models.Order{
+		CreatedByID:   userID,
+		OrderType:     "counter",
+		Status:        status,
+		ScheduledTime: scheduled,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+		OrderItems:    []models.OrderItem{{ProductID: productID, MenuID: menuID, Quantity: 1, UnitPrice: 1, ItemTotal: 1}},
+	}
+	config.DB.Create(&order)
+	return order
+}
+
+func TestGetKitchenQueue_PriorityAndFlags(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.50, cat.ID, true)
+	drink := testutils.SeedProduct(db, "Coke", 1.99, cat.ID, true)
+	db.Model(&burger).Update("preparation_time", 10)
+	db.Model(&fries).Update("preparation_time", 4)
+	db.Model(&drink).Update("preparation_time", 1)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.99, true)
+	testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, false)
+	testutils.SeedMenuProduct(db, menu.ID, drink.ID, 1, false)
+
+	noon := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, noon)
+	at := func(minutes int) *time.Time {
+		ts := noon.Add(time.Duration(minutes) * time.Minute)
+		return &ts
+	}
+
+	drinkOrder := seedKitchenOrder(user.ID, "pending", noon.Add(-time.Minute), at(30), &drink.ID, nil)
+	menuOrder := seedKitchenOrder(user.ID, "pending", noon.Add(-time.Minute), at(20), nil, &menu.ID)
+	walkIn := seedKitchenOrder(user.ID, "pending", noon.Add(-5*time.Minute), nil, &burger.ID, nil)
+	tight := seedKitchenOrder(user.ID, "pending", noon.Add(-time.Minute), at(8), &fries.ID, nil)
+	seedKitchenOrder(user.ID, "delivered", noon.Add(-time.Hour), nil, &burger.ID, nil)
+
+	r := testutils.SetupRouter()
+	r.GET("/kitchen/queue", GetKitchenQueue)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/kitchen/queue", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var queue []KitchenQueueEntry
+	json.Unmarshal(w.Body.Bytes(), &queue)
+	assert.Len(t, queue, 4)
+
+	ids := []uint{}
+	for _, e := range queue {
+		ids = append(ids, e.Order.ID)
+	}
+	// Sorted by latest start time: walk-in (11:55), fries (12:04), menu (12:10), drink (12:29)
+	assert.Equal(t, []uint{walkIn.ID, tight.ID, menuOrder.ID, drinkOrder.ID}, ids)
+	assert.Equal(t, 1, queue[0].Position)
+
+	// Unscheduled burger created at 11:55 is due 12:05 but cannot be ready before 12:10
+	assert.Equal(t, uint(10), queue[0].PrepMinutes)
+	assert.True(t, queue[0].DueAt.Equal(noon.Add(5*time.Minute)))
+	assert.True(t, queue[0].Late)
+
+	// Fries due 12:08, ready 12:04: inside the at-risk margin
+	assert.False(t, queue[1].Late)
+	assert.True(t, queue[1].AtRisk)
+
+	// Menu takes as long as its slowest component
+	assert.Equal(t, uint(10), queue[2].PrepMinutes)
+	assert.False(t, queue[2].Late)
+	assert.False(t, queue[2].AtRisk)
+}
+
+func TestKitchenQueueEntry_PreparingStartedEarlier(t *testing.T) {
+	noon := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	scheduled := noon.Add(5 * time.Minute)
+	order := models.Order{
+		Status:        "preparing",
+		ScheduledTime: &scheduled,
+		CreatedAt:     noon.Add(-20 * time.Minute),
+		UpdatedAt:     noon.Add(-time.Minute),
+		OrderItems: []models.OrderItem{
+			{ProductID: new(uint), Product: models.Products{PreparationTime: 10}},
+		},
+	}
+	started := noon.Add(-8 * time.Minute) // entered preparing at 11:52
+
+	entry := kitchenQueueEntry(order, started, noon)
+	assert.True(t, entry.EstimatedReadyAt.Equal(noon.Add(2*time.Minute)))
+	assert.False(t, entry.Late)
+	assert.True(t, entry.AtRisk)
+
+	// Preparation running longer than estimated: ready no earlier than now
+	entry = kitchenQueueEntry(order, started, noon.Add(10*time.Minute))
+
+	// Start not recorded: assumed to start now, like a pending order
+	entry = kitchenQueueEntry(order, time.Time{}, noon)
+	assert.True(t, entry.EstimatedReadyAt.Equal(noon.Add(10*time.Minute)))
+	assert.True(t, entry.EstimatedReadyAt.Equal(noon.Add(10*time.Minute)))
+	assert.True(t, entry.Late)
+}
+
+func TestGetKitchenQueue_PreparingStartFromHistory(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	db.Model(&burger).Update("preparation_time", 10)
+
+	noon := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, noon)
+
+	// Entered preparing at 11:52; an item marked done since then moved UpdatedAt to 11:59
+	order := seedKitchenOrder(user.ID, "preparing", noon.Add(-20*time.Minute), nil, &burger.ID, nil)
+	db.Create(&models.OrderStatusEvent{OrderID: order.ID, FromStatus: "pending", ToStatus: "preparing", CreatedAt: noon.Add(-8 * time.Minute)})
+	db.Model(&order).UpdateColumn("updated_at", noon.Add(-time.Minute))
+
+	r := testutils.SetupRouter()
+	r.GET("/kitchen/queue", GetKitchenQueue)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/kitchen/queue", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var queue []KitchenQueueEntry
+	json.Unmarshal(w.Body.Bytes(), &queue)
+	assert.Len(t, queue, 1)
+	assert.True(t, queue[0].EstimatedReadyAt.Equal(noon.Add(2*time.Minute)))
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"wacdo/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Login throttling. Every login attempt is recorded per email and per client IP (models.LoginAttempt).
+// After a few free failures, each new attempt must wait twice as long as the previous one; enough
+// failures on an email lock it until the lockout expires or an admin lifts it (see UnlockUser).
+// Unknown emails are throttled and locked exactly like existing ones, so the answers never tell
+// which emails have an account.
+var (
+	loginWindow          = 15 * time.Minute // Failures older than this are forgotten
+	loginFreeFailures    = 3                // Failures per email before the backoff starts
+	loginIPFreeFailures  = 10               // Failures per IP before the backoff starts (staff often share one)
+	loginBackoffBase     = time.Second      // Wait after the first failure past the free ones, doubled each time
+	loginBackoffMax      = 5 * time.Minute
+	loginLockoutFailures = 8 // Failures per email that lock it
+	loginLockout         = 15 * time.Minute
+)
+
+// loginMarkers are the records that end a run of failures on an email.
+var loginMarkers = []string{models.LoginSucceeded, models.LoginUnlocked, models.LoginLocked}
+
+// dummyPasswordHash is compared against when the email is unknown, so that the answer takes as
+// long as for a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// normalizeLoginEmail is the key login attempts are recorded under.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// recordLoginAttempt stores the outcome of a login attempt at the given time.
+func recordLoginAttempt(tx *gorm.DB, email, ip string, userID *uint, outcome string, at time.Time) error {
+	return tx.Create(&models.LoginAttempt{Email: email, IP: ip, UserID: userID, Outcome: outcome, CreatedAt: at}).Error
+}
+
+// emailFailuresSince returns the start of the current run of failures on an email: the end of the
+// window, or the last success, unlock or lockout if more recent. lockedUntil is set while the
+// email is locked.
+func emailFailuresSince(tx *gorm.DB, email string, at time.Time) (since time.Time, lockedUntil *time.Time, err error) {
+	since = at.Add(-loginWindow)
+
+	var marker models.LoginAttempt
+	err = tx.Where("email = ? AND outcome IN ?", email, loginMarkers).Order("created_at DESC, id DESC").First(&marker).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return since, nil, nil
+	}
+	if err != nil {
+		return since, nil, err
+	}
+
+	if marker.Outcome == models.LoginLocked {
+		if until := marker.CreatedAt.Add(loginLockout); at.Before(until) {
+			return since, &until, nil
+		}
+	}
+	if marker.CreatedAt.After(since) {
+		since = marker.CreatedAt
+	}
+	return since, nil, nil
+}
+
+// loginFailures counts the failed logins on an email or IP (column) since a time, and returns the
+// time of the latest.
+func loginFailures(tx *gorm.DB, column, value string, since time.Time) (int, time.Time, error) {
+	failures := func() *gorm.DB {
+		return tx.Model(&models.LoginAttempt{}).
+			Where(column+" = ? AND outcome = ? AND created_at > ?", value, models.LoginBadCredentials, since)
+	}
+
+	var count int64
+	if err := failures().Count(&count).Error; err != nil || count == 0 {
+		return 0, time.Time{}, err
+	}
+	var last models.LoginAttempt
+	if err := failures().Order("created_at DESC").First(&last).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(count), last.CreatedAt, nil
+}
+
+// loginBackoff is how long to wait after the failures-th failure when the first free ones are free.
+func loginBackoff(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+	doublings := failures - free - 1
+	if doublings >= 30 {
+		return loginBackoffMax
+	}
+	return min(loginBackoffBase<<doublings, loginBackoffMax)
+}
+
+// loginRetryAfter returns how long a login for an email from an IP must wait, or 0 if it may
+// go ahead now.
+func loginRetryAfter(tx *gorm.DB, email, ip string, at time.Time) (time.Duration, error) {
+	since, lockedUntil, err := emailFailuresSince(tx, email, at)
+	if err != nil {
+		return 0, err
+	}
+	if lockedUntil != nil {
+		return lockedUntil.Sub(at), nil
+	}
+
+	var wait time.Duration
+	failures, last, err := loginFailures(tx, "email", email, since)
+	if err != nil {
+		return 0, err
+	}
+	wait = max(wait, last.Add(loginBackoff(failures, loginFreeFailures)).Sub(at))
+
+	failures, last, err = loginFailures(tx, "ip", ip, at.Add(-loginWindow))
+	if err != nil {
+		return 0, err
+	}
+	wait = max(wait, last.Add(loginBackoff(failures, loginIPFreeFailures)).Sub(at))
+	return wait, nil
+}
+
+// recordLoginFailure records a failed login and locks the email once it reaches the lockout
+// threshold. An existing user's lockout is also kept on the user, where admins see it.
+func recordLoginFailure(tx *gorm.DB, email, ip string, user *models.Users, at time.Time) error {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	if err := recordLoginAttempt(tx, email, ip, userID, models.LoginBadCredentials, at); err != nil {
+		return err
+	}
+
+	since, _, err := emailFailuresSince(tx, email, at)
+	if err != nil {
+		return err
+	}
+	failures, _, err := loginFailures(tx, "email", email, since)
+	if err != nil || failures < loginLockoutFailures {
+		return err
+	}
+
+	if err := recordLoginAttempt(tx, email, ip, userID, models.LoginLocked, at); err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	return tx.Model(user).Update("locked_until", at.Add(loginLockout)).Error
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds, as sent in the Retry-After header.
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// throttleRouter serves the login and the admin endpoints that show and lift lockouts.
+func throttleRouter() *gin.Engine {
+	r := testutils.SetupRouter()
+	r.POST("/users/login", Login)
+	r.GET("/users/:id", GetUser)
+	r.PATCH("/users/:id/unlock", UnlockUser)
+	return r
+}
+
+// loginFrom attempts a login from a client IP.
+func loginFrom(r *gin.Engine, ip, email, password string) *httptest.ResponseRecorder {
+	req := testutils.JSONRequest("POST", "/users/login", map[string]string{"email": email, "password": password})
+	req.RemoteAddr = ip + ":4242"
+	return testutils.PerformRequest(r, req)
+}
+
+// lockEmail fails to log in until the email is locked, waiting out the backoff between attempts,
+// and returns the time of the last failure.
+func lockEmail(t *testing.T, r *gin.Engine, ip, email string, start time.Time) time.Time {
+	t.Helper()
+	at := start
+	for i := 0; i < loginLockoutFailures; i++ {
+		at = at.Add(loginBackoff(i, loginFreeFailures))
+		freezeTime(t, at)
+		assert.Equal(t, http.StatusBadRequest, loginFrom(r, ip, email, "WrongP@ss1").Code)
+	}
+	return at
+}
+
+func TestLogin_Backoff(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, start)
+
+	// The first failures are free
+	for i := 0; i < loginFreeFailures+1; i++ {
+		assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1").Code)
+	}
+
+	// Then each failure doubles the wait, even for the right password
+	w := loginFrom(r, "10.0.0.1", "counter@test.com", "P@ssw0rd")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, float64(1), testutils.ParseResponse(w)["retry_after"])
+
+	freezeTime(t, start.Add(time.Second))
+	assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1").Code)
+	freezeTime(t, start.Add(2*time.Second))
+	w = loginFrom(r, "10.0.0.1", "counter@test.com", "P@ssw0rd")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"), "2s after the failure at 1s")
+
+	// Once the wait is over the right password works, and the count starts over
+	freezeTime(t, start.Add(3*time.Second))
+	assert.Equal(t, http.StatusOK, loginFrom(r, "10.0.0.1", "counter@test.com", "P@ssw0rd").Code)
+	for i := 0; i < loginFreeFailures; i++ {
+		assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1").Code)
+	}
+
+	// Failures older than the window are forgotten
+	freezeTime(t, start.Add(3*time.Second+loginWindow))
+	assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1").Code)
+	assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1").Code)
+}
+
+func TestLogin_Lockout(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+
+	lockedAt := lockEmail(t, r, "10.0.0.1", "counter@test.com", time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	// Locked: even the right password from another IP is refused
+	w := loginFrom(r, "10.0.0.2", "Counter@test.com", "P@ssw0rd")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "900", w.Header().Get("Retry-After"))
+
+	// Admins see the lockout on the user
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/users", user.ID), nil))
+	lockedUntil, err := time.Parse(time.RFC3339, testutils.ParseResponse(w)["locked_until"].(string))
+	assert.NoError(t, err)
+	assert.True(t, lockedUntil.Equal(lockedAt.Add(loginLockout)))
+
+	freezeTime(t, lockedAt.Add(loginLockout-time.Second))
+	assert.Equal(t, http.StatusTooManyRequests, loginFrom(r, "10.0.0.2", "counter@test.com", "P@ssw0rd").Code)
+
+	// The lockout expires on its own, and a successful login clears it
+	freezeTime(t, lockedAt.Add(loginLockout))
+	assert.Equal(t, http.StatusOK, loginFrom(r, "10.0.0.2", "counter@test.com", "P@ssw0rd").Code)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/users", user.ID), nil))
+	assert.Nil(t, testutils.ParseResponse(w)["locked_until"])
+}
+
+func TestLogin_LockoutExpiryStartsOver(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+
+	lockedAt := lockEmail(t, r, "10.0.0.1", "counter@test.com", time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	// After the lockout, the failures that caused it no longer count
+	freezeTime(t, lockedAt.Add(loginLockout))
+	for i := 0; i < loginFreeFailures+1; i++ {
+		assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.2", "counter@test.com", "WrongP@ss1").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, loginFrom(r, "10.0.0.2", "counter@test.com", "WrongP@ss1").Code)
+}
+
+func TestUnlockUser(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+
+	lockEmail(t, r, "10.0.0.1", "counter@test.com", time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, http.StatusTooManyRequests, loginFrom(r, "10.0.0.2", "counter@test.com", "P@ssw0rd").Code)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/unlock", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, testutils.ParseResponse(w)["locked_until"])
+
+	assert.Equal(t, http.StatusOK, loginFrom(r, "10.0.0.2", "counter@test.com", "P@ssw0rd").Code)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", "/users/999/unlock", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLogin_ThrottleDoesNotRevealEmails(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+
+	// The same sequence of attempts on an existing and an unknown email gets the same answers
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	// Four free failures, one throttled attempt, failures after 1s, 2s, 4s and 8s (the last one locks),
+	// a locked attempt, and a failure once the lockout is over
+	steps := []time.Duration{0, 0, 0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 0, loginLockout}
+
+	answers := func(ip, email string) []string {
+		var got []string
+		at := start
+		for _, step := range steps {
+			at = at.Add(step)
+			freezeTime(t, at)
+			w := loginFrom(r, ip, email, "WrongP@ss1")
+			got = append(got, w.Header().Get("Retry-After")+" "+w.Body.String())
+		}
+		return got
+	}
+	existing := answers("10.0.0.1", "counter@test.com")
+	unknown := answers("10.0.0.2", "nobody@test.com")
+	assert.Equal(t, existing, unknown)
+	assert.Contains(t, existing[4], "Too many failed login attempts")
+	assert.Contains(t, existing[9], "900 ", "both end up locked")
+	assert.Contains(t, existing[10], "Invalid email or password")
+}
+
+func TestLogin_IPBackoff(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	r := throttleRouter()
+	freezeTime(t, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	// Guessing across many emails is throttled by IP
+	emails := []string{"a@test.com", "b@test.com", "c@test.com", "d@test.com"}
+	for i := 0; i <= loginIPFreeFailures; i++ {
+		assert.Equal(t, http.StatusBadRequest, loginFrom(r, "10.0.0.1", emails[i%len(emails)], "WrongP@ss1").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, loginFrom(r, "10.0.0.1", "counter@test.com", "P@ssw0rd").Code)
+
+	// Other clients are not affected
+	assert.Equal(t, http.StatusOK, loginFrom(r, "10.0.0.2", "counter@test.com", "P@ssw0rd").Code)
+}
+
+func TestLogin_RecordsAttempts(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	inactive := testutils.SeedUser(db, "former", "former@test.com", "P@ssw0rd", role.ID)
+	db.Model(&inactive).Update("is_active", false)
+	r := throttleRouter()
+
+	loginFrom(r, "10.0.0.1", "Nobody@Test.com", "WrongP@ss1")
+	loginFrom(r, "10.0.0.1", "counter@test.com", "WrongP@ss1")
+	loginFrom(r, "10.0.0.1", "counter@test.com", "P@ssw0rd")
+	loginFrom(r, "10.0.0.1", "former@test.com", "P@ssw0rd")
+
+	var attempts []models.LoginAttempt
+	config.DB.Order("id").Find(&attempts)
+	assert.Len(t, attempts, 4)
+	assert.Equal(t, "nobody@test.com", attempts[0].Email, "recorded lower-cased")
+	assert.Nil(t, attempts[0].UserID)
+	assert.Equal(t, models.LoginBadCredentials, attempts[0].Outcome)
+	assert.Equal(t, "counter@test.com", attempts[1].Email)
+	assert.Equal(t, user.ID, *attempts[1].UserID)
+	assert.Equal(t, "10.0.0.1", attempts[1].IP)
+	assert.Equal(t, models.LoginSucceeded, attempts[2].Outcome)
+	assert.Equal(t, models.LoginDeactivated, attempts[3].Outcome)
+}
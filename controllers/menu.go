@@ -39,7 +39,16 @@ func CreateMenu(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&menu).Error; err != nil {
+	// Availability reasons are managed by the server
+	menu.UnavailableReason = ""
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&menu).Error; err != nil {
+			return err
+		}
+		return recordPrice(tx, models.PriceChange{MenuID: &menu.ID, Price: menu.Price}, currentUserID(c))
+	})
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Menu could not be created"})
 		return
 	}
@@ -47,29 +56,67 @@ func CreateMenu(c *gin.Context) {
 	c.JSON(http.StatusOK, menu)
 }
 
-// GetMenus returns all menus with their associated products preloaded.
+// menuListSpec lists the sort fields and filters accepted by GetMenus.
+var menuListSpec = listSpec{
+	Sort: map[string]string{
+		"id":    "id",
+		"name":  "name",
+		"price": "price",
+	},
+	DefaultSort: "id ASC",
+	Filters: []listFilter{
+		{Param: "is_available", Column: "is_available", Kind: filterBool},
+	},
+}
+
+// GetMenus returns a page of menus with their associated products preloaded and their effective availability
+// (the stored flag combined with the availability schedules of the menu and its products, as of now).
+// Filter: ?is_available=true|false. Paging and sorting: ?limit=, ?offset=, ?sort=-price.
 //
 // @Summary Get all menus
-// @Description Retrieve a list of all menus with their products
+// @Description Retrieve a page of menus with their products
 // @Tags Menus
 // @Produce json
-// @Success 200 {array} models.Menu
+// @Param is_available query bool false "Filter by availability"
+// @Param sort query string false "Sort fields: id, name, price (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of menus to skip"
+// @Success 200 {object} Page{data=[]models.Menu}
+// @Failure 400 {object} map[string]string "Invalid filter, sort or paging parameter"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /menus [get]
 func GetMenus(c *gin.Context) {
 	var menus []models.Menu
 
-	// pre load menu product to get the list of products in the menu.
-	if err := config.DB.Preload("MenuProducts").Find(&menus).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Menu{}), menuListSpec, menuPreloads, &menus)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve menus"})
+		return
+	}
+	if err := fillMenuPrices(menus); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve menus"})
+		return
+	}
+	if err := fillMenuAvailability(menus); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve menus"})
 		return
 	}
 
-	c.JSON(http.StatusOK, menus)
+	c.JSON(http.StatusOK, page)
 }
 
-// GetMenu returns a single menu by ID with its products preloaded.
+// menuPreloads loads the menu's products and their substitutes so the list shows each menu's composition.
+// The component products (not serialized) are needed to check their availability schedules.
+func menuPreloads(db *gorm.DB) *gorm.DB {
+	return db.Preload("MenuProducts.Product").Preload("MenuProducts.Substitutes")
+}
+
+// GetMenu returns a single menu by ID with its products preloaded and its effective availability.
 //
 // @Summary Get a menu by ID
 // @Description Retrieve a single menu by its ID with associated products
@@ -95,7 +142,7 @@ func GetMenu(c *gin.Context) {
 	}
 
 	// get menu it's associated products
-	if err := config.DB.Preload("MenuProducts").First(&menu, id).Error; err != nil {
+	if err := menuPreloads(config.DB).First(&menu, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Menu not found"})
 			return
@@ -103,12 +150,22 @@ func GetMenu(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	menus := []models.Menu{menu}
+	if err := fillMenuPrices(menus); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if err := fillMenuAvailability(menus); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
 
-	c.JSON(http.StatusOK, menu)
+	c.JSON(http.StatusOK, menus[0])
 }
 
 // UpdateMenu modifies an existing menu.
 // Validates that the new name doesn't conflict with another menu.
+// A new price takes effect at once and is recorded in the price history (see SchedulePriceChange to plan one).
 //
 // @Summary Update a menu
 // @Description Update an existing menu by ID
@@ -152,7 +209,27 @@ func UpdateMenu(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Model(&menu).Updates(input).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&menu, id).Error; err != nil {
+			return err
+		}
+		// Compared with the price in effect, so setting any other price supersedes a due scheduled one
+		prices, err := loadDuePrices(tx, now())
+		if err != nil {
+			return err
+		}
+		prices.menu(&menu)
+		previousPrice := menu.Price
+
+		if err := tx.Model(&menu).Omit("unavailable_reason").Updates(input).Error; err != nil {
+			return err
+		}
+		if input.Price != 0 && input.Price != previousPrice {
+			return recordPrice(tx, models.PriceChange{MenuID: &menu.ID, Price: input.Price}, currentUserID(c))
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update menu"})
 		return
 	}
@@ -198,7 +275,8 @@ func DeleteMenu(c *gin.Context) {
 }
 
 // ToggleMenuAvailability flips the is_available flag on a menu.
-// Unavailable menus cannot be added to new orders.
+// Unavailable menus cannot be added to new orders. A manual switch-off is recorded as
+// unavailable_reason "manual"; a menu whose required components are out of stock cannot be switched on.
 //
 // @Summary Toggle menu availability
 // @Description Quick toggle for is_available field
@@ -208,6 +286,7 @@ func DeleteMenu(c *gin.Context) {
 // @Success 200 {object} models.Menu
 // @Failure 400 {object} map[string]string "Invalid ID"
 // @Failure 404 {object} map[string]string "Menu not found"
+// @Failure 409 {object} map[string]string "Required product out of stock"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /menus/{id}/availability [patch]
@@ -225,7 +304,21 @@ func ToggleMenuAvailability(c *gin.Context) {
 		return
 	}
 
+	// A menu switched off because a required component ran out comes back on its own once restocked
+	if !menu.IsAvailable {
+		var withProducts models.Menu
+		config.DB.Preload("MenuProducts.Product").First(&withProducts, id)
+		if menuOutOfStock(withProducts) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A required product of this menu is out of stock"})
+			return
+		}
+	}
+
 	menu.IsAvailable = !menu.IsAvailable
+	menu.UnavailableReason = ""
+	if !menu.IsAvailable {
+		menu.UnavailableReason = models.UnavailableManual
+	}
 
 	if err := config.DB.Save(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update availability"})
@@ -291,12 +384,16 @@ func AddProductToMenu(c *gin.Context) {
 	}
 
 	input.MenuID = uint(menuID)
+	input.Substitutes = nil // managed through AddMenuSubstitute
 
 	if err := config.DB.Create(&input).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add product to menu"})
 		return
 	}
 
+	// The new component may be out of stock
+	syncMenuAvailability(config.DB, input.MenuID)
+
 	c.JSON(http.StatusOK, input)
 }
 
@@ -328,7 +425,7 @@ func GetMenuProducts(c *gin.Context) {
 	}
 
 	var menuProducts []models.MenuProduct
-	if err := config.DB.Where("menu_id = ?", menuID).Find(&menuProducts).Error; err != nil {
+	if err := config.DB.Preload("Substitutes").Where("menu_id = ?", menuID).Find(&menuProducts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve menu products"})
 		return
 	}
@@ -336,7 +433,7 @@ func GetMenuProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, menuProducts)
 }
 
-// RemoveProductFromMenu deletes the link between a product and a menu.
+// RemoveProductFromMenu deletes the link between a product and a menu, along with its substitutes.
 //
 // @Summary Remove a product from a menu
 // @Description Remove a product from a menu by menu product ID
@@ -363,10 +460,19 @@ func RemoveProductFromMenu(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&menuProduct).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("menu_product_id = ?", menuProduct.ID).Delete(&models.MenuProductSubstitute{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&menuProduct).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove product from menu"})
 		return
 	}
 
+	// Removing an out-of-stock component may make the menu available again
+	syncMenuAvailability(config.DB, menuProduct.MenuID)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Product removed from menu"})
 }
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// applySubstitutions checks the substitutions requested for a menu item and swaps the replaced
+// components of menu for their substitutes, so options, stock and preparation apply to the product served.
+// menu must be preloaded with MenuProducts.Product and MenuProducts.Substitutes; substitutes come with their category.
+// - Only optional components can be replaced, at most once each
+// - The replacement must belong to the component's substitution group and be available
+// It returns the substitution records (price differences snapshotted) and the sum of their price differences.
+func applySubstitutions(tx *gorm.DB, itemName string, menu *models.Menu, inputs []OrderItemSubstitutionInput) ([]models.OrderItemSubstitution, models.Money, error) {
+	var records []models.OrderItemSubstitution
+	var delta models.Money
+	replaced := make(map[uint]string) // menu component ID -> name of the product it replaced
+
+	for _, input := range inputs {
+		var mp *models.MenuProduct
+		for i := range menu.MenuProducts {
+			if menu.MenuProducts[i].ID == input.MenuProductID {
+				mp = &menu.MenuProducts[i]
+			}
+		}
+		if mp == nil {
+			return nil, 0, errors.New(itemName + ": menu component not found")
+		}
+		if name, ok := replaced[mp.ID]; ok {
+			return nil, 0, errors.New(itemName + ": product '" + name + "' is replaced more than once")
+		}
+		if !mp.IsOptional {
+			return nil, 0, errors.New(itemName + ": product '" + mp.Product.Name + "' cannot be replaced")
+		}
+
+		var product models.Products
+		if err := tx.Preload("Category").First(&product, input.ProductID).Error; err != nil {
+			return nil, 0, errors.New(itemName + ": replacement product not found")
+		}
+
+		var substitute *models.MenuProductSubstitute
+		for i := range mp.Substitutes {
+			if mp.Substitutes[i].ProductID == product.ID {
+				substitute = &mp.Substitutes[i]
+			}
+		}
+		if substitute == nil {
+			return nil, 0, errors.New(itemName + ": '" + product.Name + "' is not an allowed replacement for '" + mp.Product.Name + "'")
+		}
+		if !product.IsAvailable {
+			return nil, 0, errors.New("product '" + product.Name + "' is not available")
+		}
+
+		records = append(records, models.OrderItemSubstitution{
+			MenuProductID: mp.ID,
+			ProductID:     product.ID,
+			Product:       product,
+			PriceDelta:    substitute.PriceDelta,
+		})
+		delta += substitute.PriceDelta
+		replaced[mp.ID] = mp.Product.Name
+
+		mp.ProductID = product.ID
+		mp.Product = product
+	}
+
+	return records, delta, nil
+}
+
+// MenuSubstituteInput is the body of AddMenuSubstitute.
+type MenuSubstituteInput struct {
+	ProductID  uint         `json:"product_id" binding:"required"`
+	PriceDelta models.Money `json:"price_delta"` // Added to the menu price when chosen (may be negative)
+}
+
+// GetMenuSubstitutes returns the substitution group of a menu component: the products it can be replaced with.
+//
+// @Summary Get the substitutes of a menu component
+// @Description Retrieve the allowed replacements of an optional menu component with their price difference
+// @Tags Menus
+// @Produce json
+// @Param id path int true "Menu Product ID"
+// @Success 200 {array} models.MenuProductSubstitute
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Menu product not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /menus/products/{id}/substitutes [get]
+func GetMenuSubstitutes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var menuProduct models.MenuProduct
+	if err := config.DB.First(&menuProduct, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Menu product not found"})
+		return
+	}
+
+	substitutes := []models.MenuProductSubstitute{}
+	if err := config.DB.Where("menu_product_id = ?", menuProduct.ID).Find(&substitutes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve substitutes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, substitutes)
+}
+
+// AddMenuSubstitute adds a product to the substitution group of an optional menu component.
+// The product must exist, differ from the component's own product and not already be in the group.
+//
+// @Summary Add a substitute to a menu component
+// @Description Allow an optional menu component to be replaced by a product, with a price difference
+// @Tags Menus
+// @Accept json
+// @Produce json
+// @Param id path int true "Menu Product ID"
+// @Param substitute body MenuSubstituteInput true "Substitute details"
+// @Success 201 {object} models.MenuProductSubstitute
+// @Failure 400 {object} map[string]string "Invalid data or component not optional"
+// @Failure 404 {object} map[string]string "Menu product or product not found"
+// @Failure 409 {object} map[string]string "Product already a substitute"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /menus/products/{id}/substitutes [post]
+func AddMenuSubstitute(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var menuProduct models.MenuProduct
+	if err := config.DB.First(&menuProduct, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Menu product not found"})
+		return
+	}
+
+	var input MenuSubstituteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if !menuProduct.IsOptional {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only optional menu components can be replaced"})
+		return
+	}
+
+	var product models.Products
+	if err := config.DB.First(&product, input.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if product.ID == menuProduct.ProductID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A product cannot replace itself"})
+		return
+	}
+
+	var existing models.MenuProductSubstitute
+	if err := config.DB.Where("menu_product_id = ? AND product_id = ?", menuProduct.ID, product.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product already a substitute for this component"})
+		return
+	}
+
+	substitute := models.MenuProductSubstitute{
+		MenuProductID: menuProduct.ID,
+		ProductID:     product.ID,
+		PriceDelta:    input.PriceDelta,
+	}
+	if err := config.DB.Create(&substitute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add substitute"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, substitute)
+}
+
+// RemoveMenuSubstitute removes a product from the substitution group of a menu component.
+// Orders already placed keep the substitution they recorded.
+//
+// @Summary Remove a substitute from a menu component
+// @Description Remove an allowed replacement by substitute ID
+// @Tags Menus
+// @Produce json
+// @Param id path int true "Substitute ID"
+// @Success 200 {object} map[string]string "Substitute removed"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Substitute not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /menus/substitutes/{id} [delete]
+func RemoveMenuSubstitute(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var substitute models.MenuProductSubstitute
+	if err := config.DB.First(&substitute, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Substitute not found"})
+		return
+	}
+
+	if err := config.DB.Delete(&substitute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove substitute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Substitute removed"})
+}
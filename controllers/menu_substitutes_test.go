@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func substituteRouter() *gin.Engine {
+	r := testutils.SetupRouter()
+	r.GET("/menus/products/:id/substitutes", GetMenuSubstitutes)
+	r.POST("/menus/products/:id/substitutes", AddMenuSubstitute)
+	r.DELETE("/menus/substitutes/:id", RemoveMenuSubstitute)
+	r.DELETE("/menus/products/:id", RemoveProductFromMenu)
+	return r
+}
+
+func TestAddMenuSubstitute_Success(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Sides")
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+
+	r := substituteRouter()
+	path := fmt.Sprintf("/menus/products/%d/substitutes", friesMP.ID)
+
+	body := map[string]interface{}{"product_id": salad.ID, "price_delta": 0.50}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", path, body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.InDelta(t, 0.50, testutils.ParseResponse(w)["price_delta"], 0.001)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", path, body))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", path, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"product_id":%d`, salad.ID))
+}
+
+func TestAddMenuSubstitute_Rejected(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Sides")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	burgerMP := testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+
+	r := substituteRouter()
+
+	cases := []struct {
+		menuProductID uint
+		productID     uint
+		code          int
+	}{
+		{burgerMP.ID, salad.ID, http.StatusBadRequest}, // required component
+		{friesMP.ID, fries.ID, http.StatusBadRequest},  // replaces itself
+		{friesMP.ID, 9999, http.StatusNotFound},
+		{9999, salad.ID, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		path := fmt.Sprintf("/menus/products/%d/substitutes", tc.menuProductID)
+		body := map[string]interface{}{"product_id": tc.productID}
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", path, body))
+		assert.Equal(t, tc.code, w.Code, "menu product %d, product %d", tc.menuProductID, tc.productID)
+	}
+}
+
+func TestRemoveMenuSubstitute(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Sides")
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, cat.ID, true)
+	nuggets := testutils.SeedProduct(db, "Nuggets", 4.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	saladSub := models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: salad.ID}
+	db.Create(&saladSub)
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: nuggets.ID, PriceDelta: 100})
+
+	r := substituteRouter()
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("DELETE", fmt.Sprintf("/menus/substitutes/%d", saladSub.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var count int64
+	config.DB.Model(&models.MenuProductSubstitute{}).Where("menu_product_id = ?", friesMP.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Removing the component takes its substitution group with it
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", fmt.Sprintf("/menus/products/%d", friesMP.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	config.DB.Model(&models.MenuProductSubstitute{}).Where("menu_product_id = ?", friesMP.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDeleteProduct_RemovesItsSubstitutions(t *testing.T) {
+	db := testutils.SetupTestDB()
+	testutils.EnableForeignKeys(db)
+	cat := testutils.SeedCategory(db, "Sides")
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, cat.ID, true)
+	nuggets := testutils.SeedProduct(db, "Nuggets", 4.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: salad.ID})
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: nuggets.ID, PriceDelta: 100})
+
+	r := testutils.SetupRouter()
+	r.DELETE("/products/:id", DeleteProduct)
+
+	// A product offered as a substitute can be deleted: it leaves the substitution groups
+	w := testutils.PerformRequest(r, testutils.JSONRequest("DELETE", testutils.IDParam("/products", salad.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var substitutes []models.MenuProductSubstitute
+	config.DB.Where("menu_product_id = ?", friesMP.ID).Find(&substitutes)
+	assert.Len(t, substitutes, 1)
+	assert.Equal(t, nuggets.ID, substitutes[0].ProductID)
+}
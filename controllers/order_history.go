@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// orderStages are the preparation stages measured by the stage-time report.
+// A stage lasts from the moment the order enters From until it moves to To.
+var orderStages = []struct{ From, To string }{
+	{"pending", "preparing"},
+	{"preparing", "prepared"},
+	{"prepared", "delivered"},
+}
+
+// StageTimeStat is the average duration of one stage over a group of orders.
+type StageTimeStat struct {
+	Stage          string  `json:"stage"`              // e.g. "pending_to_preparing"
+	Day            string  `json:"day,omitempty"`      // YYYY-MM-DD the stage ended (by-day breakdown)
+	UserID         *uint   `json:"user_id,omitempty"`  // Staff member who ended the stage (by-staff breakdown)
+	Username       string  `json:"username,omitempty"` // Name of that staff member
+	Count          int     `json:"count"`              // Number of orders measured
+	AverageSeconds float64 `json:"average_seconds"`
+}
+
+// StageTimeReport groups stage durations by day and by staff member.
+type StageTimeReport struct {
+	ByDay   []StageTimeStat `json:"by_day"`
+	ByStaff []StageTimeStat `json:"by_staff"`
+}
+
+// GetOrderHistory returns the status changes of an order in chronological order, with the staff member behind each one.
+//
+// @Summary Get order status history
+// @Description Retrieve every status change of an order with its timestamp and actor
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.OrderStatusEvent
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /orders/{id}/history [get]
+func GetOrderHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var order models.Order
+	if err := config.DB.First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	history := []models.OrderStatusEvent{}
+	if err := config.DB.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }). // deleted staff stay visible in the history
+		Where("order_id = ?", order.ID).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve order history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// stageKey identifies a stage-time group: a stage plus either a day or a staff member.
+type stageKey struct {
+	stage string
+	day   string
+	user  uint
+}
+
+// stageTotal accumulates durations for a stageKey.
+type stageTotal struct {
+	count int
+	sum   time.Duration
+}
+
+// GetStageTimeReport reports the average time orders spend in each preparation stage
+// (pending→preparing, preparing→prepared, prepared→delivered), per day and per staff member.
+// A stage is attributed to the day it ended and to the staff member who ended it.
+// Use ?from= and ?to= (YYYY-MM-DD or RFC 3339) to restrict the period.
+//
+// @Summary Get average time per order stage
+// @Description Average duration of each preparation stage, per day and per staff member
+// @Tags Reports
+// @Produce json
+// @Param from query string false "Stages ended on or after (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Stages ended on or before (YYYY-MM-DD or RFC 3339)"
+// @Success 200 {object} StageTimeReport
+// @Failure 400 {object} map[string]string "Invalid date"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /reports/stage-times [get]
+func GetStageTimeReport(c *gin.Context) {
+	from, err := parseDateParam(c, "from", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseDateParam(c, "to", true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stageNames := make(map[[2]string]string)
+	stageOrder := make(map[string]int)
+	var stageEnds []string
+	for i, stage := range orderStages {
+		name := stage.From + "_to_" + stage.To
+		stageNames[[2]string{stage.From, stage.To}] = name
+		stageOrder[name] = i
+		stageEnds = append(stageEnds, stage.To)
+	}
+
+	// Events that end a stage within the period
+	query := config.DB.Where("to_status IN ?", stageEnds)
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at <= ?", *to)
+	}
+
+	var ends []models.OrderStatusEvent
+	if err := query.Find(&ends).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stage times"})
+		return
+	}
+
+	// When each of those orders entered each status
+	orderIDs := make([]uint, 0, len(ends))
+	for _, e := range ends {
+		orderIDs = append(orderIDs, e.OrderID)
+	}
+	var starts []models.OrderStatusEvent
+	if len(orderIDs) > 0 {
+		if err := config.DB.Where("order_id IN ?", orderIDs).Find(&starts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stage times"})
+			return
+		}
+	}
+	entered := make(map[uint]map[string]time.Time)
+	for _, e := range starts {
+		if entered[e.OrderID] == nil {
+			entered[e.OrderID] = make(map[string]time.Time)
+		}
+		entered[e.OrderID][e.ToStatus] = e.CreatedAt
+	}
+
+	byDay := make(map[stageKey]*stageTotal)
+	byStaff := make(map[stageKey]*stageTotal)
+	add := func(totals map[stageKey]*stageTotal, key stageKey, d time.Duration) {
+		if totals[key] == nil {
+			totals[key] = &stageTotal{}
+		}
+		totals[key].count++
+		totals[key].sum += d
+	}
+
+	for _, end := range ends {
+		stage, isStage := stageNames[[2]string{end.FromStatus, end.ToStatus}]
+		start, ok := entered[end.OrderID][end.FromStatus]
+		if !isStage || !ok || end.CreatedAt.Before(start) {
+			continue // not a measured stage, or order created before status history was recorded
+		}
+		duration := end.CreatedAt.Sub(start)
+
+		add(byDay, stageKey{stage: stage, day: end.CreatedAt.Local().Format(time.DateOnly)}, duration)
+		if end.UserID != nil {
+			add(byStaff, stageKey{stage: stage, user: *end.UserID}, duration)
+		}
+	}
+
+	// Staff names, including users deleted since
+	var userIDs []uint
+	for key := range byStaff {
+		userIDs = append(userIDs, key.user)
+	}
+	usernames := make(map[uint]string)
+	if len(userIDs) > 0 {
+		var users []models.Users
+		if err := config.DB.Unscoped().Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stage times"})
+			return
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+
+	report := StageTimeReport{ByDay: []StageTimeStat{}, ByStaff: []StageTimeStat{}}
+	for key, total := range byDay {
+		report.ByDay = append(report.ByDay, StageTimeStat{
+			Stage:          key.stage,
+			Day:            key.day,
+			Count:          total.count,
+			AverageSeconds: total.sum.Seconds() / float64(total.count),
+		})
+	}
+	for key, total := range byStaff {
+		userID := key.user
+		report.ByStaff = append(report.ByStaff, StageTimeStat{
+			Stage:          key.stage,
+			UserID:         &userID,
+			Username:       usernames[key.user],
+			Count:          total.count,
+			AverageSeconds: total.sum.Seconds() / float64(total.count),
+		})
+	}
+
+	sort.Slice(report.ByDay, func(i, j int) bool {
+		a, b := report.ByDay[i], report.ByDay[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		return stageOrder[a.Stage] < stageOrder[b.Stage]
+	})
+	sort.Slice(report.ByStaff, func(i, j int) bool {
+		a, b := report.ByStaff[i], report.ByStaff[j]
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		if *a.UserID != *b.UserID {
+			return *a.UserID < *b.UserID
+		}
+		return stageOrder[a.Stage] < stageOrder[b.Stage]
+	})
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrderHistory_RecordsEveryTransition(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+	r.GET("/orders/:id/history", GetOrderHistory)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": p.ID, "quantity": 1}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+	for _, status := range []string{"preparing", "prepared"} {
+		w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/status", map[string]string{"status": status}))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/orders", orderID)+"/history", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var history []models.OrderStatusEvent
+	json.Unmarshal(w.Body.Bytes(), &history)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "", history[0].FromStatus)
+	assert.Equal(t, "pending", history[0].ToStatus)
+	assert.Equal(t, "pending", history[1].FromStatus)
+	assert.Equal(t, "preparing", history[1].ToStatus)
+	assert.Equal(t, "prepared", history[2].ToStatus)
+	for _, e := range history {
+		assert.Equal(t, user.ID, *e.UserID)
+		assert.Equal(t, "admin", e.User.Username)
+	}
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/orders/999/history", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCancelOrder_RecordsHistory(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "accueil", "accueil@test.com", "P@ssw0rd", role.ID)
+	order := seedOrder(user.ID, "pending", nil)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "accueil"))
+	r.PATCH("/orders/:id/cancel", CancelOrder)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", order.ID)+"/cancel", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var event models.OrderStatusEvent
+	config.DB.Where("order_id = ?", order.ID).Last(&event)
+	assert.Equal(t, "pending", event.FromStatus)
+	assert.Equal(t, "cancelled", event.ToStatus)
+	assert.Equal(t, user.ID, *event.UserID)
+}
+
+func TestGetStageTimeReport(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	alice := testutils.SeedUser(db, "alice", "alice@test.com", "P@ssw0rd", role.ID)
+	bob := testutils.SeedUser(db, "bob", "bob@test.com", "P@ssw0rd", role.ID)
+
+	day1 := time.Date(2025, 5, 12, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	record := func(orderID uint, from, to string, userID uint, at time.Time) {
+		db.Create(&models.OrderStatusEvent{OrderID: orderID, FromStatus: from, ToStatus: to, UserID: &userID, CreatedAt: at})
+	}
+
+	// Order 1 (day 1): 2 min waiting, 10 min preparing by alice
+	record(1, "", "pending", alice.ID, day1)
+	record(1, "pending", "preparing", alice.ID, day1.Add(2*time.Minute))
+	record(1, "preparing", "prepared", alice.ID, day1.Add(12*time.Minute))
+	// Order 2 (day 1): 4 min waiting, started by bob
+	record(2, "", "pending", bob.ID, day1.Add(time.Hour))
+	record(2, "pending", "preparing", bob.ID, day1.Add(time.Hour+4*time.Minute))
+	// Order 3 (day 2): 6 min waiting, started by alice; cancelled orders are ignored
+	record(3, "", "pending", alice.ID, day2)
+	record(3, "pending", "preparing", alice.ID, day2.Add(6*time.Minute))
+	record(4, "", "pending", alice.ID, day2)
+	record(4, "pending", "cancelled", alice.ID, day2.Add(time.Minute))
+
+	r := testutils.SetupRouter()
+	r.GET("/reports/stage-times", GetStageTimeReport)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/reports/stage-times", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report StageTimeReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+
+	assert.Equal(t, []StageTimeStat{
+		{Stage: "pending_to_preparing", Day: "2025-05-12", Count: 2, AverageSeconds: 180},
+		{Stage: "preparing_to_prepared", Day: "2025-05-12", Count: 1, AverageSeconds: 600},
+		{Stage: "pending_to_preparing", Day: "2025-05-13", Count: 1, AverageSeconds: 360},
+	}, report.ByDay)
+
+	assert.Len(t, report.ByStaff, 3)
+	assert.Equal(t, "alice", report.ByStaff[0].Username)
+	assert.Equal(t, "pending_to_preparing", report.ByStaff[0].Stage)
+	assert.Equal(t, 2, report.ByStaff[0].Count)
+	assert.Equal(t, float64(240), report.ByStaff[0].AverageSeconds)
+	assert.Equal(t, "bob", report.ByStaff[2].Username)
+
+	// Restricting the period keeps stages that ended on day 2 only
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/reports/stage-times?from=2025-05-13", nil))
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Len(t, report.ByDay, 1)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/reports/stage-times?to=tomorrow", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"wacdo/config"
+	"wacdo/events"
+	"wacdo/models"
+	"wacdo/workflow"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// itemTransitions lists the statuses an order item may move to from each status.
+// An item can be finished straight from pending (e.g. a drink poured on the spot).
+var itemTransitions = map[string][]string{
+	"pending":   {"preparing", "done"},
+	"preparing": {"done"},
+}
+
+// UpdateOrderItemStatus advances a single item of an order, so stations can report progress
+// independently (e.g. fries done, burgers still on the grill).
+// The parent order follows its items through the order workflow, whatever the user's role:
+// - Starting or finishing the first item moves a pending order to preparing
+// - Finishing the last item moves the order to prepared
+//
+// @Summary Update order item status
+// @Description Update the preparation status of one order item (pending, preparing, done)
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item_id path int true "Order item ID"
+// @Param status body StatusInput true "New item status"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid ID, data or transition"
+// @Failure 404 {object} map[string]string "Order or item not found"
+// @Failure 409 {object} map[string]string "Order or item changed concurrently"
+// @Security BearerAuth
+// @Router /orders/{id}/items/{item_id}/status [patch]
+func UpdateOrderItemStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	var input StatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	var order models.Order
+	if err := config.DB.First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	var item models.OrderItem
+	if err := config.DB.Where("order_id = ?", order.ID).First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if order.Status != "pending" && order.Status != "preparing" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Items can only be updated while the order is pending or preparing"})
+		return
+	}
+
+	if !slices.Contains(itemTransitions[item.Status], input.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transition from '" + item.Status + "' to '" + input.Status + "'"})
+		return
+	}
+
+	// Order status changes caused by this item, published once committed
+	var orderChanges [][2]string
+	userID := currentUserID(c)
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.OrderItem{}).
+			Where("id = ? AND status = ?", item.ID, item.Status).
+			Update("status", input.Status)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errOrderStatusChanged
+		}
+
+		status := order.Status
+		advance := func(to string) error {
+			if !workflow.Orders.Allows(status, to) {
+				return nil
+			}
+			if err := transitionOrder(tx, order.ID, status, to, userID); err != nil {
+				return err
+			}
+			orderChanges = append(orderChanges, [2]string{status, to})
+			status = to
+			return nil
+		}
+
+		// Work on any item means the order is being prepared
+		if status == "pending" {
+			if err := advance("preparing"); err != nil {
+				return err
+			}
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND status <> ?", order.ID, "done").
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return advance("prepared")
+		}
+		return nil
+	})
+
+	if errors.Is(err, errOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order changed meanwhile, reload and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item status"})
+		return
+	}
+
+	status := order.Status
+	for _, change := range orderChanges {
+		publishOrderEvent(events.OrderStatusChanged, order.ID, change[1], change[0])
+		status = change[1]
+	}
+	events.Orders.Publish(events.OrderEvent{
+		Type:       events.OrderItemStatusChanged,
+		OrderID:    order.ID,
+		Status:     status,
+		ItemID:     item.ID,
+		ItemStatus: input.Status,
+	})
+
+	// Reload with preloads
+	var result models.Order
+	if err := orderPreloads(config.DB).First(&result, order.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// OrderItemQuantityInput is the body of UpdateOrderItem.
+type OrderItemQuantityInput struct {
+	Quantity uint `json:"quantity"`
+}
+
+// pendingOrderItemParam loads the item named by the :item_id parameter, which must belong to order.
+// It answers 400, 404 or 500 itself and reports whether the caller can go ahead.
+func pendingOrderItemParam(c *gin.Context, order models.Order) (models.OrderItem, bool) {
+	var item models.OrderItem
+
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return item, false
+	}
+
+	if err := config.DB.Preload("OrderItemOptions").Preload("Substitutions").Where("order_id = ?", order.ID).First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+			return item, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return item, false
+	}
+	return item, true
+}
+
+// AddOrderItem adds an item to a pending order. The item is validated, priced and its stock reserved
+// exactly as at order creation, and the order total is recomputed.
+//
+// @Summary Add an item to a pending order
+// @Description Add a product or menu to a pending order. Prices are computed server-side.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item body OrderItemInput true "Item to add"
+// @Success 201 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid data or order not pending"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
+// @Security BearerAuth
+// @Router /orders/{id}/items [post]
+func AddOrderItem(c *gin.Context) {
+	order, ok := pendingOrderParam(c)
+	if !ok {
+		return
+	}
+
+	var input OrderItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	userID := currentUserID(c)
+	editPendingOrder(c, order, http.StatusCreated, func(tx *gorm.DB, pricing orderPricing) error {
+		item, menu, err := priceOrderItem(tx, input, order.ServiceMode, pricing)
+		if err != nil {
+			return err
+		}
+		item.OrderID = order.ID
+		return saveOrderItem(tx, &item, menu, userID)
+	})
+}
+
+// UpdateOrderItem changes the quantity of an item of a pending order.
+// The item is repriced at current catalog prices with the same options and substitutions, and its stock
+// reservation is released and taken again for the new quantity.
+//
+// @Summary Change the quantity of an order item
+// @Description Change the quantity of an item of a pending order. The item is repriced server-side.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item_id path int true "Order item ID"
+// @Param item body OrderItemQuantityInput true "New quantity"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid data or order not pending"
+// @Failure 404 {object} map[string]string "Order or item not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
+// @Security BearerAuth
+// @Router /orders/{id}/items/{item_id} [patch]
+func UpdateOrderItem(c *gin.Context) {
+	order, ok := pendingOrderParam(c)
+	if !ok {
+		return
+	}
+	item, ok := pendingOrderItemParam(c, order)
+	if !ok {
+		return
+	}
+
+	var input OrderItemQuantityInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	itemInput := OrderItemInput{ProductID: item.ProductID, MenuID: item.MenuID, Quantity: input.Quantity}
+	for _, opt := range item.OrderItemOptions {
+		itemInput.Options = append(itemInput.Options, OrderItemOptionInput{OptionValueID: opt.OptionValueID, MenuProductID: opt.MenuProductID})
+	}
+	for _, sub := range item.Substitutions {
+		itemInput.Substitutions = append(itemInput.Substitutions, OrderItemSubstitutionInput{MenuProductID: sub.MenuProductID, ProductID: sub.ProductID})
+	}
+
+	userID := currentUserID(c)
+	editPendingOrder(c, order, http.StatusOK, func(tx *gorm.DB, pricing orderPricing) error {
+		// Release first: the item's own reservation may be what made its product unavailable
+		if err := releaseOrderItemStock(tx, item, userID); err != nil {
+			return err
+		}
+
+		repriced, menu, err := priceOrderItem(tx, itemInput, order.ServiceMode, pricing)
+		if err != nil {
+			return err
+		}
+		repriced.ID = item.ID
+		repriced.OrderID = order.ID
+		repriced.Status = item.Status
+		return saveOrderItem(tx, &repriced, menu, userID)
+	})
+}
+
+// RemoveOrderItem removes an item from a pending order and gives back its reserved stock.
+// The last item cannot be removed: cancel the order instead.
+//
+// @Summary Remove an item from a pending order
+// @Description Remove an item from a pending order and recompute the total
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item_id path int true "Order item ID"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid ID, last item or order not pending"
+// @Failure 404 {object} map[string]string "Order or item not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
+// @Security BearerAuth
+// @Router /orders/{id}/items/{item_id} [delete]
+func RemoveOrderItem(c *gin.Context) {
+	order, ok := pendingOrderParam(c)
+	if !ok {
+		return
+	}
+	item, ok := pendingOrderItemParam(c, order)
+	if !ok {
+		return
+	}
+
+	userID := currentUserID(c)
+	editPendingOrder(c, order, http.StatusOK, func(tx *gorm.DB, _ orderPricing) error {
+		var count int64
+		if err := tx.Model(&models.OrderItem{}).Where("order_id = ?", order.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errors.New("an order must keep at least one item, cancel the order instead")
+		}
+
+		if err := releaseOrderItemStock(tx, item, userID); err != nil {
+			return err
+		}
+		if err := deleteOrderItemDetails(tx, item.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&item).Error
+	})
+}
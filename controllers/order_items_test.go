@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedOrderWithItems creates an order holding one item per product.
+func seedOrderWithItems(userID uint, status string, productIDs ...uint) models.Order {
+	order := models.Order{CreatedByID: userID, OrderType: "counter", Status: status}
+	for i := range productIDs {
+		order.OrderItems = append(order.OrderItems, models.OrderItem{ProductID: &productIDs[i], Quantity: 1, UnitPrice: 1, ItemTotal: 1})
+	}
+	config.DB.Create(&order)
+	return order
+}
+
+func orderStatusOf(orderID uint) string {
+	var order models.Order
+	config.DB.First(&order, orderID)
+	return order.Status
+}
+
+func itemStatusPath(order models.Order, itemID uint) string {
+	return fmt.Sprintf("/orders/%d/items/%d/status", order.ID, itemID)
+}
+
+// itemRouter serves the item and order status endpoints to a preparation user.
+func itemRouter(userID uint) *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), "preparation"))
+	r.PATCH("/orders/:id/items/:item_id/status", UpdateOrderItemStatus)
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+	return r
+}
+
+func TestUpdateOrderItemStatus_DrivesOrderStatus(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.50, cat.ID, true)
+	order := seedOrderWithItems(user.ID, "pending", burger.ID, fries.ID)
+	burgerItem, friesItem := order.OrderItems[0], order.OrderItems[1]
+	assert.Equal(t, "pending", burgerItem.Status)
+
+	r := itemRouter(user.ID)
+
+	// Starting the burger starts the order
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", itemStatusPath(order, burgerItem.ID), map[string]string{"status": "preparing"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "preparing", testutils.ParseResponse(w)["status"])
+
+	// Fries done, burger still cooking: order stays preparing
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", itemStatusPath(order, friesItem.ID), map[string]string{"status": "done"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "preparing", orderStatusOf(order.ID))
+
+	// Last item done: order prepared
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", itemStatusPath(order, burgerItem.ID), map[string]string{"status": "done"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "prepared", resp["status"])
+	for _, item := range resp["order_items"].([]interface{}) {
+		assert.Equal(t, "done", item.(map[string]interface{})["status"])
+	}
+}
+
+func TestUpdateOrderItemStatus_SingleItemDoneFromPending(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Drinks")
+	coke := testutils.SeedProduct(db, "Coke", 1.99, cat.ID, true)
+	order := seedOrderWithItems(user.ID, "pending", coke.ID)
+
+	w := testutils.PerformRequest(itemRouter(user.ID), testutils.JSONRequest("PATCH", itemStatusPath(order, order.OrderItems[0].ID), map[string]string{"status": "done"}))
+
+	// pending → preparing → prepared in one step, following the order workflow
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "prepared", orderStatusOf(order.ID))
+}
+
+func TestUpdateOrderItemStatus_Rejected(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	order := seedOrderWithItems(user.ID, "pending", burger.ID, burger.ID)
+	other := seedOrderWithItems(user.ID, "pending", burger.ID)
+	delivered := seedOrderWithItems(user.ID, "delivered", burger.ID)
+	config.DB.Model(&order.OrderItems[0]).Update("status", "done")
+
+	r := itemRouter(user.ID)
+
+	cases := []struct {
+		path   string
+		status string
+		code   int
+	}{
+		{itemStatusPath(order, order.OrderItems[0].ID), "preparing", http.StatusBadRequest}, // done is final
+		{itemStatusPath(order, order.OrderItems[1].ID), "cooking", http.StatusBadRequest},   // unknown status
+		{itemStatusPath(order, other.OrderItems[0].ID), "done", http.StatusNotFound},        // item of another order
+		{itemStatusPath(delivered, delivered.OrderItems[0].ID), "done", http.StatusBadRequest},
+		{"/orders/999/items/1/status", "done", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", tc.path, map[string]string{"status": tc.status}))
+		assert.Equal(t, tc.code, w.Code, tc.path)
+	}
+	assert.Equal(t, "pending", orderStatusOf(order.ID))
+}
+
+func TestUpdateOrderStatus_PreparedFinishesItems(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	order := seedOrderWithItems(user.ID, "preparing", burger.ID, burger.ID)
+
+	w := testutils.PerformRequest(itemRouter(user.ID), testutils.JSONRequest("PATCH", testutils.IDParam("/orders", order.ID)+"/status", map[string]string{"status": "prepared"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var pending int64
+	config.DB.Model(&models.OrderItem{}).Where("order_id = ? AND status <> ?", order.ID, "done").Count(&pending)
+	assert.Zero(t, pending)
+}
+
+// editRouter serves the pending order item endpoints to a counter user.
+func editRouter(userID uint) *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), "accueil"))
+	r.POST("/orders", CreateOrder)
+	r.POST("/orders/:id/items", AddOrderItem)
+	r.PATCH("/orders/:id/items/:item_id", UpdateOrderItem)
+	r.DELETE("/orders/:id/items/:item_id", RemoveOrderItem)
+	return r
+}
+
+func TestEditOrderItems(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "accueil", "accueil@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	size := seedOptionDirect(burger.ID, "Size", "single")
+	large := seedOptionValue(size.ID, "Large", 1.50)
+
+	r := editRouter(user.ID)
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": burger.ID, "quantity": 1, "options": []map[string]interface{}{{"option_value_id": large.ID}}},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+
+	// Add fries
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", testutils.IDParam("/orders", orderID)+"/items", map[string]interface{}{"product_id": fries.ID, "quantity": 2}))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.InDelta(t, 10.50, testutils.ParseResponse(w)["total_price"], 0.001)
+	assert.Equal(t, uint(testutils.DefaultStock-2), stockOf(fries.ID))
+
+	var items []models.OrderItem
+	config.DB.Where("order_id = ?", orderID).Order("id").Find(&items)
+	burgerItem, friesItem := items[0], items[1]
+
+	// Three burgers, still large
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", fmt.Sprintf("/orders/%d/items/%d", orderID, burgerItem.ID), map[string]interface{}{"quantity": 3}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.InDelta(t, 23.50, testutils.ParseResponse(w)["total_price"], 0.001)
+	assert.Equal(t, uint(testutils.DefaultStock-3), stockOf(burger.ID))
+	var options int64
+	config.DB.Model(&models.OrderItemOption{}).Where("order_item_id = ?", burgerItem.ID).Count(&options)
+	assert.Equal(t, int64(1), options)
+
+	// Remove the fries
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", fmt.Sprintf("/orders/%d/items/%d", orderID, friesItem.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.InDelta(t, 19.50, testutils.ParseResponse(w)["total_price"], 0.001)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(fries.ID))
+
+	// What the edits gave back is recorded as such, not as a cancellation
+	var edits, cancellations int64
+	config.DB.Model(&models.StockMovement{}).Where("order_id = ? AND reason = ?", orderID, models.StockReasonOrderEdit).Count(&edits)
+	config.DB.Model(&models.StockMovement{}).Where("order_id = ? AND reason = ?", orderID, models.StockReasonCancellation).Count(&cancellations)
+	assert.Equal(t, int64(2), edits)
+	assert.Zero(t, cancellations)
+
+	// The VAT summary follows the edits, and the removed item takes its VAT lines with it
+	var taxLines []models.OrderTaxLine
+	config.DB.Where("order_id = ?", orderID).Find(&taxLines)
+	assert.Len(t, taxLines, 1)
+	assert.Equal(t, models.Money(1950), taxLines[0].Gross)
+	var friesTaxLines int64
+	config.DB.Model(&models.OrderItemTax{}).Where("order_item_id = ?", friesItem.ID).Count(&friesTaxLines)
+	assert.Zero(t, friesTaxLines)
+
+	// The last item cannot be removed, and a zero quantity is rejected
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", fmt.Sprintf("/orders/%d/items/%d", orderID, burgerItem.ID), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", fmt.Sprintf("/orders/%d/items/%d", orderID, burgerItem.ID), map[string]interface{}{"quantity": 0}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock-3), stockOf(burger.ID))
+}
+
+func TestUpdateOrderItem_LastUnitStaysReserved(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "accueil", "accueil@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	testutils.SetStock(db, burger.ID, 2)
+
+	r := editRouter(user.ID)
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": burger.ID, "quantity": 2}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	resp := testutils.ParseResponse(w)
+	orderID := uint(resp["id"].(float64))
+	itemID := uint(resp["order_items"].([]interface{})[0].(map[string]interface{})["id"].(float64))
+
+	// The order holds the whole stock, which switched the product off: it can still be changed
+	path := fmt.Sprintf("/orders/%d/items/%d", orderID, itemID)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", path, map[string]interface{}{"quantity": 1}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(1), stockOf(burger.ID))
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", path, map[string]interface{}{"quantity": 3}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, uint(1), stockOf(burger.ID))
+}
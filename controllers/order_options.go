@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+	"wacdo/models"
+
+	"gorm.io/gorm"
+)
+
+// checkProductOptions loads the option groups of a product and checks the values picked for it
+// (see checkOptionSelection).
+func checkProductOptions(tx *gorm.DB, itemName string, productID uint, selected []models.OptionValues) error {
+	var groups []models.ProductOptions
+	if err := tx.Where("product_id = ?", productID).Order("id").Find(&groups).Error; err != nil {
+		return err
+	}
+
+	// Required groups are only enforced when they have values to pick from
+	var selectable []uint
+	if err := tx.Model(&models.OptionValues{}).
+		Where("option_id IN (?)", tx.Model(&models.ProductOptions{}).Select("id").Where("product_id = ?", productID)).
+		Distinct().Pluck("option_id", &selectable).Error; err != nil {
+		return err
+	}
+
+	return checkOptionSelection(itemName, groups, selectable, selected)
+}
+
+// checkOptionSelection enforces the option group rules on the values picked for one order item:
+// - The same value cannot be picked twice
+// - A "single" group accepts at most one value
+// - A required group needs a value, as long as it has values to pick from (selectable lists those groups)
+// groups are the option groups of the product; selected values must have their Option preloaded. Errors name the item and the option group, e.g. "product 'Big Mac': option 'Size' is required".
+func checkOptionSelection(itemName string, groups []models.ProductOptions, selectable []uint, selected []models.OptionValues) error {
+	seen := make(map[uint]bool)
+	perGroup := make(map[uint]int)
+	for _, value := range selected {
+		if seen[value.ID] {
+			return errors.New(itemName + ": option '" + value.Option.Name + "' value '" + value.Value + "' is selected more than once")
+		}
+		seen[value.ID] = true
+		perGroup[value.OptionID]++
+	}
+
+	for _, group := range groups {
+		count := perGroup[group.ID]
+		if group.IsUnique == models.OptionSingle && count > 1 {
+			return errors.New(itemName + ": only one value can be selected for option '" + group.Name + "'")
+		}
+		if group.IsRequired && count == 0 && slices.Contains(selectable, group.ID) {
+			return errors.New(itemName + ": option '" + group.Name + "' is required")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"testing"
+	"wacdo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOptionSelection(t *testing.T) {
+	size := models.ProductOptions{ID: 1, Name: "Size", IsUnique: models.OptionSingle, IsRequired: true}
+	toppings := models.ProductOptions{ID: 2, Name: "Toppings", IsUnique: models.OptionMultiple}
+	sauce := models.ProductOptions{ID: 3, Name: "Sauce", IsUnique: models.OptionSingle, IsRequired: true} // no values yet
+	groups := []models.ProductOptions{size, toppings, sauce}
+	selectable := []uint{size.ID, toppings.ID}
+
+	large := models.OptionValues{ID: 10, OptionID: size.ID, Option: size, Value: "Large"}
+	small := models.OptionValues{ID: 11, OptionID: size.ID, Option: size, Value: "Small"}
+	bacon := models.OptionValues{ID: 20, OptionID: toppings.ID, Option: toppings, Value: "Bacon"}
+	cheese := models.OptionValues{ID: 21, OptionID: toppings.ID, Option: toppings, Value: "Cheese"}
+
+	assert.NoError(t, checkOptionSelection("product 'Big Mac'", groups, selectable, []models.OptionValues{large, bacon, cheese}))
+
+	err := checkOptionSelection("product 'Big Mac'", groups, selectable, []models.OptionValues{bacon})
+	assert.EqualError(t, err, "product 'Big Mac': option 'Size' is required")
+
+	err = checkOptionSelection("product 'Big Mac'", groups, selectable, []models.OptionValues{large, small})
+	assert.EqualError(t, err, "product 'Big Mac': only one value can be selected for option 'Size'")
+
+	err = checkOptionSelection("product 'Big Mac'", groups, selectable, []models.OptionValues{large, bacon, bacon})
+	assert.EqualError(t, err, "product 'Big Mac': option 'Toppings' value 'Bacon' is selected more than once")
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"time"
+	"wacdo/events"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat is how often a comment line is sent on idle streams so that proxies keep the connection open.
+var streamHeartbeat = 15 * time.Second
+
+// streamStatuses lists, per order status permission, the status of the orders a holder acts on:
+// whoever may start preparing watches pending orders, and so on.
+// An event is sent when the order enters or leaves one of these statuses.
+var streamStatuses = map[string]string{
+	models.OrderStatusPermission("preparing"): "pending",
+	models.OrderStatusPermission("prepared"):  "preparing",
+	models.OrderStatusPermission("delivered"): "prepared",
+}
+
+// watchedStatuses returns the order statuses relevant to the holder of the given permissions,
+// or nil when they receive every event (orders:stream:all).
+func watchedStatuses(permissions models.PermissionList) []string {
+	if permissions.Grants(models.PermOrdersStreamAll) {
+		return nil
+	}
+	statuses := []string{}
+	for permission, status := range streamStatuses {
+		if permissions.Grants(permission) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// orderEventVisible reports whether an order event concerns the given statuses (nil means all).
+func orderEventVisible(statuses []string, event events.OrderEvent) bool {
+	if statuses == nil {
+		return true
+	}
+	return slices.Contains(statuses, event.Status) || slices.Contains(statuses, event.PreviousStatus)
+}
+
+// publishOrderEvent notifies connected screens of an order change. Call it only after the change is committed.
+func publishOrderEvent(eventType string, orderID uint, status, previousStatus string) {
+	events.Orders.Publish(events.OrderEvent{
+		Type:           eventType,
+		OrderID:        orderID,
+		Status:         status,
+		PreviousStatus: previousStatus,
+	})
+}
+
+// StreamOrders pushes order events to the client over Server-Sent Events.
+// Events are filtered by permission: whoever may start or finish preparing receives pending/preparing
+// orders, whoever may hand orders over receives prepared orders, and orders:stream:all receives
+// everything. The connection stays open until the client leaves, or until the request is no longer
+// authorized: at each heartbeat the stream is closed if the access token has expired, the session
+// was revoked, the user deactivated or their role or its permissions changed, and the client reconnects with
+// its current credentials.
+//
+// @Summary Stream order events
+// @Description Server-Sent Events feed of order created, updated, status changed and cancelled events, filtered by permission
+// @Tags Orders
+// @Produce text/event-stream
+// @Success 200 {object} events.OrderEvent
+// @Security BearerAuth
+// @Router /orders/stream [get]
+func StreamOrders(c *gin.Context) {
+	role, permissions := c.GetString("userRole"), middlewares.Permissions(c)
+	statuses := watchedStatuses(permissions)
+
+	subscription := events.Orders.Subscribe(32)
+	defer events.Orders.Unsubscribe(subscription)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	// Send a first comment so the client knows the subscription is active
+	io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-subscription:
+			if !orderEventVisible(statuses, event) {
+				continue
+			}
+			c.SSEvent(event.Type, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if !middlewares.Reauthenticate(c) || c.GetString("userRole") != role || !slices.Equal(middlewares.Permissions(c), permissions) {
+				return
+			}
+			io.WriteString(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/events"
+	"wacdo/middlewares"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openOrderStream starts a test server exposing the stream and order routes for the given role
+// and returns a scanner over the SSE body once the subscription is active.
+func openOrderStream(t *testing.T, userID uint, role string) (*httptest.Server, *bufio.Scanner) {
+	t.Helper()
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), role))
+	r.GET("/orders/stream", StreamOrders)
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+	r.PATCH("/orders/:id/cancel", CancelOrder)
+
+	server := httptest.NewServer(r)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orders/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, ": connected", scanner.Text())
+	return server, scanner
+}
+
+// nextOrderEvent reads the stream until the next event and decodes it, skipping comments.
+func nextOrderEvent(t *testing.T, scanner *bufio.Scanner) events.OrderEvent {
+	t.Helper()
+
+	result := make(chan events.OrderEvent, 1)
+	go func() {
+		var name string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				name = line[len("event:"):]
+			case strings.HasPrefix(line, "data:") && name != "":
+				var event events.OrderEvent
+				json.Unmarshal([]byte(line[len("data:"):]), &event)
+				result <- event
+				return
+			}
+		}
+		close(result)
+	}()
+
+	select {
+	case event, ok := <-result:
+		require.True(t, ok, "stream closed before an event was received")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an order event")
+		return events.OrderEvent{}
+	}
+}
+
+func sendRequest(t *testing.T, server *httptest.Server, method, path, body string) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300)
+}
+
+func TestStreamOrders_AdminReceivesLifecycle(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	server, scanner := openOrderStream(t, user.ID, "admin")
+
+	sendRequest(t, server, "POST", "/orders", fmt.Sprintf(`{"order_type":"counter","order_items":[{"product_id":%d,"quantity":1}]}`, p.ID))
+	created := nextOrderEvent(t, scanner)
+	assert.Equal(t, events.OrderCreated, created.Type)
+	assert.Equal(t, "pending", created.Status)
+	assert.NotZero(t, created.OrderID)
+
+	sendRequest(t, server, "PATCH", testutils.IDParam("/orders", created.OrderID)+"/status", `{"status":"preparing"}`)
+	changed := nextOrderEvent(t, scanner)
+	assert.Equal(t, events.OrderStatusChanged, changed.Type)
+	assert.Equal(t, "preparing", changed.Status)
+	assert.Equal(t, "pending", changed.PreviousStatus)
+
+	sendRequest(t, server, "POST", "/orders", fmt.Sprintf(`{"order_type":"counter","order_items":[{"product_id":%d,"quantity":1}]}`, p.ID))
+	second := nextOrderEvent(t, scanner)
+	sendRequest(t, server, "PATCH", testutils.IDParam("/orders", second.OrderID)+"/cancel", "")
+	cancelled := nextOrderEvent(t, scanner)
+	assert.Equal(t, events.OrderCancelled, cancelled.Type)
+	assert.Equal(t, second.OrderID, cancelled.OrderID)
+	assert.Equal(t, "cancelled", cancelled.Status)
+}
+
+func TestStreamOrders_AccueilOnlySeesPrepared(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "accueil", "accueil@test.com", "P@ssw0rd", role.ID)
+	order := seedOrder(user.ID, "pending", nil)
+
+	_, scanner := openOrderStream(t, user.ID, "accueil")
+
+	// pending -> preparing is kitchen business: not sent to accueil.
+	// Accueil may not trigger kitchen transitions itself, so they are published as the kitchen screens would.
+	publishOrderEvent(events.OrderStatusChanged, order.ID, "preparing", "pending")
+	publishOrderEvent(events.OrderStatusChanged, order.ID, "prepared", "preparing")
+
+	event := nextOrderEvent(t, scanner)
+	assert.Equal(t, "prepared", event.Status)
+	assert.Equal(t, "preparing", event.PreviousStatus)
+}
+
+func TestStreamOrders_UnsubscribesOnDisconnect(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+
+	before := events.Orders.Subscribers()
+	server, _ := openOrderStream(t, user.ID, "preparation")
+	assert.Equal(t, before+1, events.Orders.Subscribers())
+
+	server.CloseClientConnections()
+	assert.Eventually(t, func() bool { return events.Orders.Subscribers() == before }, 2*time.Second, 10*time.Millisecond)
+}
+
+// streamCloses reports whether a stream ends within the given time; ended is closed by its reader when it does.
+func streamCloses(ended <-chan struct{}, within time.Duration) bool {
+	select {
+	case <-ended:
+		return true
+	case <-time.After(within):
+		return false
+	}
+}
+
+func TestStreamOrders_ClosedWhenNoLongerAuthorized(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	role := testutils.SeedRole(db, "preparation")
+	counter := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+
+	previous := streamHeartbeat
+	streamHeartbeat = 20 * time.Millisecond
+	t.Cleanup(func() { streamHeartbeat = previous })
+
+	r := sessionRouter()
+	r.GET("/orders/stream", middlewares.Authentication(), StreamOrders)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	// open starts a stream and a single reader draining it; the returned channel is closed when it ends
+	open := func(accessToken string) <-chan struct{} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/orders/stream", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		ended := make(chan struct{})
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+			}
+			close(ended)
+		}()
+		return ended
+	}
+
+	// Still authorized: heartbeats keep the stream open
+	tokens := login(t, r, "prep@test.com", "P@ssw0rd")
+	stream := open(tokens.AccessToken)
+	assert.False(t, streamCloses(stream, time.Second))
+
+	// Logout revokes the session
+	assert.Equal(t, http.StatusOK, authorized(r, "POST", "/users/logout", tokens.AccessToken, nil))
+	assert.True(t, streamCloses(stream, time.Second))
+
+	// Role change
+	tokens = login(t, r, "prep@test.com", "P@ssw0rd")
+	stream = open(tokens.AccessToken)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/role", map[string]uint{"roles_id": counter.ID}))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, streamCloses(stream, time.Second))
+
+	// Deactivation
+	stream = open(login(t, r, "prep@test.com", "P@ssw0rd").AccessToken)
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/status", nil))
+	assert.True(t, streamCloses(stream, time.Second))
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/status", nil)) // reactivate
+
+	// Token expiry: a token of an open session, expiring within two seconds
+	login(t, r, "prep@test.com", "P@ssw0rd")
+	var session models.Session
+	config.DB.Last(&session)
+	claim := &CustomClaim{UserID: user.ID, SessionID: session.ID, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(2 * time.Second)),
+	}}
+	shortLived, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claim).SignedString([]byte(os.Getenv("JWT_SECRET")))
+	require.NoError(t, err)
+	stream = open(shortLived)
+	assert.True(t, streamCloses(stream, 3*time.Second))
+}
+
+func TestOrderEventVisible(t *testing.T) {
+	created := events.OrderEvent{Type: events.OrderCreated, Status: "pending"}
+	toPrepared := events.OrderEvent{Type: events.OrderStatusChanged, Status: "prepared", PreviousStatus: "preparing"}
+	delivered := events.OrderEvent{Type: events.OrderStatusChanged, Status: "delivered", PreviousStatus: "prepared"}
+	cancelled := events.OrderEvent{Type: events.OrderCancelled, Status: "cancelled", PreviousStatus: "pending"}
+	visible := func(role string, event events.OrderEvent) bool {
+		return orderEventVisible(watchedStatuses(models.DefaultRolePermissions[role]), event)
+	}
+
+	assert.True(t, visible("admin", delivered))
+
+	assert.True(t, visible("preparation", created))
+	assert.True(t, visible("preparation", toPrepared)) // leaves the kitchen queue
+	assert.True(t, visible("preparation", cancelled))
+	assert.False(t, visible("preparation", delivered))
+
+	assert.False(t, visible("accueil", created))
+	assert.True(t, visible("accueil", toPrepared))
+	assert.True(t, visible("accueil", delivered)) // leaves the pickup list
+
+	assert.False(t, visible("unknown", created))
+
+	// Custom roles follow their permissions
+	handover := models.PermissionList{models.OrderStatusPermission("delivered")}
+	assert.True(t, orderEventVisible(watchedStatuses(handover), toPrepared))
+	assert.False(t, orderEventVisible(watchedStatuses(handover), created))
+	assert.True(t, orderEventVisible(watchedStatuses(models.PermissionList{models.PermOrdersStreamAll}), created))
+}
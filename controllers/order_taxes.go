@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"sort"
+	"wacdo/models"
+
+	"gorm.io/gorm"
+)
+
+// taxShare is the part of an order item's price (VAT included) taxed at one rate.
+type taxShare struct {
+	rate  uint
+	gross models.Money
+}
+
+// itemTaxLines groups the shares of an item by rate and splits each group into net amount and VAT
+// (see models.Money.SplitTax). Lines are sorted by rate.
+func itemTaxLines(shares []taxShare) []models.OrderItemTax {
+	gross := make(map[uint]models.Money)
+	for _, share := range shares {
+		gross[share.rate] += share.gross
+	}
+
+	lines := make([]models.OrderItemTax, 0, len(gross))
+	for rate, amount := range gross {
+		net, tax := amount.SplitTax(rate)
+		lines = append(lines, models.OrderItemTax{Rate: rate, Net: net, Tax: tax, Gross: amount})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Rate < lines[j].Rate })
+	return lines
+}
+
+// menuTaxShares splits the price of a menu item across the VAT rates of its components.
+// The menu price is shared in proportion to the list prices of the components as served
+// (substitutes included), and extras (option prices and substitution price differences,
+// per unit) go to the component they belong to. menu must be preloaded with MenuProducts.Product.Category.
+// A menu without components is taxed at the default rate.
+func menuTaxShares(menu models.Menu, quantity uint, mode models.ServiceMode, extras map[uint]models.Money) []taxShare {
+	base := menu.Price.Times(quantity)
+	if len(menu.MenuProducts) == 0 {
+		rate := uint(models.DefaultTaxRateOnSite)
+		if mode == models.ServiceTakeaway {
+			rate = models.DefaultTaxRateTakeaway
+		}
+		return []taxShare{{rate: rate, gross: base}}
+	}
+
+	weights := make([]int64, len(menu.MenuProducts))
+	for i, mp := range menu.MenuProducts {
+		weights[i] = int64(mp.Product.Price.Times(mp.Quantity))
+	}
+
+	shares := make([]taxShare, 0, len(menu.MenuProducts))
+	for i, part := range base.Allocate(weights) {
+		mp := menu.MenuProducts[i]
+		shares = append(shares, taxShare{rate: mp.Product.TaxRate(mode), gross: part + extras[mp.ID].Times(quantity)})
+	}
+	return shares
+}
+
+// orderTaxLines sums the VAT lines of an order's items per rate, less the discounts taken off them:
+// an item's discount is shared between its rates in proportion to their amounts and split into
+// net and VAT at each rate. items must come with their VAT lines. Lines are sorted by rate.
+func orderTaxLines(items []models.OrderItem) []models.OrderTaxLine {
+	sums := make(map[uint]*models.OrderTaxLine)
+	for _, item := range items {
+		weights := make([]int64, len(item.TaxLines))
+		for i, line := range item.TaxLines {
+			weights[i] = int64(line.Gross)
+		}
+		reductions := item.Discount.Allocate(weights)
+
+		for i, line := range item.TaxLines {
+			sum, ok := sums[line.Rate]
+			if !ok {
+				sum = &models.OrderTaxLine{Rate: line.Rate}
+				sums[line.Rate] = sum
+			}
+			net, tax := reductions[i].SplitTax(line.Rate)
+			sum.Net += line.Net - net
+			sum.Tax += line.Tax - tax
+			sum.Gross += line.Gross - reductions[i]
+		}
+	}
+
+	lines := make([]models.OrderTaxLine, 0, len(sums))
+	for _, line := range sums {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Rate < lines[j].Rate })
+	return lines
+}
+
+// updateOrderTaxLines rebuilds the VAT summary of an order from its items (see orderTaxLines),
+// so the summary always adds up to the order total.
+func updateOrderTaxLines(tx *gorm.DB, orderID uint, items []models.OrderItem) error {
+	if err := tx.Where("order_id = ?", orderID).Delete(&models.OrderTaxLine{}).Error; err != nil {
+		return err
+	}
+
+	lines := orderTaxLines(items)
+	for i := range lines {
+		lines[i].OrderID = orderID
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byRate sorts preloaded VAT lines by rate.
+func byRate(db *gorm.DB) *gorm.DB {
+	return db.Order("rate")
+}
@@ -2,40 +2,96 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"slices"
 	"strconv"
 	"time"
 	"wacdo/config"
+	"wacdo/events"
+	"wacdo/middlewares"
 	"wacdo/models"
+	"wacdo/workflow"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderItemOptionInput struct {
-	OptionValueID uint `json:"option_value_id"`
+	OptionValueID uint  `json:"option_value_id"`
+	MenuProductID *uint `json:"menu_product_id"` // Menu component the option applies to (required for menu items)
+}
+
+// OrderItemSubstitutionInput replaces an optional menu component by one of its allowed substitutes.
+type OrderItemSubstitutionInput struct {
+	MenuProductID uint `json:"menu_product_id"`
+	ProductID     uint `json:"product_id"`
 }
 
 type OrderItemInput struct {
-	ProductID *uint                  `json:"product_id"`
-	MenuID    *uint                  `json:"menu_id"`
-	Quantity  uint                   `json:"quantity"`
-	Options   []OrderItemOptionInput `json:"options"`
+	ProductID     *uint                        `json:"product_id"`
+	MenuID        *uint                        `json:"menu_id"`
+	Quantity      uint                         `json:"quantity"`
+	Options       []OrderItemOptionInput       `json:"options"`
+	Substitutions []OrderItemSubstitutionInput `json:"substitutions"` // Menu items only
 }
 
 type OrderInput struct {
-	CustomerID    *uint            `json:"customer_id"`
-	OrderType     string           `json:"order_type"`
-	Notes         string           `json:"notes"`
-	ScheduledTime *time.Time       `json:"scheduled_time"`
-	Items         []OrderItemInput `json:"order_items"`
+	CustomerID    *uint              `json:"customer_id"`
+	OrderType     string             `json:"order_type"`
+	ServiceMode   models.ServiceMode `json:"service_mode"` // "on_site" or "takeaway"; defaults to takeaway for phone orders, on_site otherwise
+	Notes         string             `json:"notes"`
+	ScheduledTime *time.Time         `json:"scheduled_time"`
+	Items         []OrderItemInput   `json:"order_items"`
 }
 
 type StatusInput struct {
 	Status string `json:"status"`
 }
 
+var errOrderStatusChanged = errors.New("order status changed concurrently")
+
+// checkOrderTransition enforces the order workflow (workflow.Orders) for the current user's permissions.
+// It answers 400 when the workflow has no such transition and 403 when the user's role lacks the
+// permission of the transition, and reports whether the caller can go ahead.
+func checkOrderTransition(c *gin.Context, from, to string) bool {
+	err := workflow.Orders.Check(from, to, func(permission string) bool {
+		return middlewares.HasPermission(c, permission)
+	})
+	switch {
+	case errors.Is(err, workflow.ErrInvalidTransition):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transition from '" + from + "' to '" + to + "'"})
+		return false
+	case errors.Is(err, workflow.ErrNotPermitted):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot move an order from '" + from + "' to '" + to + "'", "permission": models.OrderStatusPermission(to)})
+		return false
+	}
+	return true
+}
+
+// transitionOrder moves an order from one status to another and records the change in its history.
+// The update is conditional on the current status, so a concurrent change makes it fail with
+// errOrderStatusChanged instead of being overwritten. It must run inside the caller's transaction.
+func transitionOrder(tx *gorm.DB, orderID uint, from, to string, userID *uint) error {
+	res := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ?", orderID, from).
+		Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errOrderStatusChanged
+	}
+
+	return tx.Create(&models.OrderStatusEvent{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		UserID:     userID,
+	}).Error
+}
+
 // orderPreloads applies the standard set of relationship preloads for order queries.
 // This ensures all nested data (customer, creator, items, products, menus, options) is loaded.
 func orderPreloads(db *gorm.DB) *gorm.DB {
@@ -44,166 +100,514 @@ func orderPreloads(db *gorm.DB) *gorm.DB {
 		Preload("CreatedBy").
 		Preload("OrderItems.Product").
 		Preload("OrderItems.Menu").
-		Preload("OrderItems.OrderItemOptions.OptionValue")
+		Preload("OrderItems.OrderItemOptions.OptionValue").
+		Preload("OrderItems.Substitutions.Product").
+		Preload("OrderItems.TaxLines", byRate).
+		Preload("TaxLines", byRate).
+		Preload("Discounts")
 }
 
-// CreateOrder creates a new order with server-side price calculation.
-// All pricing is computed from the database inside a transaction to ensure consistency:
-// - Each item must reference exactly one product or one menu (not both)
-// - Product/menu must be available; option values must belong to the item's product
-// - Unit prices, option prices, item totals, and the order total are all computed server-side
-// The order starts in "pending" status. The authenticated user is recorded as the creator.
+// orderPricing is what pricing the items of an order reads once per order: the moment the order is priced at,
+// the availability schedules and the scheduled prices due by then.
+type orderPricing struct {
+	at        time.Time
+	schedules schedules
+	prices    duePrices
+}
+
+// loadOrderPricing reads the schedules and due prices for pricing an order at a given time.
+func loadOrderPricing(tx *gorm.DB, at time.Time) (orderPricing, error) {
+	pricing := orderPricing{at: at}
+	var err error
+	if pricing.schedules, err = loadSchedules(tx); err != nil {
+		return pricing, err
+	}
+	pricing.prices, err = loadDuePrices(tx, at)
+	return pricing, err
+}
+
+// priceOrderItem validates an item input and captures its prices from the database:
+//   - The item must reference exactly one product or one menu, with a quantity of at least 1
+//   - The product or menu must be available, and within its availability schedules at the pricing time (a menu's
+//     products too, as served; see schedules.productClosed); option values must belong to the item's product,
+//     or for a menu item to the product of the menu component they name (menu_product_id)
+//   - Menu substitutions must replace optional components with allowed substitutes (see applySubstitutions)
+//   - Option values must follow the option groups of that product (see checkOptionSelection)
+//   - Unit price, option prices, substitution price differences and item total are snapshotted from the catalog,
+//     scheduled price changes due by the pricing time included (see duePrices)
+//   - The item total is split into net and VAT per rate for the service mode (see itemTaxLines)
 //
-// @Summary Create a new order
-// @Description Create an order with items and options. Prices are computed server-side.
-// @Tags Orders
-// @Accept json
-// @Produce json
-// @Param order body OrderInput true "Order details"
-// @Success 201 {object} models.Order
-// @Failure 400 {object} map[string]string "Invalid data"
-// @Failure 500 {object} map[string]string "Internal error"
-// @Security BearerAuth
-// @Router /orders [post]
-func CreateOrder(c *gin.Context) {
-	var input OrderInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
-		return
+// It only reads. It returns the unsaved item with its options, substitutions and VAT lines, and for menu items the
+// menu with its components as served, substitutions applied (needed to reserve stock). Errors are meant to be shown
+// to the user.
+func priceOrderItem(tx *gorm.DB, input OrderItemInput, mode models.ServiceMode, pricing orderPricing) (models.OrderItem, models.Menu, error) {
+	var menu models.Menu
+	at, schedules := pricing.at, pricing.schedules
+
+	// Validate exactly one of ProductID or MenuID
+	hasProduct := input.ProductID != nil
+	hasMenu := input.MenuID != nil
+	if hasProduct == hasMenu {
+		return models.OrderItem{}, menu, errors.New("each item must have exactly one of product_id or menu_id")
 	}
 
-	// Validate order type
-	if input.OrderType != "counter" && input.OrderType != "phone" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order type must be 'counter' or 'phone'"})
-		return
+	if input.Quantity == 0 {
+		return models.OrderItem{}, menu, errors.New("item quantity must be at least 1")
 	}
 
-	// Validate customer exists if provided
-	if input.CustomerID != nil {
-		var customer models.Customer
-		if err := config.DB.First(&customer, *input.CustomerID).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Customer not found"})
-			return
+	var product models.Products
+	var unitPrice models.Money
+	var itemName string // e.g. "product 'Big Mac'", used in option errors
+	var substitutions []models.OrderItemSubstitution
+	var substitutionDelta models.Money
+	var err error
+
+	if hasProduct {
+		if err := tx.Preload("Category").First(&product, *input.ProductID).Error; err != nil {
+			return models.OrderItem{}, menu, errors.New("product not found")
+		}
+		if !product.IsAvailable {
+			return models.OrderItem{}, menu, errors.New("product '" + product.Name + "' is not available")
+		}
+		if schedules.productClosed(product.ID, product.CategoryID, at) != "" {
+			return models.OrderItem{}, menu, errors.New("product '" + product.Name + "' is not available at this time")
+		}
+		pricing.prices.product(&product)
+		unitPrice = product.Price
+		itemName = "product '" + product.Name + "'"
+		if len(input.Substitutions) > 0 {
+			return models.OrderItem{}, menu, errors.New(itemName + ": substitutions are only allowed on menu items")
+		}
+	} else {
+		if err := tx.Preload("MenuProducts.Product.Category").Preload("MenuProducts.Substitutes").First(&menu, *input.MenuID).Error; err != nil {
+			return models.OrderItem{}, menu, errors.New("menu not found")
+		}
+		if !menu.IsAvailable {
+			return models.OrderItem{}, menu, errors.New("menu '" + menu.Name + "' is not available")
+		}
+		if !models.SchedulesOpen(schedules.menus[menu.ID], restaurantTime(at)) {
+			return models.OrderItem{}, menu, errors.New("menu '" + menu.Name + "' is not available at this time")
+		}
+		itemName = "menu '" + menu.Name + "'"
+
+		// Swap the replaced components first, so options, schedules and stock apply to the product actually served
+		substitutions, substitutionDelta, err = applySubstitutions(tx, itemName, &menu, input.Substitutions)
+		if err != nil {
+			return models.OrderItem{}, menu, err
+		}
+		pricing.prices.menu(&menu) // The components' prices share the menu price between VAT rates
+		unitPrice = menu.Price
+		for _, mp := range menu.MenuProducts {
+			if schedules.productClosed(mp.ProductID, mp.Product.CategoryID, at) != "" {
+				return models.OrderItem{}, menu, errors.New(itemName + ": product '" + mp.Product.Name + "' is not available at this time")
+			}
 		}
 	}
 
-	// Require at least one item
-	if len(input.Items) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order must have at least one item"})
-		return
+	// Process options and compute option price sum
+	var optionPriceSum models.Money
+	var optionRecords []models.OrderItemOption
+	componentExtras := make(map[uint]models.Money)   // option prices and price differences per menu component
+	selected := make(map[uint][]models.OptionValues) // per menu component (key 0 for a product item)
+
+	for _, optInput := range input.Options {
+		var optionValue models.OptionValues
+		if err := tx.Preload("Option").First(&optionValue, optInput.OptionValueID).Error; err != nil {
+			return models.OrderItem{}, menu, errors.New("option value not found")
+		}
+		pricing.prices.optionValue(&optionValue)
+
+		// Verify the option belongs to the product, or to the menu component it is attached to
+		var component uint
+		if hasProduct {
+			if optInput.MenuProductID != nil {
+				return models.OrderItem{}, menu, errors.New(itemName + ": menu_product_id is only allowed on menu items")
+			}
+			if optionValue.Option.ProductID != *input.ProductID {
+				return models.OrderItem{}, menu, errors.New("option value does not belong to the selected product")
+			}
+		} else {
+			if optInput.MenuProductID == nil {
+				return models.OrderItem{}, menu, errors.New(itemName + ": each option must name the menu component it applies to (menu_product_id)")
+			}
+			idx := slices.IndexFunc(menu.MenuProducts, func(mp models.MenuProduct) bool { return mp.ID == *optInput.MenuProductID })
+			if idx < 0 {
+				return models.OrderItem{}, menu, errors.New(itemName + ": menu component not found")
+			}
+			mp := menu.MenuProducts[idx]
+			if optionValue.Option.ProductID != mp.ProductID {
+				return models.OrderItem{}, menu, errors.New(itemName + ": option value does not belong to '" + mp.Product.Name + "'")
+			}
+			component = mp.ID
+		}
+
+		selected[component] = append(selected[component], optionValue)
+		optionPriceSum += optionValue.OptionPrice
+		componentExtras[component] += optionValue.OptionPrice
+		optionRecords = append(optionRecords, models.OrderItemOption{
+			OptionValueID: optInput.OptionValueID,
+			PriceApplied:  optionValue.OptionPrice,
+			MenuProductID: optInput.MenuProductID,
+		})
 	}
 
-	var createdOrder models.Order
+	// Enforce the option group rules (required, single choice, no duplicates),
+	// for the product or for each component of the menu
+	if hasProduct {
+		if err := checkProductOptions(tx, itemName, *input.ProductID, selected[0]); err != nil {
+			return models.OrderItem{}, menu, err
+		}
+	} else {
+		for _, mp := range menu.MenuProducts {
+			name := itemName + ", product '" + mp.Product.Name + "'"
+			if err := checkProductOptions(tx, name, mp.ProductID, selected[mp.ID]); err != nil {
+				return models.OrderItem{}, menu, err
+			}
+		}
+	}
 
-	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		// Create order record
-		order := models.Order{
-			CustomerID:    input.CustomerID,
-			CreatedByID:   uint(c.GetInt("userID")),
-			OrderType:     input.OrderType,
-			Status:        "pending",
-			Notes:         input.Notes,
-			ScheduledTime: input.ScheduledTime,
-			TotalPrice:    0,
+	itemTotal := (unitPrice + optionPriceSum + substitutionDelta).Times(input.Quantity)
+
+	// Split the total across VAT rates: a product has its own rate, a menu the rates of its components
+	var shares []taxShare
+	if hasProduct {
+		shares = []taxShare{{rate: product.TaxRate(mode), gross: itemTotal}}
+	} else {
+		for _, sub := range substitutions {
+			componentExtras[sub.MenuProductID] += sub.PriceDelta
+		}
+		shares = menuTaxShares(menu, input.Quantity, mode, componentExtras)
+	}
+	taxLines := itemTaxLines(shares)
+	var netTotal, taxTotal models.Money
+	for _, line := range taxLines {
+		netTotal += line.Net
+		taxTotal += line.Tax
+	}
+
+	return models.OrderItem{
+		ProductID:        input.ProductID,
+		Product:          product, // Its category decides which promotions apply
+		MenuID:           input.MenuID,
+		Quantity:         input.Quantity,
+		UnitPrice:        unitPrice,
+		ItemTotal:        itemTotal,
+		NetTotal:         netTotal,
+		TaxTotal:         taxTotal,
+		OrderItemOptions: optionRecords,
+		Substitutions:    substitutions,
+		TaxLines:         taxLines,
+	}, menu, nil
+}
+
+// saveOrderItem writes a priced item (see priceOrderItem) with its options, substitutions and VAT lines, then
+// reserves its stock (recorded as sale movements in the stock ledger). An item with an ID replaces the stored one;
+// its previous details are removed and its previous reservation must already have been released.
+func saveOrderItem(tx *gorm.DB, item *models.OrderItem, menu models.Menu, userID *uint) error {
+	options, substitutions, taxLines := item.OrderItemOptions, item.Substitutions, item.TaxLines
+	item.OrderItemOptions, item.Substitutions, item.TaxLines = nil, nil, nil
+
+	if item.ID == 0 {
+		if err := tx.Omit(clause.Associations).Create(item).Error; err != nil {
+			return err
+		}
+	} else {
+		if err := tx.Omit(clause.Associations).Save(item).Error; err != nil {
+			return err
+		}
+		if err := deleteOrderItemDetails(tx, item.ID); err != nil {
+			return err
+		}
+	}
+
+	if item.ProductID != nil {
+		if err := reserveStock(tx, *item, *item.ProductID, item.Quantity, userID); err != nil {
+			return err
 		}
+	} else if err := reserveMenuStock(tx, *item, menu, userID); err != nil {
+		return err
+	}
 
-		if err := tx.Create(&order).Error; err != nil {
+	// Create option records
+	for i := range options {
+		options[i].ID = 0
+		options[i].OrderItemID = item.ID
+		if err := tx.Create(&options[i]).Error; err != nil {
 			return err
 		}
+	}
+	item.OrderItemOptions = options
 
-		var totalPrice float64
+	for i := range substitutions {
+		substitutions[i].ID = 0
+		substitutions[i].OrderItemID = item.ID
+		if err := tx.Omit("Product").Create(&substitutions[i]).Error; err != nil {
+			return err
+		}
+	}
+	item.Substitutions = substitutions
 
-		for _, itemInput := range input.Items {
-			// Validate exactly one of ProductID or MenuID
-			hasProduct := itemInput.ProductID != nil
-			hasMenu := itemInput.MenuID != nil
-			if hasProduct == hasMenu {
-				return errors.New("each item must have exactly one of product_id or menu_id")
-			}
+	for i := range taxLines {
+		taxLines[i].ID = 0
+		taxLines[i].OrderItemID = item.ID
+		if err := tx.Create(&taxLines[i]).Error; err != nil {
+			return err
+		}
+	}
+	item.TaxLines = taxLines
+	return nil
+}
 
-			if itemInput.Quantity == 0 {
-				return errors.New("item quantity must be at least 1")
-			}
+// deleteOrderItemDetails removes the options, substitutions and VAT lines of order items.
+// itemIDs is an item ID or a subquery selecting item IDs.
+func deleteOrderItemDetails(tx *gorm.DB, itemIDs interface{}) error {
+	for _, detail := range []interface{}{&models.OrderItemOption{}, &models.OrderItemSubstitution{}, &models.OrderItemTax{}} {
+		if err := tx.Where("order_item_id IN (?)", itemIDs).Delete(detail).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			var unitPrice float64
+// updateOrderTotals evaluates the promotions in effect at a given time against the stored items of an order (see applyPromotions),
+// records its discount lines, and recomputes the order total and its VAT summary.
+func updateOrderTotals(tx *gorm.DB, order *models.Order, at time.Time) error {
+	var items []models.OrderItem
+	if err := tx.Preload("Product").Preload("TaxLines").Where("order_id = ?", order.ID).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
 
-			if hasProduct {
-				var product models.Products
-				if err := tx.First(&product, *itemInput.ProductID).Error; err != nil {
-					return errors.New("product not found")
-				}
-				if !product.IsAvailable {
-					return errors.New("product '" + product.Name + "' is not available")
-				}
-				unitPrice = product.Price
-			} else {
-				var menu models.Menu
-				if err := tx.First(&menu, *itemInput.MenuID).Error; err != nil {
-					return errors.New("menu not found")
-				}
-				if !menu.IsAvailable {
-					return errors.New("menu '" + menu.Name + "' is not available")
-				}
-				unitPrice = menu.Price
-			}
+	discounts, err := applyPromotions(tx, items, at)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderDiscount{}).Error; err != nil {
+		return err
+	}
+	for i := range discounts {
+		discounts[i].OrderID = order.ID
+		if err := tx.Create(&discounts[i]).Error; err != nil {
+			return err
+		}
+	}
 
-			// Process options and compute option price sum
-			var optionPriceSum float64
-			var optionRecords []models.OrderItemOption
+	var subtotal, discountTotal models.Money
+	for _, item := range items {
+		if err := tx.Model(&models.OrderItem{}).Where("id = ?", item.ID).Update("discount", item.Discount).Error; err != nil {
+			return err
+		}
+		subtotal += item.ItemTotal
+		discountTotal += item.Discount
+	}
 
-			for _, optInput := range itemInput.Options {
-				var optionValue models.OptionValues
-				if err := tx.Preload("Option").First(&optionValue, optInput.OptionValueID).Error; err != nil {
-					return errors.New("option value not found")
-				}
+	order.TotalPrice = subtotal - discountTotal
+	order.DiscountTotal = discountTotal
+	if err := tx.Model(order).Select("TotalPrice", "DiscountTotal").Updates(order).Error; err != nil {
+		return err
+	}
+	return updateOrderTaxLines(tx, order.ID, items)
+}
 
-				// Verify the option belongs to the product (only for product items)
-				if hasProduct {
-					if optionValue.Option.ProductID != *itemInput.ProductID {
-						return errors.New("option value does not belong to the selected product")
-					}
-				}
+// validateOrderInput checks the order-level fields shared by creation and full edits:
+// the order type, the service mode (defaulted when missing), the customer if one is given,
+// and the presence of at least one item.
+func validateOrderInput(input *OrderInput) error {
+	// Validate order type
+	if input.OrderType != "counter" && input.OrderType != "phone" {
+		return errors.New("Order type must be 'counter' or 'phone'")
+	}
 
-				optionPriceSum += optionValue.OptionPrice
-				optionRecords = append(optionRecords, models.OrderItemOption{
-					OptionValueID: optInput.OptionValueID,
-					PriceApplied:  optionValue.OptionPrice,
-				})
-			}
+	// Phone orders are picked up; counter orders are eaten on site unless told otherwise
+	if input.ServiceMode == "" {
+		input.ServiceMode = models.ServiceOnSite
+		if input.OrderType == "phone" {
+			input.ServiceMode = models.ServiceTakeaway
+		}
+	}
+	if !input.ServiceMode.Valid() {
+		return errors.New("Service mode must be 'on_site' or 'takeaway'")
+	}
 
-			itemTotal := (unitPrice + optionPriceSum) * float64(itemInput.Quantity)
+	// Validate customer exists if provided
+	if input.CustomerID != nil {
+		var customer models.Customer
+		if err := config.DB.First(&customer, *input.CustomerID).Error; err != nil {
+			return errors.New("Customer not found")
+		}
+	}
 
-			orderItem := models.OrderItem{
-				OrderID:   order.ID,
-				ProductID: itemInput.ProductID,
-				MenuID:    itemInput.MenuID,
-				Quantity:  itemInput.Quantity,
-				UnitPrice: unitPrice,
-				ItemTotal: itemTotal,
-			}
+	// Require at least one item
+	if len(input.Items) == 0 {
+		return errors.New("Order must have at least one item")
+	}
+	return nil
+}
 
-			if err := tx.Create(&orderItem).Error; err != nil {
-				return err
-			}
+// createOrder writes a new order for validated input (see validateOrderInput) inside tx: the order record,
+// the opening entry of its history, its items priced and their stock reserved (see priceOrderItem and
+// saveOrderItem), then the promotions in effect and the totals (see updateOrderTotals). The availability
+// schedules and promotions are those in effect at the given time.
+// userID is recorded as the creator. Errors are meant to be shown to the user.
+func createOrder(tx *gorm.DB, input OrderInput, userID *uint, at time.Time) (models.Order, error) {
+	order := models.Order{
+		CustomerID:    input.CustomerID,
+		OrderType:     input.OrderType,
+		ServiceMode:   input.ServiceMode,
+		Status:        "pending",
+		Notes:         input.Notes,
+		ScheduledTime: input.ScheduledTime,
+		TotalPrice:    0,
+	}
+	if userID != nil {
+		order.CreatedByID = *userID
+	}
 
-			// Create option records
-			for i := range optionRecords {
-				optionRecords[i].OrderItemID = orderItem.ID
-				if err := tx.Create(&optionRecords[i]).Error; err != nil {
-					return err
+	if err := tx.Create(&order).Error; err != nil {
+		return order, err
+	}
+
+	// Creation opens the order's status history
+	if err := tx.Create(&models.OrderStatusEvent{OrderID: order.ID, ToStatus: order.Status, UserID: userID}).Error; err != nil {
+		return order, err
+	}
+
+	pricing, err := loadOrderPricing(tx, at)
+	if err != nil {
+		return order, err
+	}
+	for _, itemInput := range input.Items {
+		item, menu, err := priceOrderItem(tx, itemInput, input.ServiceMode, pricing)
+		if err != nil {
+			return order, err
+		}
+		item.OrderID = order.ID
+		if err := saveOrderItem(tx, &item, menu, userID); err != nil {
+			return order, err
+		}
+	}
+
+	if err := updateOrderTotals(tx, &order, at); err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+// quoteOrder prices an order without writing anything or taking any lock, so a quote never holds up the
+// orders being created. The items go through the same checks and pricing as at creation (see priceOrderItem),
+// the stock they would take is checked against the current stock with plain reads (see checkStock), and the
+// promotions and totals are computed as updateOrderTotals does. It returns the order as it would be created
+// at the given time, without identifiers. Stock taken by another order after the quote is only seen at creation.
+func quoteOrder(input OrderInput, userID *uint, at time.Time) (models.Order, error) {
+	tx := config.DB
+	quote := models.Order{
+		CustomerID:    input.CustomerID,
+		OrderType:     input.OrderType,
+		ServiceMode:   input.ServiceMode,
+		Status:        "pending",
+		Notes:         input.Notes,
+		ScheduledTime: input.ScheduledTime,
+	}
+	if input.CustomerID != nil {
+		if err := tx.First(&quote.Customer, *input.CustomerID).Error; err != nil {
+			return models.Order{}, err
+		}
+	}
+	if userID != nil {
+		quote.CreatedByID = *userID
+		if err := tx.First(&quote.CreatedBy, *userID).Error; err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	pricing, err := loadOrderPricing(tx, at)
+	if err != nil {
+		return models.Order{}, err
+	}
+	taken := make(map[uint]uint) // Units of each product taken by the items before
+	for _, itemInput := range input.Items {
+		item, menu, err := priceOrderItem(tx, itemInput, input.ServiceMode, pricing)
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		if item.ProductID != nil {
+			if err := checkStock(tx, *item.ProductID, item.Quantity, taken); err != nil {
+				return models.Order{}, err
+			}
+		} else {
+			for _, mp := range menu.MenuProducts {
+				if err := checkStock(tx, mp.ProductID, mp.Quantity*item.Quantity, taken); err != nil {
+					return models.Order{}, fmt.Errorf("menu '%s': %w", menu.Name, err)
 				}
 			}
-
-			totalPrice += itemTotal
+			// Shown like the stored order: the menu itself, without its components
+			item.Menu = menu
+			item.Menu.MenuProducts = nil
 		}
 
-		// Update total price
-		if err := tx.Model(&order).Update("total_price", totalPrice).Error; err != nil {
-			return err
+		// Shown like the stored order: each option with its value
+		for i := range item.OrderItemOptions {
+			option := &item.OrderItemOptions[i]
+			if err := tx.First(&option.OptionValue, option.OptionValueID).Error; err != nil {
+				return models.Order{}, err
+			}
+			option.OptionValue.OptionPrice = option.PriceApplied
 		}
+		item.Status = "pending"
+		quote.OrderItems = append(quote.OrderItems, item)
+	}
 
-		createdOrder = order
-		return nil
+	discounts, err := applyPromotions(tx, quote.OrderItems, at)
+	if err != nil {
+		return models.Order{}, err
+	}
+	quote.Discounts = discounts
+	for _, item := range quote.OrderItems {
+		quote.TotalPrice += item.ItemTotal - item.Discount
+		quote.DiscountTotal += item.Discount
+	}
+	quote.TaxLines = orderTaxLines(quote.OrderItems)
+	return quote, nil
+}
+
+// CreateOrder creates a new order with server-side price calculation.
+// All pricing is computed from the database inside a transaction to ensure consistency (see priceOrderItem):
+// - Each item must reference exactly one product or one menu (not both)
+// - Product/menu must be available; option values must belong to the item's product
+// - Stock is reserved for the product (or each menu component); the order is rejected if any item is short
+// - Unit prices, option prices, item totals, and the order total are all computed server-side
+// - Active promotions are applied and recorded as discount lines (see applyPromotions)
+// The order starts in "pending" status. The authenticated user is recorded as the creator.
+// QuoteOrder prices the same input without writing anything.
+//
+// @Summary Create a new order
+// @Description Create an order with items and options. Prices are computed server-side.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order body OrderInput true "Order details"
+// @Success 201 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /orders [post]
+func CreateOrder(c *gin.Context) {
+	var input OrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateOrderInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createdOrder models.Order
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		createdOrder, err = createOrder(tx, input, currentUserID(c), now())
+		return err
 	})
 
 	if err != nil {
@@ -211,6 +615,8 @@ func CreateOrder(c *gin.Context) {
 		return
 	}
 
+	publishOrderEvent(events.OrderCreated, createdOrder.ID, createdOrder.Status, "")
+
 	// Reload with preloads
 	var result models.Order
 	if err := orderPreloads(config.DB).First(&result, createdOrder.ID).Error; err != nil {
@@ -221,33 +627,99 @@ func CreateOrder(c *gin.Context) {
 	c.JSON(http.StatusCreated, result)
 }
 
-// GetOrders returns all orders with optional status filtering.
-// Use ?status=pending to filter. All relationships are preloaded.
+// QuoteOrder prices an order without creating it, so the customer can be told the exact total before confirming.
+// It takes the same input as CreateOrder and runs the same validation and pricing (see quoteOrder): unit prices,
+// option prices, line totals, discounts, VAT and the order total are those the order would get if created now.
+// Nothing is saved, no stock is reserved and no row is locked, but an item out of stock is reported as it would be
+// at creation.
+//
+// @Summary Quote an order
+// @Description Dry run of order creation: validate and price an order without saving it
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order body OrderInput true "Order details"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Security BearerAuth
+// @Router /orders/quote [post]
+func QuoteOrder(c *gin.Context) {
+	var input OrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateOrderInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := quoteOrder(input, currentUserID(c), now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// orderListSpec lists the sort fields and filters accepted by GetOrders.
+var orderListSpec = listSpec{
+	Sort: map[string]string{
+		"id":             "id",
+		"created_at":     "created_at",
+		"scheduled_time": "scheduled_time",
+		"total_price":    "total_price",
+		"status":         "status",
+	},
+	DefaultSort: "created_at DESC, id DESC",
+	Filters: []listFilter{
+		{Param: "status", Column: "status", Kind: filterString},
+		{Param: "order_type", Column: "order_type", Kind: filterString},
+		{Param: "created_by", Column: "created_by_id", Kind: filterID},
+		{Param: "customer_id", Column: "customer_id", Kind: filterID},
+		{Param: "from", Column: "created_at", Kind: filterDateFrom},
+		{Param: "to", Column: "created_at", Kind: filterDateTo},
+	},
+}
+
+// GetOrders returns a page of orders, most recent first. All relationships are preloaded.
+// Filters: ?status=, ?order_type=, ?created_by= (user ID), ?customer_id=, ?from= and ?to= (creation date).
+// Paging and sorting: ?limit=, ?offset=, ?sort=-created_at,total_price.
 //
 // @Summary Get all orders
-// @Description Retrieve all orders with optional status filter
+// @Description Retrieve a page of orders with optional filters and sorting
 // @Tags Orders
 // @Produce json
 // @Param status query string false "Filter by status"
-// @Success 200 {array} models.Order
+// @Param order_type query string false "Filter by order type (counter, phone)"
+// @Param created_by query int false "Filter by the staff member who created the order"
+// @Param customer_id query int false "Filter by customer"
+// @Param from query string false "Created on or after (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Created on or before (YYYY-MM-DD or RFC 3339)"
+// @Param sort query string false "Sort fields: id, created_at, scheduled_time, total_price, status (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of orders to skip"
+// @Success 200 {object} Page{data=[]models.Order}
+// @Failure 400 {object} map[string]string "Invalid filter, sort or paging parameter"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /orders [get]
 func GetOrders(c *gin.Context) {
 	var orders []models.Order
 
-	query := orderPreloads(config.DB)
-
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	if err := query.Find(&orders).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Order{}), orderListSpec, orderPreloads, &orders)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve orders"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, page)
 }
 
 // GetOrder returns a single order by ID with all items, options, and relationships preloaded.
@@ -284,10 +756,159 @@ func GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
-// UpdateOrderStatus advances an order through the preparation workflow.
-// Enforces a strict state machine: pending→preparing→prepared→delivered.
-// Invalid transitions (e.g. pending→delivered) are rejected.
-// Cancellation is handled separately by CancelOrder.
+// pendingOrderParam loads the order named by the :id parameter and checks that it can still be edited.
+// It answers 400, 404 or 500 itself and reports whether the caller can go ahead.
+func pendingOrderParam(c *gin.Context) (models.Order, bool) {
+	var order models.Order
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return order, false
+	}
+
+	if err := config.DB.First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return order, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return order, false
+	}
+
+	if order.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending orders can be edited"})
+		return order, false
+	}
+	return order, true
+}
+
+// editPendingOrder applies an edit to a pending order in one transaction, recomputes the order total
+// and answers with the reloaded order. The order row is first touched with a conditional update, so an
+// edit racing with the kitchen starting the order fails with 409 instead of changing an order in progress.
+// The schedules and due prices are read once for the whole edit and passed to edit for pricing its items.
+// Errors returned by edit are validation messages and are answered with 400.
+func editPendingOrder(c *gin.Context, order models.Order, successStatus int, edit func(tx *gorm.DB, pricing orderPricing) error) {
+	at := now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", order.ID, "pending").
+			Update("updated_at", at)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errOrderStatusChanged
+		}
+
+		pricing, err := loadOrderPricing(tx, at)
+		if err != nil {
+			return err
+		}
+		if err := edit(tx, pricing); err != nil {
+			return err
+		}
+		return updateOrderTotals(tx, &order, at)
+	})
+
+	if errors.Is(err, errOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order status changed meanwhile, reload and try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	publishOrderEvent(events.OrderUpdated, order.ID, order.Status, order.Status)
+
+	// Reload with preloads
+	var result models.Order
+	if err := orderPreloads(config.DB).First(&result, order.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order"})
+		return
+	}
+
+	c.JSON(successStatus, result)
+}
+
+// UpdateOrder replaces the details and the items of a pending order, e.g. to fix a mistake made at the counter.
+// The whole order is validated and repriced like a new one (see CreateOrder): the stock reserved by
+// the previous items is released first, then the new items are priced at current catalog prices and
+// their stock is reserved. Once preparation has started, the order can no longer be edited.
+//
+// @Summary Update a pending order
+// @Description Replace the details and items of a pending order. Prices are recomputed server-side.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param order body OrderInput true "Order details"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} map[string]string "Invalid data or order not pending"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
+// @Security BearerAuth
+// @Router /orders/{id} [put]
+func UpdateOrder(c *gin.Context) {
+	order, ok := pendingOrderParam(c)
+	if !ok {
+		return
+	}
+
+	var input OrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateOrderInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := currentUserID(c)
+	editPendingOrder(c, order, http.StatusOK, func(tx *gorm.DB, pricing orderPricing) error {
+		// Give back what the previous items reserved before checking availability again
+		if err := releaseOrderStock(tx, order.ID, models.StockReasonOrderEdit, userID); err != nil {
+			return err
+		}
+		if err := deleteOrderItemDetails(tx, tx.Model(&models.OrderItem{}).Select("id").Where("order_id = ?", order.ID)); err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&order).Select("CustomerID", "OrderType", "ServiceMode", "Notes", "ScheduledTime").Updates(models.Order{
+			CustomerID:    input.CustomerID,
+			OrderType:     input.OrderType,
+			ServiceMode:   input.ServiceMode,
+			Notes:         input.Notes,
+			ScheduledTime: input.ScheduledTime,
+		}).Error; err != nil {
+			return err
+		}
+
+		for _, itemInput := range input.Items {
+			item, menu, err := priceOrderItem(tx, itemInput, input.ServiceMode, pricing)
+			if err != nil {
+				return err
+			}
+			item.OrderID = order.ID
+			if err := saveOrderItem(tx, &item, menu, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateOrderStatus advances an order through the order workflow (workflow.Orders).
+// Transitions the workflow does not define (e.g. pending→delivered) are rejected, and so are
+// transitions the user's role may not trigger (e.g. the kitchen marking an order delivered).
+// Marking an order prepared also marks all of its items done. Cancellation is normally done
+// through CancelOrder; cancelling here releases the reserved stock the same way.
 //
 // @Summary Update order status
 // @Description Update the status of an order with valid transition enforcement
@@ -298,7 +919,9 @@ func GetOrder(c *gin.Context) {
 // @Param status body StatusInput true "New status"
 // @Success 200 {object} models.Order
 // @Failure 400 {object} map[string]string "Invalid transition"
+// @Failure 403 {object} map[string]string "Transition not allowed for this role"
 // @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
 // @Security BearerAuth
 // @Router /orders/{id}/status [patch]
 func UpdateOrderStatus(c *gin.Context) {
@@ -325,29 +948,44 @@ func UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	// Enforce valid transitions
-	validTransitions := map[string][]string{
-		"pending":   {"preparing", "cancelled"},
-		"preparing": {"prepared"},
-		"prepared":  {"delivered"},
-	}
-
-	allowed, exists := validTransitions[order.Status]
-	if !exists {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No transitions allowed from status '" + order.Status + "'"})
+	// Enforce the workflow: the transition must exist and be allowed for the user's role
+	if !checkOrderTransition(c, order.Status, input.Status) {
 		return
 	}
 
-	if !slices.Contains(allowed, input.Status) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transition from '" + order.Status + "' to '" + input.Status + "'"})
+	previousStatus := order.Status
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if input.Status == "cancelled" {
+			return cancelOrder(tx, order.ID, previousStatus, currentUserID(c))
+		}
+		if err := transitionOrder(tx, order.ID, previousStatus, input.Status, currentUserID(c)); err != nil {
+			return err
+		}
+
+		if input.Status == "prepared" {
+			// Marking the whole order prepared finishes the items still in progress
+			return tx.Model(&models.OrderItem{}).
+				Where("order_id = ? AND status <> ?", order.ID, "done").
+				Update("status", "done").Error
+		}
+		return nil
+	})
+
+	if errors.Is(err, errOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order status changed meanwhile, reload and try again"})
 		return
 	}
-
-	if err := config.DB.Model(&order).Update("status", input.Status).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
 
+	if input.Status == "cancelled" {
+		publishOrderEvent(events.OrderCancelled, order.ID, input.Status, previousStatus)
+	} else {
+		publishOrderEvent(events.OrderStatusChanged, order.ID, input.Status, previousStatus)
+	}
+
 	// Reload with preloads
 	var result models.Order
 	if err := orderPreloads(config.DB).First(&result, id).Error; err != nil {
@@ -358,17 +996,30 @@ func UpdateOrderStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// CancelOrder cancels an order, but only if it is still in "pending" status.
-// Once preparation has started, cancellation is no longer allowed.
+// cancelOrder moves an order from the given status to cancelled and gives back the stock it reserved.
+// Every path that cancels an order goes through it, so none of them can leave the stock reserved.
+func cancelOrder(tx *gorm.DB, orderID uint, from string, userID *uint) error {
+	// Conditional update so that two concurrent cancellations cannot both release the stock
+	if err := transitionOrder(tx, orderID, from, "cancelled", userID); err != nil {
+		return err
+	}
+	return releaseOrderStock(tx, orderID, models.StockReasonCancellation, userID)
+}
+
+// CancelOrder cancels an order, if the order workflow allows cancelling from its current status
+// (only pending by default: once preparation has started, cancellation is no longer allowed).
+// The stock reserved by the order is given back in the same transaction.
 //
 // @Summary Cancel an order
-// @Description Cancel an order (only if status is pending)
+// @Description Cancel an order (only from statuses the workflow allows, pending by default)
 // @Tags Orders
 // @Produce json
 // @Param id path int true "Order ID"
 // @Success 200 {object} models.Order
 // @Failure 400 {object} map[string]string "Cannot cancel"
+// @Failure 403 {object} map[string]string "Cancellation not allowed for this role"
 // @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order status changed concurrently"
 // @Security BearerAuth
 // @Router /orders/{id}/cancel [patch]
 func CancelOrder(c *gin.Context) {
@@ -389,16 +1040,30 @@ func CancelOrder(c *gin.Context) {
 		return
 	}
 
-	if order.Status != "pending" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending orders can be cancelled"})
+	if !workflow.Orders.Allows(order.Status, "cancelled") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order cannot be cancelled while '" + order.Status + "'"})
+		return
+	}
+	if !checkOrderTransition(c, order.Status, "cancelled") {
 		return
 	}
 
-	if err := config.DB.Model(&order).Update("status", "cancelled").Error; err != nil {
+	previousStatus := order.Status
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return cancelOrder(tx, order.ID, previousStatus, currentUserID(c))
+	})
+
+	if errors.Is(err, errOrderStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order status changed meanwhile, reload and try again"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
 		return
 	}
 
+	publishOrderEvent(events.OrderCancelled, order.ID, "cancelled", previousStatus)
+
 	// Reload with preloads
 	var result models.Order
 	if err := orderPreloads(config.DB).First(&result, id).Error; err != nil {
@@ -409,16 +1074,37 @@ func CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// GetOrdersByCustomer returns all orders linked to a specific customer.
+// GetOrderWorkflow returns the order workflow: its statuses, the allowed transitions and the roles
+// that may trigger each of them. Screens use it to only offer the actions the user can perform.
+//
+// @Summary Get the order workflow
+// @Description Statuses, allowed transitions and the roles allowed to trigger each transition
+// @Tags Orders
+// @Produce json
+// @Success 200 {object} workflow.Definition
+// @Security BearerAuth
+// @Router /orders/workflow [get]
+func GetOrderWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, workflow.Orders)
+}
+
+// GetOrdersByCustomer returns a page of the orders linked to a specific customer, most recent first.
 // The customer must exist. Useful for viewing a customer's order history.
+// Accepts the paging, sorting and filters of GetOrders.
 //
 // @Summary Get orders by customer
-// @Description Retrieve all orders for a specific customer
+// @Description Retrieve a page of the orders of a specific customer
 // @Tags Orders
 // @Produce json
 // @Param id path int true "Customer ID"
-// @Success 200 {array} models.Order
-// @Failure 400 {object} map[string]string "Invalid ID"
+// @Param status query string false "Filter by status"
+// @Param from query string false "Created on or after (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "Created on or before (YYYY-MM-DD or RFC 3339)"
+// @Param sort query string false "Sort fields: id, created_at, scheduled_time, total_price, status (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of orders to skip"
+// @Success 200 {object} Page{data=[]models.Order}
+// @Failure 400 {object} map[string]string "Invalid ID, filter, sort or paging parameter"
 // @Failure 404 {object} map[string]string "Customer not found"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
@@ -443,10 +1129,15 @@ func GetOrdersByCustomer(c *gin.Context) {
 	}
 
 	var orders []models.Order
-	if err := orderPreloads(config.DB).Where("customer_id = ?", id).Find(&orders).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Order{}).Where("customer_id = ?", id), orderListSpec, orderPreloads, &orders)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve orders"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	c.JSON(http.StatusOK, page)
 }
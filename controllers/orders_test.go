@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"sync"
 	"testing"
+	"time"
 	"wacdo/config"
 	"wacdo/models"
 	"wacdo/testutils"
@@ -16,12 +19,19 @@ func seedOrder(userID uint, status string, customerID *uint) models.Order {
 		OrderType:   "counter",
 		Status:      status,
 		CustomerID:  customerID,
-		TotalPrice:  10.0,
+		TotalPrice:  1000,
 	}
 	config.DB.Create(&order)
 	return order
 }
 
+// stockOf reads the current stock quantity of a product.
+func stockOf(productID uint) uint {
+	var product models.Products
+	config.DB.First(&product, productID)
+	return product.StockQuantity
+}
+
 func TestCreateOrder_Success(t *testing.T) {
 	db := testutils.SetupTestDB()
 	role := testutils.SeedRole(db, "admin")
@@ -72,6 +82,202 @@ func TestCreateOrder_WithMenu(t *testing.T) {
 	assert.Equal(t, 9.99, resp["total_price"])
 }
 
+// Amounts are summed in cents: the float sums these prices used to produce were off by a fraction of a cent.
+func TestCreateOrder_ExactTotals(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Extras")
+	sauce := testutils.SeedProduct(db, "Sauce", 0.10, cat.ID, true)
+	cookie := testutils.SeedProduct(db, "Cookie", 1.15, cat.ID, true)
+	topping := seedOptionDirect(sauce.ID, "Topping", "multiple")
+	topping.IsRequired = false
+	config.DB.Save(&topping)
+	extra := seedOptionValue(topping.ID, "Extra", 0.20)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": sauce.ID, "quantity": 3, "options": []map[string]interface{}{{"option_value_id": extra.ID}}},
+			{"product_id": cookie.ID, "quantity": 3},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// (0.10 + 0.20) x 3 is 0.90 exactly, where float arithmetic gives 0.9000000000000001
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, 4.35, resp["total_price"])
+	assert.Contains(t, w.Body.String(), `"total_price":4.35`)
+	assert.Contains(t, w.Body.String(), `"item_total":0.90`)
+
+	var order models.Order
+	config.DB.First(&order, uint(resp["id"].(float64)))
+	assert.Equal(t, models.Money(435), order.TotalPrice)
+}
+
+func TestQuoteOrder(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	food := testutils.SeedCategory(db, "Food")
+	drinks := testutils.SeedCategory(db, "Drinks")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, food.ID, true)
+	coke := testutils.SeedProduct(db, "Coke", 2.50, drinks.ID, true)
+	size := seedOptionDirect(coke.ID, "Size", "single")
+	large := seedOptionValue(size.ID, "Large", 0.50)
+	menu := testutils.SeedMenu(db, "Burger Menu", 8.90, true)
+	testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	cokeMP := testutils.SeedMenuProduct(db, menu.ID, coke.ID, 1, false)
+	db.Create(&models.Promotion{Name: "Drinks", Type: models.PromotionPercentage, Percentage: 1000, CategoryID: &drinks.ID, IsActive: true})
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.POST("/orders/quote", QuoteOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"menu_id": menu.ID, "quantity": 2, "options": []map[string]interface{}{{"option_value_id": large.ID, "menu_product_id": cokeMP.ID}}},
+			{"product_id": coke.ID, "quantity": 1, "options": []map[string]interface{}{{"option_value_id": large.ID}}},
+		},
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders/quote", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	quote := testutils.ParseResponse(w)
+	assert.Equal(t, 0.0, quote["id"])
+	// (8.90 + 0.50) x 2 + 3.00, less 10% of the coke
+	assert.Equal(t, 21.50, quote["total_price"])
+	items := quote["order_items"].([]interface{})
+	assert.Equal(t, 18.80, items[0].(map[string]interface{})["item_total"])
+	assert.Equal(t, 0.50, items[1].(map[string]interface{})["order_item_options"].([]interface{})[0].(map[string]interface{})["price_applied"])
+
+	// Nothing was kept: no order, no history, no stock taken
+	var count int64
+	config.DB.Model(&models.Order{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	config.DB.Model(&models.OrderStatusEvent{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	config.DB.Model(&models.StockMovement{}).Where("reason = ?", models.StockReasonSale).Count(&count)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(coke.ID))
+
+	// Creating the same order gives the same prices
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	created := testutils.ParseResponse(w)
+	withoutIDs := func(lines interface{}) interface{} {
+		for _, line := range lines.([]interface{}) {
+			delete(line.(map[string]interface{}), "id")
+			delete(line.(map[string]interface{}), "order_id")
+		}
+		return lines
+	}
+	assert.Equal(t, quote["total_price"], created["total_price"])
+	assert.Equal(t, quote["discount_total"], created["discount_total"])
+	assert.Equal(t, withoutIDs(quote["discounts"]), withoutIDs(created["discounts"]))
+	assert.Equal(t, withoutIDs(quote["tax_lines"]), withoutIDs(created["tax_lines"]))
+	for i, item := range created["order_items"].([]interface{}) {
+		for _, field := range []string{"unit_price", "item_total", "net_total", "tax_total", "discount"} {
+			assert.Equal(t, items[i].(map[string]interface{})[field], item.(map[string]interface{})[field], field)
+		}
+	}
+
+	// Quotes fail where creation would
+	testutils.SetStock(db, burger.ID, 1)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders/quote", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Burger")
+
+	// Including when the items only run short together: two cokes in the menus and one on its own
+	testutils.SetStock(db, burger.ID, 10)
+	testutils.SetStock(db, coke.ID, 2)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders/quote", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "insufficient stock for 'Coke': 1 requested, 0 available")
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCreateOrder_TaxBreakdown(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	food := testutils.SeedCategory(db, "Food") // 10% on site, 5.5% takeaway
+	alcohol := testutils.SeedCategory(db, "Alcohol")
+	db.Model(&alcohol).Updates(models.Category{TaxRateOnSite: 2000, TaxRateTakeaway: 2000})
+	burger := testutils.SeedProduct(db, "Burger", 5.00, food.ID, true)
+	beer := testutils.SeedProduct(db, "Beer", 4.00, alcohol.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, food.ID, true)
+	tenPercent := uint(1000)
+	db.Model(&fries).Update("tax_rate_takeaway", &tenPercent) // overrides the category
+
+	menu := testutils.SeedMenu(db, "Burger & Beer", 10.00, true)
+	testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	testutils.SeedMenuProduct(db, menu.ID, beer.ID, 1, false)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	line := func(v interface{}) map[string]interface{} { return v.(map[string]interface{}) }
+	rates := func(lines interface{}) map[float64][3]float64 {
+		byRate := make(map[float64][3]float64)
+		for _, l := range lines.([]interface{}) {
+			l := line(l)
+			byRate[l["rate"].(float64)] = [3]float64{l["net"].(float64), l["tax"].(float64), l["gross"].(float64)}
+		}
+		return byRate
+	}
+
+	// On site: the menu price is split 5.56 / 4.44 in proportion to burger (5.00) and beer (4.00)
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"menu_id": menu.ID, "quantity": 1},
+			{"product_id": fries.ID, "quantity": 2},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "on_site", resp["service_mode"])
+
+	items := resp["order_items"].([]interface{})
+	assert.Equal(t, map[float64][3]float64{1000: {5.05, 0.51, 5.56}, 2000: {3.70, 0.74, 4.44}}, rates(line(items[0])["tax_lines"]))
+	assert.Equal(t, 8.75, line(items[0])["net_total"])
+	assert.Equal(t, 1.25, line(items[0])["tax_total"])
+	assert.Equal(t, map[float64][3]float64{1000: {3.64, 0.36, 4.00}}, rates(line(items[1])["tax_lines"]))
+
+	// The order summary adds up the items per rate
+	assert.Equal(t, map[float64][3]float64{1000: {8.69, 0.87, 9.56}, 2000: {3.70, 0.74, 4.44}}, rates(resp["tax_lines"]))
+	assert.Equal(t, 14.00, resp["total_price"])
+
+	// Phone orders are taken away by default: burger at 5.5%, fries at their own 10%
+	body = map[string]interface{}{
+		"order_type": "phone",
+		"order_items": []map[string]interface{}{
+			{"product_id": burger.ID, "quantity": 1},
+			{"product_id": fries.ID, "quantity": 1},
+		},
+	}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp = testutils.ParseResponse(w)
+	assert.Equal(t, "takeaway", resp["service_mode"])
+	assert.Equal(t, map[float64][3]float64{550: {4.74, 0.26, 5.00}, 1000: {1.82, 0.18, 2.00}}, rates(resp["tax_lines"]))
+
+	body["service_mode"] = "delivery"
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestCreateOrder_InvalidOrderType(t *testing.T) {
 	db := testutils.SetupTestDB()
 	role := testutils.SeedRole(db, "admin")
@@ -192,6 +398,81 @@ func TestGetOrders_FilterByStatus(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestGetOrders_Pagination(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	for i := 0; i < 5; i++ {
+		seedOrder(user.ID, "pending", nil)
+	}
+
+	r := testutils.SetupRouter()
+	r.GET("/orders", GetOrders)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/orders?limit=2&offset=2&sort=id", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, float64(5), resp["total"])
+	assert.Equal(t, float64(2), resp["limit"])
+	assert.Equal(t, float64(4), resp["next_offset"])
+	data := resp["data"].([]interface{})
+	assert.Len(t, data, 2)
+	assert.Equal(t, float64(3), data[0].(map[string]interface{})["id"])
+
+	// Last page has no next offset
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/orders?limit=2&offset=4", nil))
+	resp = testutils.ParseResponse(w)
+	assert.Len(t, resp["data"], 1)
+	assert.Nil(t, resp["next_offset"])
+
+	// Default sort is most recent first
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/orders", nil))
+	resp = testutils.ParseResponse(w)
+	assert.Equal(t, float64(5), resp["data"].([]interface{})[0].(map[string]interface{})["id"])
+}
+
+func TestGetOrders_Filters(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	other := testutils.SeedUser(db, "other", "other@test.com", "P@ssw0rd", role.ID)
+	customer := testutils.SeedCustomer(db, "John", "0600000000", "john@test.com")
+	seedOrder(user.ID, "pending", &customer.ID)
+	seedOrder(user.ID, "pending", nil)
+	phone := seedOrder(other.ID, "pending", nil)
+	config.DB.Model(&phone).Update("order_type", "phone")
+	old := seedOrder(other.ID, "delivered", nil)
+	config.DB.Model(&old).Update("created_at", time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC))
+
+	r := testutils.SetupRouter()
+	r.GET("/orders", GetOrders)
+
+	cases := map[string]float64{
+		"/orders?order_type=phone":                                 1,
+		"/orders?created_by=" + fmt.Sprint(other.ID):               2,
+		"/orders?customer_id=" + fmt.Sprint(customer.ID):           1,
+		"/orders?from=2025-01-01&to=2025-01-10":                    1,
+		"/orders?status=pending&created_by=" + fmt.Sprint(user.ID): 2,
+	}
+	for url, expected := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("GET", url, nil))
+		assert.Equal(t, http.StatusOK, w.Code, url)
+		assert.Equal(t, expected, testutils.ParseResponse(w)["total"], url)
+	}
+}
+
+func TestGetOrders_InvalidQuery(t *testing.T) {
+	testutils.SetupTestDB()
+
+	r := testutils.SetupRouter()
+	r.GET("/orders", GetOrders)
+
+	for _, url := range []string{"/orders?sort=password", "/orders?limit=0", "/orders?offset=-1", "/orders?created_by=abc", "/orders?from=yesterday"} {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("GET", url, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
 func TestGetOrder_Success(t *testing.T) {
 	db := testutils.SetupTestDB()
 	role := testutils.SeedRole(db, "admin")
@@ -226,6 +507,7 @@ func TestUpdateOrderStatus_ValidTransition(t *testing.T) {
 	order := seedOrder(user.ID, "pending", nil)
 
 	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
 	r.PATCH("/orders/:id/status", UpdateOrderStatus)
 
 	body := map[string]string{"status": "preparing"}
@@ -244,6 +526,7 @@ func TestUpdateOrderStatus_InvalidTransition(t *testing.T) {
 	order := seedOrder(user.ID, "pending", nil)
 
 	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
 	r.PATCH("/orders/:id/status", UpdateOrderStatus)
 
 	body := map[string]string{"status": "delivered"} // can't go from pending to delivered
@@ -260,6 +543,7 @@ func TestUpdateOrderStatus_FullWorkflow(t *testing.T) {
 	order := seedOrder(user.ID, "pending", nil)
 
 	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
 	r.PATCH("/orders/:id/status", UpdateOrderStatus)
 
 	transitions := []string{"preparing", "prepared", "delivered"}
@@ -279,6 +563,7 @@ func TestCancelOrder_FromPending(t *testing.T) {
 	order := seedOrder(user.ID, "pending", nil)
 
 	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
 	r.PATCH("/orders/:id/cancel", CancelOrder)
 
 	req := testutils.JSONRequest("PATCH", testutils.IDParam("/orders", order.ID)+"/cancel", nil)
@@ -296,6 +581,7 @@ func TestCancelOrder_FromPreparing(t *testing.T) {
 	order := seedOrder(user.ID, "preparing", nil) // not pending
 
 	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
 	r.PATCH("/orders/:id/cancel", CancelOrder)
 
 	req := testutils.JSONRequest("PATCH", testutils.IDParam("/orders", order.ID)+"/cancel", nil)
@@ -321,15 +607,39 @@ func TestGetOrdersByCustomer_Success(t *testing.T) {
 	role := testutils.SeedRole(db, "admin")
 	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
 	cust := testutils.SeedCustomer(db, "John", "0612345678", "john@test.com")
-	seedOrder(user.ID, "pending", &cust.ID)
+	other := testutils.SeedCustomer(db, "Jane", "0698765432", "jane@test.com")
+	first := seedOrder(user.ID, "pending", &cust.ID)
+	second := seedOrder(user.ID, "delivered", &cust.ID)
+	third := seedOrder(user.ID, "pending", &cust.ID)
+	seedOrder(user.ID, "pending", &other.ID)
 
 	r := testutils.SetupRouter()
 	r.GET("/customers/:id/orders", GetOrdersByCustomer)
 
-	req := testutils.JSONRequest("GET", testutils.IDParam("/customers", cust.ID)+"/orders", nil)
-	w := testutils.PerformRequest(r, req)
-
+	firstID := func(resp map[string]interface{}) interface{} {
+		return resp["data"].([]interface{})[0].(map[string]interface{})["id"]
+	}
+	url := testutils.IDParam("/customers", cust.ID) + "/orders"
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", url+"?sort=id&limit=2", nil))
 	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, float64(3), resp["total"])
+	assert.Len(t, resp["data"], 2)
+	assert.Equal(t, float64(first.ID), firstID(resp))
+	assert.Equal(t, float64(2), resp["next_offset"])
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", url+"?sort=id&limit=2&offset=2", nil))
+	resp = testutils.ParseResponse(w)
+	assert.Len(t, resp["data"], 1)
+	assert.Equal(t, float64(third.ID), firstID(resp))
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", url+"?status=delivered", nil))
+	resp = testutils.ParseResponse(w)
+	assert.Len(t, resp["data"], 1)
+	assert.Equal(t, float64(second.ID), firstID(resp))
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", url+"?sort=unknown", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
 func TestGetOrdersByCustomer_CustomerNotFound(t *testing.T) {
@@ -343,3 +653,555 @@ func TestGetOrdersByCustomer_CustomerNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestCreateOrder_ReservesProductStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": p.ID, "quantity": 3},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock-3), stockOf(p.ID))
+}
+
+func TestCreateOrder_ReservesMenuComponentStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	nuggets := testutils.SeedProduct(db, "Nuggets", 3.99, cat.ID, true)
+	m := testutils.SeedMenu(db, "Big Mac Menu", 9.99, true)
+	testutils.SeedMenuProduct(db, m.ID, burger.ID, 1, false)
+	testutils.SeedMenuProduct(db, m.ID, nuggets.ID, 2, false)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"menu_id": m.ID, "quantity": 2},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock-2), stockOf(burger.ID))
+	assert.Equal(t, uint(testutils.DefaultStock-4), stockOf(nuggets.ID))
+}
+
+func TestCreateOrder_InsufficientStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	fries := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	testutils.SetStock(db, burger.ID, 1)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": fries.ID, "quantity": 1},
+			{"product_id": burger.ID, "quantity": 2},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "insufficient stock for 'Big Mac': 2 requested, 1 available", resp["error"])
+
+	// The whole order is rolled back, including the fries reservation
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(fries.ID))
+	var count int64
+	db.Model(&models.Order{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCreateOrder_InsufficientMenuComponentStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Sides")
+	fries := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+	testutils.SetStock(db, fries.ID, 0)
+	m := testutils.SeedMenu(db, "Big Mac Menu", 9.99, true)
+	testutils.SeedMenuProduct(db, m.ID, fries.ID, 1, false)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"menu_id": m.ID, "quantity": 1},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "menu 'Big Mac Menu': insufficient stock for 'Fries': 1 requested, 0 available", resp["error"])
+}
+
+func TestCreateOrder_ConcurrentOrdersForLastUnit(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	testutils.SetStock(db, p.ID, 1)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": p.ID, "quantity": 1},
+		},
+	}
+
+	const counters = 5
+	codes := make(chan int, counters)
+	var wg sync.WaitGroup
+	for i := 0; i < counters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body)).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			created++
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	assert.Equal(t, uint(0), stockOf(p.ID))
+}
+
+func TestCancelOrder_ReleasesStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+	m := testutils.SeedMenu(db, "Big Mac Menu", 9.99, true)
+	testutils.SeedMenuProduct(db, m.ID, fries.ID, 1, false)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/orders/:id/cancel", CancelOrder)
+
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"product_id": burger.ID, "quantity": 2},
+			{"menu_id": m.ID, "quantity": 3},
+		},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/cancel", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(burger.ID))
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(fries.ID))
+
+	// A second cancellation is rejected and must not release the stock twice
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/cancel", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(burger.ID))
+}
+
+func TestUpdateOrderStatus_CancelledReleasesStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": burger.ID, "quantity": 3}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+	assert.Equal(t, uint(testutils.DefaultStock-3), stockOf(burger.ID))
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/status", map[string]string{"status": "cancelled"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(burger.ID))
+
+	var cancellation models.StockMovement
+	db.Where("order_id = ? AND reason = ?", orderID, models.StockReasonCancellation).First(&cancellation)
+	assert.Equal(t, 3, cancellation.Delta)
+
+	// Cancelling again through the status endpoint is rejected and releases nothing
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/status", map[string]string{"status": "cancelled"}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(burger.ID))
+}
+
+func TestUpdateOrderStatus_RoleNotAllowed(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "preparation")
+	user := testutils.SeedUser(db, "prep", "prep@test.com", "P@ssw0rd", role.ID)
+	order := seedOrder(user.ID, "prepared", nil)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "preparation"))
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+	r.PATCH("/orders/:id/cancel", CancelOrder)
+
+	// Handing the order over is the counter's job
+	body := map[string]string{"status": "delivered"}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", order.ID)+"/status", body))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// So is cancelling
+	pending := seedOrder(user.ID, "pending", nil)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", pending.ID)+"/cancel", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Equal(t, "prepared", orderStatusOf(order.ID))
+	assert.Equal(t, "pending", orderStatusOf(pending.ID))
+}
+
+func TestUpdateOrderStatus_CustomRole(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := models.Roles{RoleName: "runner", Permissions: models.PermissionList{models.OrderStatusPermission("delivered")}}
+	db.Create(&role)
+	user := testutils.SeedUser(db, "runner", "runner@test.com", "P@ssw0rd", role.ID)
+	prepared := seedOrder(user.ID, "prepared", nil)
+	pending := seedOrder(user.ID, "pending", nil)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), role.RoleName))
+	r.PATCH("/orders/:id/status", UpdateOrderStatus)
+
+	body := map[string]string{"status": "delivered"}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", prepared.ID)+"/status", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body = map[string]string{"status": "preparing"}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", pending.ID)+"/status", body))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "orders:status:preparing", testutils.ParseResponse(w)["permission"])
+}
+
+func TestGetOrderWorkflow(t *testing.T) {
+	r := testutils.SetupRouter()
+	r.GET("/orders/workflow", GetOrderWorkflow)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/orders/workflow", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "pending", resp["initial"])
+	assert.Len(t, resp["states"], 5)
+	transition := resp["transitions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "pending", transition["from"])
+	assert.Equal(t, "orders:status:preparing", transition["permission"])
+}
+
+func TestUpdateOrder_ReplacesItemsAndReprices(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "accueil", "accueil@test.com", "P@ssw0rd", role.ID)
+	customer := testutils.SeedCustomer(db, "John", "0600000000", "john@test.com")
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "accueil"))
+	r.POST("/orders", CreateOrder)
+	r.PUT("/orders/:id", UpdateOrder)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": burger.ID, "quantity": 3}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+	assert.Equal(t, uint(testutils.DefaultStock-3), stockOf(burger.ID))
+
+	// The burger price changed since: the edited order uses the current price
+	db.Model(&models.Products{}).Where("id = ?", burger.ID).Update("price", models.FromEuros(6.00))
+
+	body = map[string]interface{}{
+		"order_type":  "phone",
+		"customer_id": customer.ID,
+		"notes":       "no onions",
+		"order_items": []map[string]interface{}{
+			{"product_id": burger.ID, "quantity": 1},
+			{"product_id": fries.ID, "quantity": 2},
+		},
+	}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/orders", orderID), body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, "phone", resp["order_type"])
+	assert.Equal(t, "no onions", resp["notes"])
+	assert.Equal(t, float64(customer.ID), resp["customer_id"])
+	assert.InDelta(t, 10.00, resp["total_price"], 0.001)
+	assert.Len(t, resp["order_items"], 2)
+
+	// Stock reflects the new items only
+	assert.Equal(t, uint(testutils.DefaultStock-1), stockOf(burger.ID))
+	assert.Equal(t, uint(testutils.DefaultStock-2), stockOf(fries.ID))
+	var itemCount int64
+	db.Model(&models.OrderItem{}).Where("order_id = ?", orderID).Count(&itemCount)
+	assert.Equal(t, int64(2), itemCount)
+}
+
+func TestUpdateOrder_Rejected(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	off := testutils.SeedProduct(db, "McRib", 6.00, cat.ID, false)
+	pending := seedOrderWithItems(user.ID, "pending", burger.ID)
+	preparing := seedOrderWithItems(user.ID, "preparing", burger.ID)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.PUT("/orders/:id", UpdateOrder)
+
+	items := func(productID uint) map[string]interface{} {
+		return map[string]interface{}{
+			"order_type":  "counter",
+			"order_items": []map[string]interface{}{{"product_id": productID, "quantity": 1}},
+		}
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/orders", preparing.ID), items(burger.ID)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Only pending orders can be edited", testutils.ParseResponse(w)["error"])
+
+	// A failed edit leaves the order untouched
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/orders", pending.ID), items(off.ID)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var item models.OrderItem
+	config.DB.Where("order_id = ?", pending.ID).First(&item)
+	assert.Equal(t, burger.ID, *item.ProductID)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", "/orders/999", items(burger.ID)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateOrder_OptionRules(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	size := seedOptionDirect(p.ID, "Size", "single") // required
+	small := seedOptionValue(size.ID, "Small", 0)
+	large := seedOptionValue(size.ID, "Large", 1.50)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	order := func(valueIDs ...uint) map[string]interface{} {
+		options := []map[string]interface{}{}
+		for _, id := range valueIDs {
+			options = append(options, map[string]interface{}{"option_value_id": id})
+		}
+		return map[string]interface{}{
+			"order_type":  "counter",
+			"order_items": []map[string]interface{}{{"product_id": p.ID, "quantity": 1, "options": options}},
+		}
+	}
+
+	cases := []struct {
+		values []uint
+		error  string
+	}{
+		{nil, "product 'Big Mac': option 'Size' is required"},
+		{[]uint{small.ID, large.ID}, "product 'Big Mac': only one value can be selected for option 'Size'"},
+		{[]uint{large.ID, large.ID}, "product 'Big Mac': option 'Size' value 'Large' is selected more than once"},
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", order(tc.values...)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, tc.error, testutils.ParseResponse(w)["error"])
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", order(large.ID)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.InDelta(t, 6.50, testutils.ParseResponse(w)["total_price"], 0.001)
+
+	// Nothing was reserved by the rejected orders
+	assert.Equal(t, uint(testutils.DefaultStock-1), stockOf(p.ID))
+}
+
+func TestCreateOrder_MenuComponentOptions(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Menus")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, cat.ID, true)
+	coke := testutils.SeedProduct(db, "Coke", 2.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	size := seedOptionDirect(coke.ID, "Size", "single") // required
+	large := seedOptionValue(size.ID, "Large", 0.50)
+	salt := seedOptionDirect(fries.ID, "Salt", "single") // required, even though fries are an optional component
+	noSalt := seedOptionValue(salt.ID, "No salt", 0)
+
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	burgerMP := testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	cokeMP := testutils.SeedMenuProduct(db, menu.ID, coke.ID, 1, false)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	other := testutils.SeedMenu(db, "Kids Menu", 5.00, true)
+	otherMP := testutils.SeedMenuProduct(db, other.ID, coke.ID, 1, false)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	order := func(item map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{item}}
+	}
+	menuItem := func(options ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"menu_id": menu.ID, "quantity": 2, "options": options}
+	}
+	option := func(valueID uint, menuProductID *uint) map[string]interface{} {
+		return map[string]interface{}{"option_value_id": valueID, "menu_product_id": menuProductID}
+	}
+
+	cases := []struct {
+		item  map[string]interface{}
+		error string
+	}{
+		{menuItem(), "menu 'Big Mac Menu', product 'Coke': option 'Size' is required"},
+		{menuItem(option(large.ID, nil)), "menu 'Big Mac Menu': each option must name the menu component it applies to (menu_product_id)"},
+		{menuItem(option(large.ID, &otherMP.ID)), "menu 'Big Mac Menu': menu component not found"},
+		{menuItem(option(large.ID, &burgerMP.ID)), "menu 'Big Mac Menu': option value does not belong to 'Burger'"},
+		{menuItem(option(large.ID, &cokeMP.ID)), "menu 'Big Mac Menu', product 'Fries': option 'Salt' is required"},
+		{map[string]interface{}{"product_id": coke.ID, "quantity": 1, "options": []map[string]interface{}{option(large.ID, &cokeMP.ID)}},
+			"product 'Coke': menu_product_id is only allowed on menu items"},
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", order(tc.item)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, tc.error, testutils.ParseResponse(w)["error"])
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders",
+		order(menuItem(option(large.ID, &cokeMP.ID), option(noSalt.ID, &friesMP.ID)))))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.InDelta(t, 19.00, resp["total_price"], 0.001)
+
+	options := resp["order_items"].([]interface{})[0].(map[string]interface{})["order_item_options"].([]interface{})
+	assert.Len(t, options, 2)
+	assert.Equal(t, float64(cokeMP.ID), options[0].(map[string]interface{})["menu_product_id"])
+}
+
+func TestCreateOrder_MenuSubstitutions(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Menus")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 3.00, cat.ID, true)
+	nuggets := testutils.SeedProduct(db, "Nuggets", 4.00, cat.ID, true)
+	dressing := seedOptionDirect(salad.ID, "Dressing", "single") // required on the substitute
+	ranch := seedOptionValue(dressing.ID, "Ranch", 0.20)
+
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+	burgerMP := testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: salad.ID, PriceDelta: 50})
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+
+	order := func(item map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{item}}
+	}
+	substitution := func(menuProductID, productID uint) map[string]interface{} {
+		return map[string]interface{}{"menu_product_id": menuProductID, "product_id": productID}
+	}
+	menuItem := func(options []map[string]interface{}, substitutions ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"menu_id": menu.ID, "quantity": 2, "options": options, "substitutions": substitutions}
+	}
+
+	cases := []struct {
+		item  map[string]interface{}
+		error string
+	}{
+		{menuItem(nil, substitution(burgerMP.ID, salad.ID)), "menu 'Big Mac Menu': product 'Burger' cannot be replaced"},
+		{menuItem(nil, substitution(friesMP.ID, nuggets.ID)), "menu 'Big Mac Menu': 'Nuggets' is not an allowed replacement for 'Fries'"},
+		{menuItem(nil, substitution(friesMP.ID, salad.ID), substitution(friesMP.ID, salad.ID)), "menu 'Big Mac Menu': product 'Fries' is replaced more than once"},
+		{menuItem(nil, substitution(friesMP.ID, salad.ID)), "menu 'Big Mac Menu', product 'Salad': option 'Dressing' is required"},
+		{map[string]interface{}{"product_id": burger.ID, "quantity": 1, "substitutions": []map[string]interface{}{substitution(friesMP.ID, salad.ID)}},
+			"product 'Burger': substitutions are only allowed on menu items"},
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", order(tc.item)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, tc.error, testutils.ParseResponse(w)["error"])
+	}
+
+	options := []map[string]interface{}{{"option_value_id": ranch.ID, "menu_product_id": friesMP.ID}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", order(menuItem(options, substitution(friesMP.ID, salad.ID)))))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.InDelta(t, 19.40, resp["total_price"], 0.001) // (9.00 + 0.20 + 0.50) x 2
+
+	item := resp["order_items"].([]interface{})[0].(map[string]interface{})
+	substitutions := item["substitutions"].([]interface{})
+	assert.Len(t, substitutions, 1)
+	assert.Equal(t, float64(salad.ID), substitutions[0].(map[string]interface{})["product_id"])
+	assert.InDelta(t, 0.50, substitutions[0].(map[string]interface{})["price_delta"], 0.001)
+
+	// The substitute is reserved instead of the replaced component
+	assert.Equal(t, uint(testutils.DefaultStock-2), stockOf(salad.ID))
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(fries.ID))
+	assert.Equal(t, uint(testutils.DefaultStock-2), stockOf(burger.ID))
+}
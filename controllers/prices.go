@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// duePrices holds the scheduled prices in effect at a given time that the price job has not copied to
+// the catalog yet (see applyDuePrices), by item. Order pricing and catalog reads overlay them on the stored
+// prices, so a price change is charged and shown from its effective_at on without anything being written.
+type duePrices struct {
+	products     map[uint]models.Money
+	menus        map[uint]models.Money
+	optionValues map[uint]models.Money
+}
+
+// loadDuePrices reads the scheduled prices in effect at a given time that are not applied yet.
+func loadDuePrices(tx *gorm.DB, at time.Time) (duePrices, error) {
+	prices := duePrices{
+		products:     make(map[uint]models.Money),
+		menus:        make(map[uint]models.Money),
+		optionValues: make(map[uint]models.Money),
+	}
+
+	current, err := duePriceChanges(tx, at)
+	if err != nil {
+		return prices, err
+	}
+	for _, change := range current {
+		switch {
+		case change.ProductID != nil:
+			prices.products[*change.ProductID] = change.Price
+		case change.MenuID != nil:
+			prices.menus[*change.MenuID] = change.Price
+		case change.OptionValueID != nil:
+			prices.optionValues[*change.OptionValueID] = change.Price
+		}
+	}
+	return prices, nil
+}
+
+// product sets the price of a product to its due price, if it has one.
+func (p duePrices) product(product *models.Products) {
+	if price, ok := p.products[product.ID]; ok {
+		product.Price = price
+	}
+}
+
+// menu sets the price of a menu, and of the component products it is preloaded with, to their due prices.
+func (p duePrices) menu(menu *models.Menu) {
+	if price, ok := p.menus[menu.ID]; ok {
+		menu.Price = price
+	}
+	for i := range menu.MenuProducts {
+		p.product(&menu.MenuProducts[i].Product)
+	}
+}
+
+// optionValue sets the price of an option value to its due price, if it has one.
+func (p duePrices) optionValue(value *models.OptionValues) {
+	if price, ok := p.optionValues[value.ID]; ok {
+		value.OptionPrice = price
+	}
+}
+
+// fillProductPrices sets catalog products to their prices in effect now, due scheduled prices included.
+func fillProductPrices(products []models.Products) error {
+	prices, err := loadDuePrices(config.DB, now())
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		prices.product(&products[i])
+	}
+	return nil
+}
+
+// fillMenuPrices sets catalog menus to their prices in effect now, due scheduled prices included.
+func fillMenuPrices(menus []models.Menu) error {
+	prices, err := loadDuePrices(config.DB, now())
+	if err != nil {
+		return err
+	}
+	for i := range menus {
+		prices.menu(&menus[i])
+	}
+	return nil
+}
+
+// fillOptionValuePrices sets option values to their prices in effect now, due scheduled prices included.
+func fillOptionValuePrices(values []models.OptionValues) error {
+	prices, err := loadDuePrices(config.DB, now())
+	if err != nil {
+		return err
+	}
+	for i := range values {
+		prices.optionValue(&values[i])
+	}
+	return nil
+}
+
+// duePriceChanges returns, in one query, the scheduled price changes that have come due by a given time,
+// are not applied yet and are still the price in effect for their item: the last change that took effect at
+// or before that time. A scheduled change is superseded by any price set after its effective_at, e.g. an
+// edit in the catalog made before the price job came round. Exactly one item column is set per change, so
+// the other two never match in the comparison below.
+func duePriceChanges(tx *gorm.DB, at time.Time) ([]models.PriceChange, error) {
+	var current []models.PriceChange
+	err := tx.Where("applied_at IS NULL AND effective_at <= ?", at).
+		Where(`NOT EXISTS (SELECT 1 FROM price_changes later WHERE later.effective_at <= ?
+			AND (later.product_id = price_changes.product_id OR later.menu_id = price_changes.menu_id
+				OR later.option_value_id = price_changes.option_value_id)
+			AND (later.effective_at > price_changes.effective_at
+				OR (later.effective_at = price_changes.effective_at AND later.id > price_changes.id)))`, at).
+		Order("effective_at, id").Find(&current).Error
+	return current, err
+}
+
+// applyDuePrices copies the scheduled price changes that have come due by a given time to the catalog prices
+// and marks them applied. A change superseded by a later price (see duePriceChanges) is marked applied without
+// touching the catalog. Only the price job runs it (see ApplyDuePrices); everything else reads due prices
+// through duePrices.
+func applyDuePrices(tx *gorm.DB, at time.Time) error {
+	current, err := duePriceChanges(tx, at)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range current {
+		switch {
+		case change.ProductID != nil:
+			err = tx.Model(&models.Products{}).Where("id = ?", *change.ProductID).Update("price", change.Price).Error
+		case change.MenuID != nil:
+			err = tx.Model(&models.Menu{}).Where("id = ?", *change.MenuID).Update("price", change.Price).Error
+		case change.OptionValueID != nil:
+			err = tx.Model(&models.OptionValues{}).Where("id = ?", *change.OptionValueID).Update("option_price", change.Price).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Superseded changes are marked applied too, without touching the catalog
+	return tx.Model(&models.PriceChange{}).Where("applied_at IS NULL AND effective_at <= ?", at).Update("applied_at", at).Error
+}
+
+// ApplyDuePrices is the price job: it copies the scheduled prices that came due to the catalog (see
+// applyDuePrices) now and then every interval, until the process exits. Orders and catalog reads use due
+// prices before that, so the interval only bounds how long the stored catalog lags behind.
+// Run it in its own goroutine once the database is ready.
+func ApplyDuePrices(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			return applyDuePrices(tx, now())
+		}); err != nil {
+			log.Println("Failed to apply scheduled prices:", err)
+		}
+		<-ticker.C
+	}
+}
+
+// recordPrice writes a price that takes effect at once (a new item or a price edited in the catalog)
+// to the price history. change names the item and the price; the rest is filled in.
+func recordPrice(tx *gorm.DB, change models.PriceChange, userID *uint) error {
+	at := now()
+	change.EffectiveAt = at
+	change.AppliedAt = &at
+	change.CreatedByID = userID
+	return tx.Create(&change).Error
+}
+
+// priceTarget reads which item a price request is about from ?product_id=, ?menu_id= or ?option_value_id=,
+// exactly one of which must be given. It returns the history column to filter on and the item ID.
+func priceTarget(c *gin.Context) (string, uint, error) {
+	column, id := "", uint64(0)
+	for _, param := range []string{"product_id", "menu_id", "option_value_id"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if column != "" {
+			return "", 0, errors.New("Give only one of product_id, menu_id or option_value_id")
+		}
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", 0, errors.New("Invalid '" + param + "', expected an ID")
+		}
+		column, id = param, parsed
+	}
+	if column == "" {
+		return "", 0, errors.New("Give one of product_id, menu_id or option_value_id")
+	}
+	return column, uint(id), nil
+}
+
+// validatePriceChange checks a scheduled price change sent by an admin. Errors are meant to be shown to the user.
+func validatePriceChange(change *models.PriceChange) error {
+	targets := 0
+	if change.ProductID != nil {
+		targets++
+		if err := config.DB.First(&models.Products{}, *change.ProductID).Error; err != nil {
+			return errors.New("Product not found")
+		}
+	}
+	if change.MenuID != nil {
+		targets++
+		if err := config.DB.First(&models.Menu{}, *change.MenuID).Error; err != nil {
+			return errors.New("Menu not found")
+		}
+	}
+	if change.OptionValueID != nil {
+		targets++
+		if err := config.DB.First(&models.OptionValues{}, *change.OptionValueID).Error; err != nil {
+			return errors.New("Option value not found")
+		}
+	}
+	if targets != 1 {
+		return errors.New("A price change applies to exactly one of product_id, menu_id or option_value_id")
+	}
+
+	if change.Price < 0 {
+		return errors.New("Price cannot be negative")
+	}
+	if !change.EffectiveAt.After(now()) {
+		return errors.New("effective_at must be in the future; edit the item to change its price now")
+	}
+	return nil
+}
+
+// SchedulePriceChange plans a new price for a product, a menu or an option value. The catalog price
+// and order pricing switch to it at effective_at; until then it can be cancelled.
+//
+// @Summary Schedule a price change
+// @Description Set the future price of a product, menu or option value from a given moment (RFC 3339)
+// @Tags Prices
+// @Accept json
+// @Produce json
+// @Param change body models.PriceChange true "Item, price and effective_at"
+// @Success 201 {object} models.PriceChange
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /prices [post]
+func SchedulePriceChange(c *gin.Context) {
+	var change models.PriceChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validatePriceChange(&change); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change.ID = 0
+	change.AppliedAt = nil
+	change.CreatedByID = currentUserID(c)
+	if err := config.DB.Create(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule price change"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, change)
+}
+
+// GetPriceHistory returns the price history of a product, a menu or an option value, oldest first,
+// scheduled changes included (applied_at null). Exactly one of ?product_id=, ?menu_id=, ?option_value_id=.
+//
+// @Summary Get the price history of an item
+// @Description Retrieve the past, current and scheduled prices of a product, menu or option value
+// @Tags Prices
+// @Produce json
+// @Param product_id query int false "Product ID"
+// @Param menu_id query int false "Menu ID"
+// @Param option_value_id query int false "Option value ID"
+// @Success 200 {array} models.PriceChange
+// @Failure 400 {object} map[string]string "Missing or invalid item"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /prices [get]
+func GetPriceHistory(c *gin.Context) {
+	column, id, err := priceTarget(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	history := []models.PriceChange{}
+	if err := config.DB.Where(column+" = ?", id).Order("effective_at, id").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve price history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetPriceAt returns the price of a product, a menu or an option value in effect at a given moment
+// (?at=, RFC 3339, default now): the last change that took effect at or before it, scheduled changes included.
+// Exactly one of ?product_id=, ?menu_id=, ?option_value_id=.
+//
+// @Summary Get the price in effect at a moment
+// @Description Retrieve the price change of a product, menu or option value in effect at a timestamp
+// @Tags Prices
+// @Produce json
+// @Param product_id query int false "Product ID"
+// @Param menu_id query int false "Menu ID"
+// @Param option_value_id query int false "Option value ID"
+// @Param at query string false "Moment (RFC 3339, default now)"
+// @Success 200 {object} models.PriceChange
+// @Failure 400 {object} map[string]string "Missing or invalid item or moment"
+// @Failure 404 {object} map[string]string "No price recorded at that moment"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /prices/at [get]
+func GetPriceAt(c *gin.Context) {
+	column, id, err := priceTarget(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	at := now()
+	if raw := c.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'at', use RFC 3339 (e.g. 2026-03-01T00:00:00+01:00)"})
+			return
+		}
+		at = parsed
+	}
+
+	var change models.PriceChange
+	if err := config.DB.Where(column+" = ? AND effective_at <= ?", id, at).Order("effective_at DESC, id DESC").First(&change).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No price recorded at that moment"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// CancelPriceChange removes a scheduled price change that has not taken effect yet.
+// Prices that already applied are history and cannot be removed.
+//
+// @Summary Cancel a scheduled price change
+// @Description Delete a price change that is not due yet
+// @Tags Prices
+// @Produce json
+// @Param id path int true "Price change ID"
+// @Success 200 {object} map[string]string "Price change cancelled"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Price change not found"
+// @Failure 409 {object} map[string]string "The price already took effect"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /prices/{id} [delete]
+func CancelPriceChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var change models.PriceChange
+	if err := config.DB.First(&change, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price change not found"})
+		return
+	}
+
+	if change.AppliedAt != nil || !change.EffectiveAt.After(now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The price already took effect and is part of the history"})
+		return
+	}
+
+	if err := config.DB.Delete(&change).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel price change"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Price change cancelled"})
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func priceRouter(userID uint) *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), "admin"))
+	r.GET("/prices", GetPriceHistory)
+	r.GET("/prices/at", GetPriceAt)
+	r.POST("/prices", SchedulePriceChange)
+	r.DELETE("/prices/:id", CancelPriceChange)
+	r.POST("/products", CreateProduct)
+	r.GET("/products/:id", GetProduct)
+	r.PUT("/products/:id", UpdateProduct)
+	r.POST("/orders", CreateOrder)
+	return r
+}
+
+// Friday 27 February 2026, 12:00
+var beforeIncrease = time.Date(2026, 2, 27, 12, 0, 0, 0, time.Local)
+
+func TestSchedulePriceChange_Validation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	product := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+
+	freezeTime(t, beforeIncrease)
+	r := priceRouter(1)
+	future := beforeIncrease.AddDate(0, 0, 2).Format(time.RFC3339)
+
+	cases := []struct {
+		body map[string]interface{}
+		code int
+	}{
+		{map[string]interface{}{"product_id": product.ID, "price": 5.50, "effective_at": future}, http.StatusCreated},
+		{map[string]interface{}{"menu_id": menu.ID, "price": 9.50, "effective_at": future}, http.StatusCreated},
+		{map[string]interface{}{"price": 5.50, "effective_at": future}, http.StatusBadRequest},                                               // no item
+		{map[string]interface{}{"product_id": product.ID, "menu_id": menu.ID, "price": 5.50, "effective_at": future}, http.StatusBadRequest}, // two items
+		{map[string]interface{}{"option_value_id": 9999, "price": 0.50, "effective_at": future}, http.StatusBadRequest},                      // unknown option value
+		{map[string]interface{}{"product_id": product.ID, "price": -1, "effective_at": future}, http.StatusBadRequest},
+		{map[string]interface{}{"product_id": product.ID, "price": 5.50, "effective_at": beforeIncrease.Add(-time.Hour).Format(time.RFC3339)}, http.StatusBadRequest}, // in the past
+		{map[string]interface{}{"product_id": product.ID, "price": 5.50}, http.StatusBadRequest},                                                                      // no date
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/prices", tc.body))
+		assert.Equal(t, tc.code, w.Code, "%v: %s", tc.body, w.Body.String())
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/prices", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "an item is required")
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", fmt.Sprintf("/prices?product_id=%d&menu_id=%d", product.ID, menu.ID), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestScheduledPrice_AppliesToOrders(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+
+	freezeTime(t, beforeIncrease.AddDate(0, -1, 0))
+	r := priceRouter(user.ID)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/products", map[string]interface{}{"name": "Big Mac", "price": 5.00, "category_id": cat.ID, "stock_quantity": 10, "is_available": true}))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	productID := uint(testutils.ParseResponse(w)["id"].(float64))
+
+	// The increase is planned for the 1st of March
+	freezeTime(t, beforeIncrease)
+	firstOfMarch := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/prices", map[string]interface{}{"product_id": productID, "price": 5.50, "effective_at": firstOfMarch.Format(time.RFC3339)}))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	body := map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{{"product_id": productID, "quantity": 2}}}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, 10.00, testutils.ParseResponse(w)["total_price"])
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", productID), nil))
+	assert.Equal(t, 5.00, testutils.ParseResponse(w)["price"])
+
+	// From then on orders and the catalog use the new price, without anyone editing the product
+	freezeTime(t, firstOfMarch.Add(9*time.Hour))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, 11.00, testutils.ParseResponse(w)["total_price"])
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", productID), nil))
+	assert.Equal(t, 5.50, testutils.ParseResponse(w)["price"])
+	var pending int64
+	config.DB.Model(&models.PriceChange{}).Where("applied_at IS NULL").Count(&pending)
+	assert.Equal(t, int64(1), pending, "orders and reads leave writing it to the price job")
+
+	// An edit records its price too
+	freezeTime(t, firstOfMarch.AddDate(0, 1, 0))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/products", productID), map[string]interface{}{"name": "Big Mac", "price": 5.20}))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The price job marks the increase applied without overwriting the edit made since
+	assert.NoError(t, applyDuePrices(config.DB, now()))
+	var product models.Products
+	config.DB.First(&product, productID)
+	assert.Equal(t, models.Money(520), product.Price)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", fmt.Sprintf("/prices?product_id=%d", productID), nil))
+	var history []models.PriceChange
+	json.Unmarshal(w.Body.Bytes(), &history)
+	var prices []models.Money
+	for _, change := range history {
+		prices = append(prices, change.Price)
+		assert.NotNil(t, change.AppliedAt)
+	}
+	assert.Equal(t, []models.Money{500, 550, 520}, prices)
+
+	// The price in effect at any moment
+	priceAt := func(at time.Time) (int, interface{}) {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("GET", fmt.Sprintf("/prices/at?product_id=%d&at=%s", productID, url.QueryEscape(at.Format(time.RFC3339))), nil))
+		return w.Code, testutils.ParseResponse(w)["price"]
+	}
+	code, price := priceAt(firstOfMarch.Add(-time.Second))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 5.00, price)
+	_, price = priceAt(firstOfMarch)
+	assert.Equal(t, 5.50, price)
+	_, price = priceAt(firstOfMarch.AddDate(1, 0, 0))
+	assert.Equal(t, 5.20, price)
+	code, _ = priceAt(beforeIncrease.AddDate(-1, 0, 0))
+	assert.Equal(t, http.StatusNotFound, code, "before the product existed")
+}
+
+func TestScheduledPrice_LatestDueWins(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	product := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+	other := testutils.SeedProduct(db, "Cheeseburger", 2.00, cat.ID, true)
+
+	freezeTime(t, beforeIncrease)
+	r := priceRouter(1)
+	for _, change := range []map[string]interface{}{
+		{"product_id": product.ID, "price": 5.50, "effective_at": beforeIncrease.AddDate(0, 0, 1).Format(time.RFC3339)},
+		{"product_id": product.ID, "price": 5.80, "effective_at": beforeIncrease.AddDate(0, 0, 2).Format(time.RFC3339)},
+		{"product_id": other.ID, "price": 2.20, "effective_at": beforeIncrease.AddDate(0, 0, 1).Format(time.RFC3339)},
+	} {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/prices", change))
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Both changes of the product have come due before the price job ran: the later one is in effect
+	freezeTime(t, beforeIncrease.AddDate(0, 0, 3))
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", product.ID), nil))
+	assert.Equal(t, 5.80, testutils.ParseResponse(w)["price"])
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", other.ID), nil))
+	assert.Equal(t, 2.20, testutils.ParseResponse(w)["price"])
+
+	assert.NoError(t, applyDuePrices(config.DB, now()))
+	var applied models.Products
+	config.DB.First(&applied, product.ID)
+	assert.Equal(t, models.Money(580), applied.Price)
+	var pending int64
+	config.DB.Model(&models.PriceChange{}).Where("applied_at IS NULL").Count(&pending)
+	assert.Equal(t, int64(0), pending)
+}
+
+func TestScheduledPrice_MenuAndOptionValue(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Burger Menu", 9.00, true)
+	burgerMP := testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	option := seedOptionDirect(burger.ID, "Extras", string(models.OptionMultiple))
+	bacon := seedOptionValue(option.ID, "Bacon", 1.00)
+
+	freezeTime(t, beforeIncrease)
+	tomorrow := beforeIncrease.AddDate(0, 0, 1)
+	db.Create(&models.PriceChange{MenuID: &menu.ID, Price: 950, EffectiveAt: tomorrow})
+	db.Create(&models.PriceChange{OptionValueID: &bacon.ID, Price: 120, EffectiveAt: tomorrow})
+
+	r := priceRouter(user.ID)
+	body := map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{
+		{"menu_id": menu.ID, "quantity": 1, "options": []map[string]interface{}{{"option_value_id": bacon.ID, "menu_product_id": burgerMP.ID}}},
+	}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, 10.00, testutils.ParseResponse(w)["total_price"], w.Body.String())
+
+	// The menu and the option value both change price overnight
+	freezeTime(t, tomorrow)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, 10.70, testutils.ParseResponse(w)["total_price"], w.Body.String())
+}
+
+func TestCancelPriceChange(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	product := testutils.SeedProduct(db, "Big Mac", 5.00, cat.ID, true)
+
+	freezeTime(t, beforeIncrease)
+	planned := models.PriceChange{ProductID: &product.ID, Price: 550, EffectiveAt: beforeIncrease.AddDate(0, 0, 2)}
+	db.Create(&planned)
+	applied := models.PriceChange{ProductID: &product.ID, Price: 500, EffectiveAt: beforeIncrease.AddDate(0, -1, 0)}
+	db.Create(&applied)
+
+	r := priceRouter(1)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("DELETE", testutils.IDParam("/prices", applied.ID), nil))
+	assert.Equal(t, http.StatusConflict, w.Code, "history cannot be removed")
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", testutils.IDParam("/prices", planned.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The cancelled price never applies
+	freezeTime(t, beforeIncrease.AddDate(0, 0, 3))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", product.ID), nil))
+	assert.Equal(t, 5.00, testutils.ParseResponse(w)["price"])
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // CreateCategory adds a new product category.
-// Category names must be unique.
+// Category names must be unique. VAT rates are in basis points; left out, they default to 10% on site and 5.5% takeaway.
 //
 // @Summary Create a new category
 // @Description Create a new product category with the provided details
@@ -32,6 +32,11 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
+	if !taxRatesValid(&category.TaxRateOnSite, &category.TaxRateTakeaway) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tax rates are in basis points and cannot exceed 10000 (100%)"})
+		return
+	}
+
 	// Check if the category already exist
 	var existingCategory models.Category
 	if err := config.DB.Where("name = ?", category.Name).First(&existingCategory).Error; err == nil {
@@ -196,6 +201,11 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	if !taxRatesValid(&input.TaxRateOnSite, &input.TaxRateTakeaway) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tax rates are in basis points and cannot exceed 10000 (100%)"})
+		return
+	}
+
 	// Check if the new name conflicts with another category
 	var existingCategory models.Category
 	if err := config.DB.Where("name = ? AND id != ?", input.Name, id).First(&existingCategory).Error; err == nil {
@@ -211,3 +221,13 @@ func UpdateCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, category)
 }
+
+// taxRatesValid reports whether every given VAT rate (in basis points, nil when not set) is at most 100%.
+func taxRatesValid(rates ...*uint) bool {
+	for _, rate := range rates {
+		if rate != nil && *rate > models.MaxTaxRate {
+			return false
+		}
+	}
+	return true
+}
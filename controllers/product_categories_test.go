@@ -23,6 +23,29 @@ func TestCreateCategory_Success(t *testing.T) {
 	assert.Equal(t, "Burgers", resp["name"])
 }
 
+func TestCreateCategory_TaxRates(t *testing.T) {
+	testutils.SetupTestDB()
+
+	r := testutils.SetupRouter()
+	r.POST("/categories", CreateCategory)
+
+	// Restaurant food rates unless told otherwise
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/categories", map[string]interface{}{"name": "Burgers"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, float64(1000), resp["tax_rate_on_site"])
+	assert.Equal(t, float64(550), resp["tax_rate_takeaway"])
+
+	body := map[string]interface{}{"name": "Beers", "tax_rate_on_site": 2000, "tax_rate_takeaway": 2000}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/categories", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(2000), testutils.ParseResponse(w)["tax_rate_takeaway"])
+
+	body = map[string]interface{}{"name": "Broken", "tax_rate_on_site": 10001}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/categories", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestCreateCategory_Duplicate(t *testing.T) {
 	db := testutils.SetupTestDB()
 	testutils.SeedCategory(db, "Burgers")
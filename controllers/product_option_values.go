@@ -58,7 +58,18 @@ func CreateOptionValue(c *gin.Context) {
 		}
 	}
 
-	if err := config.DB.Create(&optionValues).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&optionValues).Error; err != nil {
+			return err
+		}
+		for _, value := range optionValues {
+			if err := recordPrice(tx, models.PriceChange{OptionValueID: &value.ID, Price: value.OptionPrice}, currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Option values could not be created"})
 		return
 	}
@@ -132,12 +143,18 @@ func GetOptionValue(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	values := []models.OptionValues{optionValue}
+	if err := fillOptionValuePrices(values); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
 
-	c.JSON(http.StatusOK, optionValue)
+	c.JSON(http.StatusOK, values[0])
 }
 
 // UpdateOptionValue modifies an existing option value.
 // Validates that the new value doesn't conflict with another value for the same option.
+// A new price takes effect at once and is recorded in the price history (see SchedulePriceChange to plan one).
 //
 // @Summary Update an option value
 // @Description Update an existing option value by ID
@@ -185,7 +202,27 @@ func UpdateOptionValue(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Model(&optionValue).Updates(input).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&optionValue, id).Error; err != nil {
+			return err
+		}
+		// Compared with the price in effect (see duePrices)
+		prices, err := loadDuePrices(tx, now())
+		if err != nil {
+			return err
+		}
+		prices.optionValue(&optionValue)
+		previousPrice := optionValue.OptionPrice
+
+		if err := tx.Model(&optionValue).Updates(input).Error; err != nil {
+			return err
+		}
+		if input.OptionPrice != 0 && input.OptionPrice != previousPrice {
+			return recordPrice(tx, models.PriceChange{OptionValueID: &optionValue.ID, Price: input.OptionPrice}, currentUserID(c))
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update option value"})
 		return
 	}
@@ -227,6 +264,10 @@ func GetValuesByOption(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve option values"})
 		return
 	}
+	if err := fillOptionValuePrices(values); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve option values"})
+		return
+	}
 
 	c.JSON(http.StatusOK, values)
 }
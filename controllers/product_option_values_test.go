@@ -11,7 +11,7 @@ import (
 )
 
 func seedOptionValue(optionID uint, value string, price float64) models.OptionValues {
-	ov := models.OptionValues{OptionID: optionID, Value: value, OptionPrice: price}
+	ov := models.OptionValues{OptionID: optionID, Value: value, OptionPrice: models.FromEuros(price)}
 	config.DB.Create(&ov)
 	return ov
 }
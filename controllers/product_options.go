@@ -41,7 +41,7 @@ func CreateOption(c *gin.Context) {
 	}
 
 	// Validate is_unique value
-	if option.IsUnique != "single" && option.IsUnique != "multiple" {
+	if !option.IsUnique.Valid() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "is_unique must be 'single' or 'multiple'"})
 		return
 	}
@@ -197,7 +197,7 @@ func UpdateOption(c *gin.Context) {
 	}
 
 	// Validate is_unique value if provided
-	if input.IsUnique != "" && input.IsUnique != "single" && input.IsUnique != "multiple" {
+	if input.IsUnique != "" && !input.IsUnique.Valid() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "is_unique must be 'single' or 'multiple'"})
 		return
 	}
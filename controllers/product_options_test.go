@@ -11,7 +11,7 @@ import (
 )
 
 func seedOptionDirect(productID uint, name, isUnique string) models.ProductOptions {
-	opt := models.ProductOptions{ProductID: productID, Name: name, IsUnique: isUnique, IsRequired: true}
+	opt := models.ProductOptions{ProductID: productID, Name: name, IsUnique: models.OptionSelection(isUnique), IsRequired: true}
 	config.DB.Create(&opt)
 	return opt
 }
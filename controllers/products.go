@@ -11,8 +11,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// StockUpdateInput is the request body for UpdateProductStock.
+type StockUpdateInput struct {
+	StockQuantity *uint  `json:"stock_quantity"` // New absolute stock level
+	Delta         *int   `json:"delta"`          // Signed change, used instead of stock_quantity
+	Reason        string `json:"reason"`         // delivery, waste or correction (default)
+	Note          string `json:"note"`
+}
+
 // CreateProduct adds a new product to the catalog.
 // The referenced category must exist, and the product name must be unique.
+// A non-zero initial stock is recorded as a correction movement in the stock ledger;
+// a product created without stock starts out unavailable (out of stock).
 //
 // @Summary Create a new product
 // @Description Create a new product with the provided details
@@ -32,6 +42,11 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if !taxRatesValid(product.TaxRateOnSite, product.TaxRateTakeaway) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tax rates are in basis points and cannot exceed 10000 (100%)"})
+		return
+	}
+
 	// Check if the category exists
 	var category models.Category
 	if err := config.DB.First(&category, product.CategoryID).Error; err != nil {
@@ -46,7 +61,32 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&product).Error; err != nil {
+	// Create the product and record its initial stock in the ledger
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		initialStock := product.StockQuantity
+		product.StockQuantity = 0
+		product.UnavailableReason = ""
+		if !product.IsAvailable {
+			product.UnavailableReason = models.UnavailableManual
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if err := recordPrice(tx, models.PriceChange{ProductID: &product.ID, Price: product.Price}, currentUserID(c)); err != nil {
+			return err
+		}
+		if initialStock == 0 {
+			return syncStockAvailability(tx, product.ID)
+		}
+		return applyStockMovement(tx, models.StockMovement{
+			ProductID: product.ID,
+			Delta:     int(initialStock),
+			Reason:    models.StockReasonCorrection,
+			Note:      "Initial stock",
+			UserID:    currentUserID(c),
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product could not be created"})
 		return
 	}
@@ -57,7 +97,8 @@ func CreateProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
-// DeleteProduct permanently removes a product from the catalog.
+// DeleteProduct permanently removes a product from the catalog, along with its stock ledger
+// and the menu substitution groups it is offered in.
 //
 // @Summary Delete a product
 // @Description Delete a product by ID
@@ -92,29 +133,70 @@ func DeleteProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
 }
 
-// GetProducts returns all products with their category preloaded.
+// productListSpec lists the sort fields and filters accepted by GetProducts.
+var productListSpec = listSpec{
+	Sort: map[string]string{
+		"id":             "id",
+		"name":           "name",
+		"price":          "price",
+		"stock_quantity": "stock_quantity",
+		"created_at":     "created_at",
+	},
+	DefaultSort: "id ASC",
+	Filters: []listFilter{
+		{Param: "category_id", Column: "category_id", Kind: filterID},
+		{Param: "is_available", Column: "is_available", Kind: filterBool},
+	},
+}
+
+// GetProducts returns a page of products with their category preloaded and their effective availability
+// (the stored flag combined with the availability schedules, as of now).
+// Filters: ?category_id=, ?is_available=true|false. Paging and sorting: ?limit=, ?offset=, ?sort=name,-price.
 //
 // @Summary Get all products
-// @Description Retrieve a list of all products with their categories
+// @Description Retrieve a page of products with their categories
 // @Tags Products
 // @Produce json
-// @Success 200 {array} models.Products
+// @Param category_id query int false "Filter by category"
+// @Param is_available query bool false "Filter by availability"
+// @Param sort query string false "Sort fields: id, name, price, stock_quantity, created_at (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of products to skip"
+// @Success 200 {object} Page{data=[]models.Products}
+// @Failure 400 {object} map[string]string "Invalid filter, sort or paging parameter"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /products [get]
 func GetProducts(c *gin.Context) {
 	var products []models.Products
 
-	// Preload the Category to get the details with the product
-	if err := config.DB.Preload("Category").Find(&products).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Products{}), productListSpec, productPreloads, &products)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
+		return
+	}
+	if err := fillProductPrices(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
+		return
+	}
+	if err := fillProductAvailability(products); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, page)
+}
+
+// productPreloads loads the Category with the product.
+func productPreloads(db *gorm.DB) *gorm.DB {
+	return db.Preload("Category")
 }
 
-// GetProduct returns a single product by ID with its category preloaded.
+// GetProduct returns a single product by ID with its category preloaded and its effective availability.
 //
 // @Summary Get a product by ID
 // @Description Retrieve a single product by its ID
@@ -145,13 +227,29 @@ func GetProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	products := []models.Products{product}
+	if err := fillProductPrices(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if err := fillProductAvailability(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, products[0])
 }
 
+// errProductOutOfStock is returned when a product without stock is switched on.
+var errProductOutOfStock = errors.New("product out of stock")
+
 // UpdateProduct modifies an existing product.
 // Validates that the new name doesn't conflict with another product, and that the
-// new category (if changed) exists.
+// new category (if changed) exists. Stock is not updated here — use UpdateProductStock
+// so that every change goes through the stock ledger. The VAT rate overrides are always
+// replaced, so leaving them out (or null) makes the product use its category's rates.
+// A new price takes effect at once and is recorded in the price history (see SchedulePriceChange to plan one).
+// Like ToggleProductAvailability, an out-of-stock product cannot be made available before it is restocked.
 //
 // @Summary Update a product
 // @Description Update an existing product by ID
@@ -163,7 +261,7 @@ func GetProduct(c *gin.Context) {
 // @Success 200 {object} models.Products
 // @Failure 400 {object} map[string]string "Invalid ID or data"
 // @Failure 404 {object} map[string]string "Product not found"
-// @Failure 409 {object} map[string]string "Product name already exists"
+// @Failure 409 {object} map[string]string "Product name already exists, or out of stock"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /products/{id} [put]
@@ -188,6 +286,11 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if !taxRatesValid(input.TaxRateOnSite, input.TaxRateTakeaway) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tax rates are in basis points and cannot exceed 10000 (100%)"})
+		return
+	}
+
 	// Check if the new name conflicts with another product
 	var existingProduct models.Products
 	if err := config.DB.Where("name = ? AND id != ?", input.Name, id).First(&existingProduct).Error; err == nil {
@@ -204,7 +307,47 @@ func UpdateProduct(c *gin.Context) {
 		}
 	}
 
-	if err := config.DB.Model(&product).Updates(input).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, id).Error; err != nil {
+			return err
+		}
+		// The price in effect, a scheduled price that came due included: setting another one supersedes it
+		prices, err := loadDuePrices(tx, now())
+		if err != nil {
+			return err
+		}
+		prices.product(&product)
+		previousPrice := product.Price
+
+		// Availability follows the stock: switching on a product with none left is refused
+		if input.IsAvailable && product.StockQuantity == 0 {
+			return errProductOutOfStock
+		}
+		if input.IsAvailable && !product.IsAvailable {
+			if err := tx.Model(&product).Update("unavailable_reason", "").Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&product).Omit("stock_quantity", "unavailable_reason").Updates(input).Error; err != nil {
+			return err
+		}
+		if input.Price != 0 && input.Price != previousPrice {
+			if err := recordPrice(tx, models.PriceChange{ProductID: &product.ID, Price: input.Price}, currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		// VAT overrides are always replaced: null makes the product follow its category again
+		if err := tx.Model(&product).Select("tax_rate_on_site", "tax_rate_takeaway").Updates(input).Error; err != nil {
+			return err
+		}
+		return syncStockAvailability(tx, product.ID)
+	})
+	if errors.Is(err, errProductOutOfStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is out of stock, restock it before making it available"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
@@ -215,7 +358,7 @@ func UpdateProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
-// GetProductsByCategory returns all products belonging to a given category.
+// GetProductsByCategory returns all products belonging to a given category, with their effective availability.
 // The category must exist.
 //
 // @Summary Get products by category
@@ -249,12 +392,22 @@ func GetProductsByCategory(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
 		return
 	}
+	if err := fillProductPrices(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
+		return
+	}
+	if err := fillProductAvailability(products); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products"})
+		return
+	}
 
 	c.JSON(http.StatusOK, products)
 }
 
 // ToggleProductAvailability flips the is_available flag on a product.
-// Unavailable products cannot be added to new orders.
+// Unavailable products cannot be added to new orders. A manual switch-off is recorded as
+// unavailable_reason "manual" and is not undone by restocking; an out-of-stock product
+// cannot be switched on until it is restocked.
 //
 // @Summary Toggle product availability
 // @Description Quick toggle for is_available field
@@ -264,6 +417,7 @@ func GetProductsByCategory(c *gin.Context) {
 // @Success 200 {object} models.Products
 // @Failure 400 {object} map[string]string "Invalid ID"
 // @Failure 404 {object} map[string]string "Product not found"
+// @Failure 409 {object} map[string]string "Product is out of stock"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /products/{id}/availability [patch]
@@ -281,7 +435,17 @@ func ToggleProductAvailability(c *gin.Context) {
 		return
 	}
 
+	// An out-of-stock product comes back on its own once restocked
+	if !product.IsAvailable && product.StockQuantity == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product is out of stock, restock it before making it available"})
+		return
+	}
+
 	product.IsAvailable = !product.IsAvailable
+	product.UnavailableReason = ""
+	if !product.IsAvailable {
+		product.UnavailableReason = models.UnavailableManual
+	}
 
 	if err := config.DB.Save(&product).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update availability"})
@@ -293,18 +457,22 @@ func ToggleProductAvailability(c *gin.Context) {
 	c.JSON(http.StatusOK, product)
 }
 
-// UpdateProductStock sets the stock quantity for a product.
+// UpdateProductStock changes the stock of a product and records the change in the stock ledger.
+// Send either an absolute "stock_quantity" (the difference is recorded) or a signed "delta".
+// The reason must be "delivery" (stock in), "waste" (stock out) or "correction" (default).
+// Sale, cancellation and order edit movements are reserved for the order workflow.
 //
 // @Summary Update product stock
-// @Description Update the stock quantity for a product
+// @Description Apply a stock change with a reason; every change is written to the stock ledger
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
-// @Param stock body object true "Stock update" example({"stock_quantity": 100})
+// @Param stock body StockUpdateInput true "Stock update"
 // @Success 200 {object} models.Products
 // @Failure 400 {object} map[string]string "Invalid ID or data"
 // @Failure 404 {object} map[string]string "Product not found"
+// @Failure 409 {object} map[string]string "Stock changed concurrently"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /products/{id}/stock [patch]
@@ -322,22 +490,76 @@ func UpdateProductStock(c *gin.Context) {
 		return
 	}
 
-	var input struct {
-		StockQuantity uint `json:"stock_quantity"`
-	}
-
+	var input StockUpdateInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
 		return
 	}
 
-	product.StockQuantity = input.StockQuantity
+	if (input.StockQuantity == nil) == (input.Delta == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide exactly one of stock_quantity or delta"})
+		return
+	}
 
-	if err := config.DB.Save(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+	if input.Reason == "" {
+		input.Reason = models.StockReasonCorrection
+	}
+
+	delta := 0
+	if input.Delta != nil {
+		delta = *input.Delta
+	} else {
+		delta = int(*input.StockQuantity) - int(product.StockQuantity)
+	}
+
+	switch input.Reason {
+	case models.StockReasonDelivery:
+		if delta <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A delivery must increase the stock"})
+			return
+		}
+	case models.StockReasonWaste:
+		if delta >= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Waste must decrease the stock"})
+			return
+		}
+	case models.StockReasonCorrection:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reason must be 'delivery', 'waste' or 'correction'"})
 		return
 	}
 
+	if delta != 0 {
+		movement := models.StockMovement{
+			ProductID: product.ID,
+			Delta:     delta,
+			Reason:    input.Reason,
+			Note:      input.Note,
+			UserID:    currentUserID(c),
+		}
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			// An absolute quantity is only valid against the stock it was computed from
+			if input.StockQuantity != nil {
+				return setStockLevel(tx, movement, product.StockQuantity)
+			}
+			return applyStockMovement(tx, movement)
+		})
+
+		var shortage *stockShortageError
+		if errors.Is(err, errStockChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Stock changed in the meantime, please retry"})
+			return
+		}
+		if errors.As(err, &shortage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot go below zero"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+			return
+		}
+	}
+
 	config.DB.Preload("Category").First(&product, id)
 
 	c.JSON(http.StatusOK, product)
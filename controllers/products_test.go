@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 	"wacdo/testutils"
@@ -78,6 +79,30 @@ func TestGetProducts_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestGetProducts_FilterAndSort(t *testing.T) {
+	db := testutils.SetupTestDB()
+	burgers := testutils.SeedCategory(db, "Burgers")
+	drinks := testutils.SeedCategory(db, "Drinks")
+	testutils.SeedProduct(db, "Big Mac", 5.99, burgers.ID, true)
+	testutils.SeedProduct(db, "Cheeseburger", 2.50, burgers.ID, false)
+	testutils.SeedProduct(db, "Coke", 1.99, drinks.ID, true)
+
+	r := testutils.SetupRouter()
+	r.GET("/products", GetProducts)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", fmt.Sprintf("/products?category_id=%d", burgers.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(2), testutils.ParseResponse(w)["total"])
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/products?is_available=true&sort=-price", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, float64(2), resp["total"])
+	data := resp["data"].([]interface{})
+	assert.Equal(t, "Big Mac", data[0].(map[string]interface{})["name"])
+	assert.Equal(t, "Coke", data[1].(map[string]interface{})["name"])
+}
+
 func TestGetProduct_Success(t *testing.T) {
 	db := testutils.SetupTestDB()
 	cat := testutils.SeedCategory(db, "Burgers")
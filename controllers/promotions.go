@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/promotions"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// applyPromotions evaluates the active promotions, oldest first, against priced order items at a given time
+// read in the restaurant's timezone (see promotions.Apply). It sets the Discount of each item and returns the
+// discount lines, one per promotion that took something off, not yet attached to an order.
+// Product items must come with their product.
+func applyPromotions(tx *gorm.DB, items []models.OrderItem, at time.Time) ([]models.OrderDiscount, error) {
+	var promos []models.Promotion
+	if err := tx.Where("is_active = ?", true).Order("id").Find(&promos).Error; err != nil {
+		return nil, err
+	}
+
+	lines := make([]promotions.Line, len(items))
+	for i, item := range items {
+		lines[i] = promotions.Line{ProductID: item.ProductID, MenuID: item.MenuID, Quantity: item.Quantity, Total: item.ItemTotal}
+		if item.ProductID != nil {
+			lines[i].CategoryID = item.Product.CategoryID
+		}
+		items[i].Discount = 0
+	}
+
+	var records []models.OrderDiscount
+	for _, discount := range promotions.Apply(promos, lines, restaurantTime(at)) {
+		for i, part := range discount.Lines {
+			items[i].Discount += part
+		}
+		records = append(records, models.OrderDiscount{
+			PromotionID: discount.Promotion.ID,
+			Name:        discount.Promotion.Name,
+			Amount:      discount.Amount,
+		})
+	}
+	return records, nil
+}
+
+// validatePromotion checks a promotion sent by an admin and fills in its defaults.
+// Errors are meant to be shown to the user.
+func validatePromotion(p *models.Promotion) error {
+	if p.Name == "" {
+		return errors.New("Name is required")
+	}
+
+	switch p.Type {
+	case models.PromotionPercentage:
+		if p.Percentage == 0 || p.Percentage > 10000 {
+			return errors.New("Percentage is in basis points and must be between 1 and 10000 (100%)")
+		}
+	case models.PromotionFixed:
+		if p.Amount <= 0 {
+			return errors.New("Amount must be positive")
+		}
+	case models.PromotionBuyXGetY:
+		if p.BuyQuantity == 0 || p.GetQuantity == 0 {
+			return errors.New("Buy quantity and get quantity must be at least 1")
+		}
+		if p.Percentage == 0 {
+			p.Percentage = 10000 // the extra units are free
+		}
+		if p.Percentage > 10000 {
+			return errors.New("Percentage is in basis points and cannot exceed 10000 (100%)")
+		}
+	default:
+		return errors.New("Type must be 'percentage', 'fixed' or 'buy_x_get_y'")
+	}
+
+	scopes := 0
+	if p.ProductID != nil {
+		scopes++
+		if err := config.DB.First(&models.Products{}, *p.ProductID).Error; err != nil {
+			return errors.New("Product not found")
+		}
+	}
+	if p.CategoryID != nil {
+		scopes++
+		if err := config.DB.First(&models.Category{}, *p.CategoryID).Error; err != nil {
+			return errors.New("Category not found")
+		}
+	}
+	if p.MenuID != nil {
+		scopes++
+		if err := config.DB.First(&models.Menu{}, *p.MenuID).Error; err != nil {
+			return errors.New("Menu not found")
+		}
+	}
+	if scopes > 1 {
+		return errors.New("A promotion applies to at most one of product_id, category_id or menu_id")
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return errors.New("starts_at must be before ends_at")
+	}
+	return validateWindow(p.Window)
+}
+
+// CreatePromotion adds a discount rule. It applies to orders priced from then on, while active and within its time window.
+// is_active defaults to true.
+//
+// @Summary Create a promotion
+// @Description Create a percentage, fixed or buy-X-get-Y promotion, optionally scoped to a product, category or menu and limited in time
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param promotion body models.Promotion true "Promotion details"
+// @Success 201 {object} models.Promotion
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /promotions [post]
+func CreatePromotion(c *gin.Context) {
+	promotion := models.Promotion{IsActive: true}
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validatePromotion(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promotion.ID = 0
+	if err := config.DB.Create(&promotion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promotion"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// GetPromotions returns all promotions, active or not.
+//
+// @Summary Get all promotions
+// @Description Retrieve the list of promotions
+// @Tags Promotions
+// @Produce json
+// @Success 200 {array} models.Promotion
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /promotions [get]
+func GetPromotions(c *gin.Context) {
+	promos := []models.Promotion{}
+	if err := config.DB.Order("id").Find(&promos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promos)
+}
+
+// GetPromotion returns a single promotion by ID.
+//
+// @Summary Get a promotion by ID
+// @Description Retrieve a single promotion by its ID
+// @Tags Promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Promotion not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /promotions/{id} [get]
+func GetPromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := config.DB.First(&promotion, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// UpdatePromotion replaces a promotion's rule. Orders already priced keep the discount they recorded;
+// pending orders pick up the change the next time they are edited.
+//
+// @Summary Update a promotion
+// @Description Replace a promotion by ID
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param promotion body models.Promotion true "Promotion details"
+// @Success 200 {object} models.Promotion
+// @Failure 400 {object} map[string]string "Invalid ID or data"
+// @Failure 404 {object} map[string]string "Promotion not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /promotions/{id} [put]
+func UpdatePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := config.DB.First(&promotion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	input := models.Promotion{IsActive: true}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validatePromotion(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every field is replaced, so a scope or time limit left out is removed
+	input.ID = promotion.ID
+	input.CreatedAt = promotion.CreatedAt
+	if err := config.DB.Save(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+// DeletePromotion removes a promotion. Orders keep the discount lines it produced, with its name.
+//
+// @Summary Delete a promotion
+// @Description Delete a promotion by ID
+// @Tags Promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} map[string]string "Promotion deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Promotion not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /promotions/{id} [delete]
+func DeletePromotion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var promotion models.Promotion
+	if err := config.DB.First(&promotion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promotion not found"})
+		return
+	}
+
+	if err := config.DB.Delete(&promotion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete promotion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+}
+
+// PromotionPreview is the result of PreviewPromotions: an order as it would be priced, nothing saved.
+type PromotionPreview struct {
+	Items         []models.OrderItem     `json:"order_items"`
+	Subtotal      models.Money           `json:"subtotal"` // Sum of the item totals, before discounts
+	Discounts     []models.OrderDiscount `json:"discounts"`
+	DiscountTotal models.Money           `json:"discount_total"`
+	TotalPrice    models.Money           `json:"total_price"`
+	TaxLines      []models.OrderTaxLine  `json:"tax_lines"`
+}
+
+// PreviewPromotions prices an order without creating it and shows the promotions that would apply.
+// The order goes through the same validation and pricing as CreateOrder (see quoteOrder) and nothing is saved.
+// By default promotions are evaluated now; ?at= (RFC 3339) previews another moment, e.g. the start of happy hour.
+//
+// @Summary Preview the promotions of an order
+// @Description Dry run: price an order and apply the promotions without saving anything
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param order body OrderInput true "Order details"
+// @Param at query string false "Moment to evaluate the promotions at (RFC 3339, default now)"
+// @Success 200 {object} PromotionPreview
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Security BearerAuth
+// @Router /promotions/preview [post]
+func PreviewPromotions(c *gin.Context) {
+	at := now()
+	if raw := c.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'at', use RFC 3339 (e.g. 2026-03-04T18:30:00+01:00)"})
+			return
+		}
+		at = parsed
+	}
+
+	var input OrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateOrderInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := quoteOrder(input, currentUserID(c), at)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview := PromotionPreview{
+		Items:         quote.OrderItems,
+		Subtotal:      quote.TotalPrice + quote.DiscountTotal,
+		Discounts:     quote.Discounts,
+		DiscountTotal: quote.DiscountTotal,
+		TotalPrice:    quote.TotalPrice,
+		TaxLines:      quote.TaxLines,
+	}
+	c.JSON(http.StatusOK, preview)
+}
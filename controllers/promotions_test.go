@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func promotionRouter(userID uint) *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), "admin"))
+	r.GET("/promotions", GetPromotions)
+	r.POST("/promotions", CreatePromotion)
+	r.POST("/promotions/preview", PreviewPromotions)
+	r.PUT("/promotions/:id", UpdatePromotion)
+	r.DELETE("/promotions/:id", DeletePromotion)
+	r.POST("/orders", CreateOrder)
+	return r
+}
+
+// Wednesday 4 March 2026, 18:00
+var happyHourTime = time.Date(2026, 3, 4, 18, 0, 0, 0, time.Local)
+
+func TestCreatePromotion_Validation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Drinks")
+	menu := testutils.SeedMenu(db, "Big Mac Menu", 9.00, true)
+
+	r := promotionRouter(1)
+
+	cases := []struct {
+		body map[string]interface{}
+		code int
+	}{
+		{map[string]interface{}{"name": "Happy hour", "type": "percentage", "percentage": 2000, "category_id": cat.ID, "start_time": "17:00", "end_time": "19:00"}, http.StatusCreated},
+		{map[string]interface{}{"name": "2nd menu half price", "type": "buy_x_get_y", "buy_quantity": 1, "get_quantity": 1, "percentage": 5000, "menu_id": menu.ID}, http.StatusCreated},
+		{map[string]interface{}{"name": "Voucher", "type": "fixed", "amount": 5}, http.StatusCreated},
+		{map[string]interface{}{"type": "fixed", "amount": 5}, http.StatusBadRequest},                                   // no name
+		{map[string]interface{}{"name": "X", "type": "bogus"}, http.StatusBadRequest},                                   // unknown type
+		{map[string]interface{}{"name": "X", "type": "percentage", "percentage": 12000}, http.StatusBadRequest},         // over 100%
+		{map[string]interface{}{"name": "X", "type": "fixed"}, http.StatusBadRequest},                                   // no amount
+		{map[string]interface{}{"name": "X", "type": "buy_x_get_y", "buy_quantity": 2}, http.StatusBadRequest},          // nothing to get
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "category_id": 9999}, http.StatusBadRequest}, // unknown category
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "category_id": cat.ID, "menu_id": menu.ID}, http.StatusBadRequest},
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "start_time": "17:00"}, http.StatusBadRequest}, // no end
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "start_time": "5pm", "end_time": "7pm"}, http.StatusBadRequest},
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "weekdays": []int{7}}, http.StatusBadRequest},
+		{map[string]interface{}{"name": "X", "type": "fixed", "amount": 1, "starts_at": "2026-03-02T00:00:00Z", "ends_at": "2026-03-01T00:00:00Z"}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/promotions", tc.body))
+		assert.Equal(t, tc.code, w.Code, "%v: %s", tc.body, w.Body.String())
+	}
+
+	// Buy X get Y defaults to free extra units, and new promotions are active
+	var bogo models.Promotion
+	config.DB.Where("type = ?", models.PromotionBuyXGetY).First(&bogo)
+	assert.True(t, bogo.IsActive)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/promotions", map[string]interface{}{"name": "Free fries", "type": "buy_x_get_y", "buy_quantity": 2, "get_quantity": 1}))
+	assert.Equal(t, float64(10000), testutils.ParseResponse(w)["percentage"])
+}
+
+func TestCreateOrder_Promotions(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	food := testutils.SeedCategory(db, "Food")
+	drinks := testutils.SeedCategory(db, "Drinks")
+	burger := testutils.SeedProduct(db, "Burger", 5.00, food.ID, true)
+	coke := testutils.SeedProduct(db, "Coke", 3.00, drinks.ID, true)
+	menu := testutils.SeedMenu(db, "Burger Menu", 10.00, true)
+	testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+
+	happyHour := models.Promotion{Name: "Happy hour", Type: models.PromotionPercentage, Percentage: 2000, CategoryID: &drinks.ID, Window: models.Window{StartTime: "17:00", EndTime: "19:00"}, IsActive: true}
+	secondHalf := models.Promotion{Name: "2nd menu half price", Type: models.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1, Percentage: 5000, MenuID: &menu.ID, IsActive: true}
+	inactive := models.Promotion{Name: "Old voucher", Type: models.PromotionFixed, Amount: 500}
+	db.Create(&happyHour)
+	db.Create(&secondHalf)
+	db.Create(&inactive)
+	db.Model(&inactive).Update("is_active", false)
+
+	r := promotionRouter(user.ID)
+	body := map[string]interface{}{
+		"order_type": "counter",
+		"order_items": []map[string]interface{}{
+			{"menu_id": menu.ID, "quantity": 2},
+			{"product_id": coke.ID, "quantity": 1},
+		},
+	}
+
+	// During happy hour: half a menu (5.00) and 20% of the coke (0.60) off 23.00
+	freezeTime(t, happyHourTime)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, 17.40, resp["total_price"])
+	assert.Equal(t, 5.60, resp["discount_total"])
+
+	discounts := resp["discounts"].([]interface{})
+	if assert.Len(t, discounts, 2) {
+		assert.Equal(t, float64(happyHour.ID), discounts[0].(map[string]interface{})["promotion_id"])
+		assert.Equal(t, 0.60, discounts[0].(map[string]interface{})["amount"])
+		assert.Equal(t, "2nd menu half price", discounts[1].(map[string]interface{})["name"])
+		assert.Equal(t, 5.00, discounts[1].(map[string]interface{})["amount"])
+	}
+	items := resp["order_items"].([]interface{})
+	assert.Equal(t, 5.00, items[0].(map[string]interface{})["discount"])
+	assert.Equal(t, 0.60, items[1].(map[string]interface{})["discount"])
+
+	// The VAT summary is computed on the discounted amounts
+	var gross float64
+	for _, line := range resp["tax_lines"].([]interface{}) {
+		gross += line.(map[string]interface{})["gross"].(float64)
+	}
+	assert.InDelta(t, 17.40, gross, 0.001)
+
+	// After happy hour only the menu deal applies
+	freezeTime(t, happyHourTime.Add(2*time.Hour))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp = testutils.ParseResponse(w)
+	assert.Equal(t, 18.00, resp["total_price"])
+	assert.Len(t, resp["discounts"], 1)
+}
+
+func TestCreateOrder_DiscountNeverBelowZero(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Drinks")
+	coke := testutils.SeedProduct(db, "Coke", 3.00, cat.ID, true)
+	db.Create(&models.Promotion{Name: "Big voucher", Type: models.PromotionFixed, Amount: 5000, IsActive: true})
+	db.Create(&models.Promotion{Name: "Coke deal", Type: models.PromotionFixed, Amount: 1000, ProductID: &coke.ID, IsActive: true})
+
+	r := promotionRouter(user.ID)
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": coke.ID, "quantity": 2}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, 0.0, resp["total_price"])
+	assert.Equal(t, 6.00, resp["discount_total"])
+	// The voucher took everything, so the second promotion has nothing left to discount
+	assert.Len(t, resp["discounts"], 1)
+}
+
+func TestPreviewPromotions(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	drinks := testutils.SeedCategory(db, "Drinks")
+	coke := testutils.SeedProduct(db, "Coke", 3.00, drinks.ID, true)
+	db.Create(&models.Promotion{Name: "Happy hour", Type: models.PromotionPercentage, Percentage: 5000, CategoryID: &drinks.ID, Window: models.Window{StartTime: "17:00", EndTime: "19:00"}, IsActive: true})
+
+	r := promotionRouter(user.ID)
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": coke.ID, "quantity": 2}},
+	}
+
+	freezeTime(t, happyHourTime.Add(-2*time.Hour))
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/promotions/preview", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, 6.00, resp["total_price"])
+	assert.Empty(t, resp["discounts"])
+
+	// Previewing happy hour ahead of time
+	at := happyHourTime.Format(time.RFC3339)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/promotions/preview?at="+at, body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp = testutils.ParseResponse(w)
+	assert.Equal(t, 6.00, resp["subtotal"])
+	assert.Equal(t, 3.00, resp["total_price"])
+	assert.Len(t, resp["discounts"], 1)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/promotions/preview?at=tonight", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Nothing was saved or reserved
+	var orders int64
+	config.DB.Model(&models.Order{}).Count(&orders)
+	assert.Equal(t, int64(0), orders)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(coke.ID))
+}
+
+func TestUpdateAndDeletePromotion(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	drinks := testutils.SeedCategory(db, "Drinks")
+	coke := testutils.SeedProduct(db, "Coke", 3.00, drinks.ID, true)
+	promo := models.Promotion{Name: "Drinks", Type: models.PromotionPercentage, Percentage: 1000, CategoryID: &drinks.ID, IsActive: true}
+	db.Create(&promo)
+
+	r := promotionRouter(user.ID)
+	path := fmt.Sprintf("/promotions/%d", promo.ID)
+
+	// A full replacement: the category scope left out is removed
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PUT", path, map[string]interface{}{"name": "Everything", "type": "percentage", "percentage": 1000}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated models.Promotion
+	config.DB.First(&updated, promo.ID)
+	assert.Nil(t, updated.CategoryID)
+	assert.Equal(t, "Everything", updated.Name)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", "/promotions/9999", map[string]interface{}{"name": "X", "type": "percentage", "percentage": 1000}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": coke.ID, "quantity": 1}},
+	}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+
+	// Orders keep their discount lines once the promotion is gone
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", path, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var discount models.OrderDiscount
+	assert.NoError(t, config.DB.Where("order_id = ?", orderID).First(&discount).Error)
+	assert.Equal(t, "Everything", discount.Name)
+	assert.Equal(t, models.Money(30), discount.Amount)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", path, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
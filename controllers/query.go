@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Page size bounds for list endpoints. ?limit= above maxPageSize is capped rather than rejected.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// Page is the response envelope returned by every paginated list endpoint.
+// NextOffset is the offset of the following page, or null on the last page.
+type Page struct {
+	Data       interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextOffset *int        `json:"next_offset"`
+}
+
+// filterKind tells how a query parameter is parsed before it is applied as a WHERE clause.
+type filterKind int
+
+const (
+	filterString   filterKind = iota // exact match on a string column
+	filterID                         // exact match on a numeric ID column
+	filterBool                       // true/false
+	filterDateFrom                   // column >= date (YYYY-MM-DD or RFC 3339)
+	filterDateTo                     // column <= date, a plain date covers the whole day
+)
+
+// listFilter maps a query parameter to a column.
+type listFilter struct {
+	Param  string
+	Column string
+	Kind   filterKind
+}
+
+// listSpec describes what a list endpoint accepts: the sortable fields (query name → column),
+// the default ordering and the typed filters.
+type listSpec struct {
+	Sort        map[string]string
+	DefaultSort string
+	Filters     []listFilter
+}
+
+// applyFilters adds a WHERE clause for every filter present in the query string.
+// A value that does not parse for its kind is reported as an error naming the parameter.
+func applyFilters(c *gin.Context, query *gorm.DB, filters []listFilter) (*gorm.DB, error) {
+	for _, f := range filters {
+		value := c.Query(f.Param)
+		if value == "" {
+			continue
+		}
+
+		switch f.Kind {
+		case filterString:
+			query = query.Where(f.Column+" = ?", value)
+		case filterID:
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, errors.New("invalid '" + f.Param + "' filter, expected an ID")
+			}
+			query = query.Where(f.Column+" = ?", id)
+		case filterBool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, errors.New("invalid '" + f.Param + "' filter, expected true or false")
+			}
+			query = query.Where(f.Column+" = ?", b)
+		case filterDateFrom, filterDateTo:
+			t, err := parseDateParam(c, f.Param, f.Kind == filterDateTo)
+			if err != nil {
+				return nil, err
+			}
+			if f.Kind == filterDateFrom {
+				query = query.Where(f.Column+" >= ?", *t)
+			} else {
+				query = query.Where(f.Column+" <= ?", *t)
+			}
+		}
+	}
+	return query, nil
+}
+
+// sortClause turns ?sort=name,-price into an ORDER BY clause using only allow-listed fields.
+// A leading "-" sorts descending. The primary key is always appended so pages are stable.
+func sortClause(c *gin.Context, spec listSpec) (string, error) {
+	param := c.Query("sort")
+	if param == "" {
+		return spec.DefaultSort, nil
+	}
+
+	var parts []string
+	for _, field := range strings.Split(param, ",") {
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+		column, ok := spec.Sort[field]
+		if !ok {
+			return "", errors.New("invalid sort field '" + field + "'")
+		}
+		parts = append(parts, column+" "+direction)
+	}
+	return strings.Join(parts, ", ") + ", id ASC", nil
+}
+
+// pageParams reads ?limit= and ?offset=.
+func pageParams(c *gin.Context) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("invalid 'limit', expected a positive number")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid 'offset', expected zero or a positive number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// paginate filters, counts, sorts and pages a list query, loading the rows into dest (a pointer to a slice).
+// query must carry the Model but no preloads, since preloads do not apply to the count;
+// preload is applied to the page query only and may be nil.
+// Invalid request parameters are returned as a listQueryError (see isListQueryError) so callers can answer 400.
+func paginate(c *gin.Context, query *gorm.DB, spec listSpec, preload func(*gorm.DB) *gorm.DB, dest interface{}) (*Page, error) {
+	limit, offset, err := pageParams(c)
+	if err != nil {
+		return nil, &listQueryError{err}
+	}
+	order, err := sortClause(c, spec)
+	if err != nil {
+		return nil, &listQueryError{err}
+	}
+	query, err = applyFilters(c, query, spec.Filters)
+	if err != nil {
+		return nil, &listQueryError{err}
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	pageQuery := query.Order(order).Limit(limit).Offset(offset)
+	if preload != nil {
+		pageQuery = preload(pageQuery)
+	}
+	if err := pageQuery.Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	page := &Page{Data: dest, Total: total, Limit: limit, Offset: offset}
+	if next := offset + limit; int64(next) < total {
+		page.NextOffset = &next
+	}
+	return page, nil
+}
+
+// listQueryError marks an invalid limit, offset, sort or filter parameter.
+type listQueryError struct{ err error }
+
+func (e *listQueryError) Error() string { return e.err.Error() }
+
+// isListQueryError reports whether an error from paginate was caused by the request parameters.
+func isListQueryError(err error) bool {
+	var queryErr *listQueryError
+	return errors.As(err, &queryErr)
+}
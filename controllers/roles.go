@@ -5,15 +5,30 @@ import (
 	"net/http"
 	"strconv"
 	"wacdo/config"
+	"wacdo/middlewares"
 	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// errLastRoleManager is returned when a permission change would leave no active user able to manage roles.
+var errLastRoleManager = errors.New("no active user would be left with the roles:manage permission")
+
+// unknownPermission returns the first permission of the list that cannot be granted, or "" if all can.
+func unknownPermission(permissions models.PermissionList) string {
+	for _, p := range permissions {
+		if !models.ValidPermission(p) {
+			return p
+		}
+	}
+	return ""
+}
+
 // CreateRole adds a new role to the system.
 // Role names must be unique — duplicate names are rejected.
-// Expected roles are "admin", "accueil", and "preparation".
+// The seeded roles are "admin", "accueil", and "preparation"; a new role gets the permissions
+// sent with it (see GetPermissions), which must all be known.
 //
 // @Summary Create a new role
 // @Description Create a new role with the provided details
@@ -22,7 +37,7 @@ import (
 // @Produce json
 // @Param role body models.Roles true "Role details"
 // @Success 200 {object} models.Roles
-// @Failure 400 {object} map[string]string "Invalid data or role already exists"
+// @Failure 400 {object} map[string]string "Invalid data, unknown permission or role already exists"
 // @Security BearerAuth
 // @Router /roles [post]
 func CreateRole(c *gin.Context) {
@@ -33,6 +48,14 @@ func CreateRole(c *gin.Context) {
 		return
 	}
 
+	if p := unknownPermission(role.Permissions); p != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission '" + p + "'"})
+		return
+	}
+	if role.Permissions == nil {
+		role.Permissions = models.PermissionList{}
+	}
+
 	// Check if the role already exist
 	var existingRole models.Roles
 	if err := config.DB.Where("role_name = ?", role.RoleName).First(&existingRole).Error; err == nil {
@@ -157,3 +180,109 @@ func GetRole(c *gin.Context) {
 
 	c.JSON(http.StatusOK, role)
 }
+
+// UpdateRolePermissions replaces the permissions of a role. They apply from the next request of
+// its users (the cache of authenticated users is emptied). The change is refused if no active user would be left able to manage roles, so the
+// system cannot be locked out of its own administration.
+//
+// @Summary Set the permissions of a role
+// @Description Replace the permissions of a role with the given list (see GET /roles/permissions)
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param permissions body object true "Permissions, e.g. {\"permissions\": [\"orders:create\"]}"
+// @Success 200 {object} models.Roles
+// @Failure 400 {object} map[string]string "Invalid data or unknown permission"
+// @Failure 404 {object} map[string]string "Role not found"
+// @Failure 409 {object} map[string]string "Nobody would be left to manage roles"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /roles/{id}/permissions [patch]
+func UpdateRolePermissions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		Permissions models.PermissionList `json:"permissions" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+	if p := unknownPermission(input.Permissions); p != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission '" + p + "'"})
+		return
+	}
+
+	var role models.Roles
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&role, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Update("permissions", input.Permissions).Error; err != nil {
+			return err
+		}
+		role.Permissions = input.Permissions
+
+		managed, err := roleManagerLeft(tx)
+		if err != nil {
+			return err
+		}
+		if !managed {
+			return errLastRoleManager
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		return
+	case errors.Is(err, errLastRoleManager):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot change permissions: no active user would be left able to manage roles"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update permissions"})
+		return
+	}
+	middlewares.ForgetAllUsers()
+
+	c.JSON(http.StatusOK, role)
+}
+
+// roleManagerLeft reports whether at least one active user has a role granting roles:manage.
+func roleManagerLeft(tx *gorm.DB) (bool, error) {
+	var roles []models.Roles
+	if err := tx.Find(&roles).Error; err != nil {
+		return false, err
+	}
+	managers := []uint{}
+	for _, r := range roles {
+		if r.Permissions.Grants(models.PermRolesManage) {
+			managers = append(managers, r.ID)
+		}
+	}
+	if len(managers) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := tx.Model(&models.Users{}).Where("roles_id IN ? AND is_active = ?", managers, true).Count(&count).Error
+	return count > 0, err
+}
+
+// GetPermissions lists every permission a role can be granted, with a description.
+//
+// @Summary List the permissions
+// @Description Every permission that can be granted to a role. "*" grants them all and a prefix such as "orders:*" grants every permission under it.
+// @Tags Roles
+// @Produce json
+// @Success 200 {array} models.PermissionInfo
+// @Security BearerAuth
+// @Router /roles/permissions [get]
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.KnownPermissions)
+}
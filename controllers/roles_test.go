@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
+	"wacdo/config"
+	"wacdo/models"
 	"wacdo/testutils"
 
 	"github.com/stretchr/testify/assert"
@@ -145,3 +148,99 @@ func TestDeleteRole_StillInUse(t *testing.T) {
 	resp := testutils.ParseResponse(w)
 	assert.Contains(t, resp["error"], "still in use")
 }
+
+func TestCreateRole_WithPermissions(t *testing.T) {
+	testutils.SetupTestDB()
+
+	r := testutils.SetupRouter()
+	r.POST("/roles", CreateRole)
+
+	body := map[string]interface{}{"role_name": "manager", "permissions": []string{"catalog:write", "orders:*"}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/roles", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var role models.Roles
+	config.DB.Where("role_name = ?", "manager").First(&role)
+	assert.Equal(t, models.PermissionList{"catalog:write", "orders:*"}, role.Permissions)
+}
+
+func TestCreateRole_UnknownPermission(t *testing.T) {
+	testutils.SetupTestDB()
+
+	r := testutils.SetupRouter()
+	r.POST("/roles", CreateRole)
+
+	body := map[string]interface{}{"role_name": "manager", "permissions": []string{"catalog:write", "catalog:burn"}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/roles", body))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, testutils.ParseResponse(w)["error"], "catalog:burn")
+}
+
+func TestUpdateRolePermissions_Success(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	role := testutils.SeedRole(db, "accueil")
+
+	r := testutils.SetupRouter()
+	r.PATCH("/roles/:id/permissions", UpdateRolePermissions)
+
+	body := map[string]interface{}{"permissions": []string{"orders:create", "kitchen:view"}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/roles", role.ID)+"/permissions", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []interface{}{"orders:create", "kitchen:view"}, testutils.ParseResponse(w)["permissions"])
+
+	config.DB.First(&role, role.ID)
+	assert.Equal(t, models.PermissionList{"orders:create", "kitchen:view"}, role.Permissions)
+
+	// Unknown permissions and missing roles are rejected
+	body = map[string]interface{}{"permissions": []string{"kitchen:cook"}}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/roles", role.ID)+"/permissions", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", "/roles/999/permissions", map[string]interface{}{"permissions": []string{}}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateRolePermissions_KeepsARoleManager(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	manager := testutils.SeedRole(db, "manager")
+
+	r := testutils.SetupRouter()
+	r.PATCH("/roles/:id/permissions", UpdateRolePermissions)
+	adminURL := testutils.IDParam("/roles", admin.ID) + "/permissions"
+
+	// The only active user able to manage roles would lose it
+	body := map[string]interface{}{"permissions": []string{"catalog:write"}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", adminURL, body))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	config.DB.First(&admin, admin.ID)
+	assert.Equal(t, models.PermissionList{models.PermAll}, admin.Permissions, "rolled back")
+
+	// A role without active users does not count
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/roles", manager.ID)+"/permissions", map[string]interface{}{"permissions": []string{"roles:manage"}}))
+	inactive := testutils.SeedUser(db, "former", "former@test.com", "P@ssw0rd", manager.ID)
+	config.DB.Model(&inactive).Update("is_active", false)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", adminURL, body))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Once someone else can manage roles, admin may give it up
+	testutils.SeedUser(db, "lead", "lead@test.com", "P@ssw0rd", manager.ID)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", adminURL, body))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetPermissions(t *testing.T) {
+	r := testutils.SetupRouter()
+	r.GET("/roles/permissions", GetPermissions)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/roles/permissions", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var permissions []models.PermissionInfo
+	json.Unmarshal(w.Body.Bytes(), &permissions)
+	assert.Len(t, permissions, len(models.KnownPermissions))
+	assert.Equal(t, models.PermUsersManage, permissions[0].Name)
+}
@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// restaurantTime returns a moment as the restaurant's clock reads it (see config.Location).
+// Schedules and promotion windows are compared against it.
+func restaurantTime(at time.Time) time.Time {
+	return at.In(config.Location)
+}
+
+// schedules holds the availability schedules by what they restrict.
+type schedules struct {
+	products   map[uint][]models.AvailabilitySchedule
+	menus      map[uint][]models.AvailabilitySchedule
+	categories map[uint][]models.AvailabilitySchedule
+}
+
+// loadSchedules reads every availability schedule. The table is small (a few per restricted item),
+// so loading it whole is simpler than picking the schedules of each product, menu and category.
+func loadSchedules(tx *gorm.DB) (schedules, error) {
+	var list []models.AvailabilitySchedule
+	if err := tx.Order("id").Find(&list).Error; err != nil {
+		return schedules{}, err
+	}
+
+	s := schedules{
+		products:   make(map[uint][]models.AvailabilitySchedule),
+		menus:      make(map[uint][]models.AvailabilitySchedule),
+		categories: make(map[uint][]models.AvailabilitySchedule),
+	}
+	for _, schedule := range list {
+		switch {
+		case schedule.ProductID != nil:
+			s.products[*schedule.ProductID] = append(s.products[*schedule.ProductID], schedule)
+		case schedule.MenuID != nil:
+			s.menus[*schedule.MenuID] = append(s.menus[*schedule.MenuID], schedule)
+		case schedule.CategoryID != nil:
+			s.categories[*schedule.CategoryID] = append(s.categories[*schedule.CategoryID], schedule)
+		}
+	}
+	return s, nil
+}
+
+// productClosed returns why the schedules keep a product from being ordered at a moment
+// (its own, then its category's), or "" when they let it be ordered.
+func (s schedules) productClosed(productID, categoryID uint, at time.Time) string {
+	at = restaurantTime(at)
+	if !models.SchedulesOpen(s.products[productID], at) {
+		return models.UnavailableOutsideSchedule
+	}
+	if !models.SchedulesOpen(s.categories[categoryID], at) {
+		return models.UnavailableCategoryOutsideSchedule
+	}
+	return ""
+}
+
+// menuClosed returns why the schedules keep a menu from being ordered at a moment, or "" when they let it be ordered.
+// Besides its own schedules, every product of the menu must be within its schedules (see productClosed);
+// the components must come with their product.
+func (s schedules) menuClosed(menu models.Menu, at time.Time) string {
+	if !models.SchedulesOpen(s.menus[menu.ID], restaurantTime(at)) {
+		return models.UnavailableOutsideSchedule
+	}
+	for _, mp := range menu.MenuProducts {
+		if s.productClosed(mp.ProductID, mp.Product.CategoryID, at) != "" {
+			return models.UnavailableComponentOutsideSchedule
+		}
+	}
+	return ""
+}
+
+// storedAvailability is the availability given by the is_available flag alone.
+func storedAvailability(isAvailable bool, reason string) models.Availability {
+	if isAvailable {
+		return models.Availability{Available: true}
+	}
+	if reason == "" {
+		reason = models.UnavailableManual
+	}
+	return models.Availability{Reason: reason}
+}
+
+// product returns the effective availability of a product at a moment: the stored flag first, then the schedules.
+func (s schedules) product(p models.Products, at time.Time) models.Availability {
+	if !p.IsAvailable {
+		return storedAvailability(false, p.UnavailableReason)
+	}
+	if reason := s.productClosed(p.ID, p.CategoryID, at); reason != "" {
+		return models.Availability{Reason: reason}
+	}
+	return models.Availability{Available: true}
+}
+
+// menu returns the effective availability of a menu at a moment: the stored flag first, then the schedules.
+func (s schedules) menu(m models.Menu, at time.Time) models.Availability {
+	if !m.IsAvailable {
+		return storedAvailability(false, m.UnavailableReason)
+	}
+	if reason := s.menuClosed(m, at); reason != "" {
+		return models.Availability{Reason: reason}
+	}
+	return models.Availability{Available: true}
+}
+
+// fillProductAvailability sets the effective availability of catalog products as of now.
+func fillProductAvailability(products []models.Products) error {
+	s, err := loadSchedules(config.DB)
+	if err != nil {
+		return err
+	}
+	at := now()
+	for i := range products {
+		availability := s.product(products[i], at)
+		products[i].EffectiveAvailability = &availability
+	}
+	return nil
+}
+
+// fillMenuAvailability sets the effective availability of catalog menus as of now.
+// The menu components must come with their product.
+func fillMenuAvailability(menus []models.Menu) error {
+	s, err := loadSchedules(config.DB)
+	if err != nil {
+		return err
+	}
+	at := now()
+	for i := range menus {
+		availability := s.menu(menus[i], at)
+		menus[i].EffectiveAvailability = &availability
+	}
+	return nil
+}
+
+// validateWindow checks the weekdays and daily hours of a promotion or schedule.
+// Errors are meant to be shown to the user.
+func validateWindow(w models.Window) error {
+	for _, day := range w.Weekdays {
+		if day < time.Sunday || day > time.Saturday {
+			return errors.New("Weekdays go from 0 (Sunday) to 6 (Saturday)")
+		}
+	}
+	if (w.StartTime == "") != (w.EndTime == "") {
+		return errors.New("start_time and end_time go together")
+	}
+	if w.StartTime != "" && (!models.ValidClock(w.StartTime) || !models.ValidClock(w.EndTime) || w.StartTime == w.EndTime) {
+		return errors.New("start_time and end_time must be distinct times written HH:MM")
+	}
+	return nil
+}
+
+// validateSchedule checks a schedule sent by an admin. Errors are meant to be shown to the user.
+func validateSchedule(s *models.AvailabilitySchedule) error {
+	targets := 0
+	if s.ProductID != nil {
+		targets++
+		if err := config.DB.First(&models.Products{}, *s.ProductID).Error; err != nil {
+			return errors.New("Product not found")
+		}
+	}
+	if s.MenuID != nil {
+		targets++
+		if err := config.DB.First(&models.Menu{}, *s.MenuID).Error; err != nil {
+			return errors.New("Menu not found")
+		}
+	}
+	if s.CategoryID != nil {
+		targets++
+		if err := config.DB.First(&models.Category{}, *s.CategoryID).Error; err != nil {
+			return errors.New("Category not found")
+		}
+	}
+	if targets != 1 {
+		return errors.New("A schedule applies to exactly one of product_id, menu_id or category_id")
+	}
+
+	if err := validateWindow(s.Window); err != nil {
+		return err
+	}
+	if (s.StartDate != "" && !models.ValidDate(s.StartDate)) || (s.EndDate != "" && !models.ValidDate(s.EndDate)) {
+		return errors.New("start_date and end_date must be dates written YYYY-MM-DD")
+	}
+	if s.StartDate != "" && s.EndDate != "" && s.StartDate > s.EndDate {
+		return errors.New("start_date cannot be after end_date")
+	}
+	if len(s.Weekdays) == 0 && s.StartTime == "" && s.StartDate == "" && s.EndDate == "" {
+		return errors.New("A schedule needs weekdays, daily hours or dates")
+	}
+	return nil
+}
+
+// CreateSchedule restricts when a product, a menu or the products of a category can be ordered.
+// Something with several schedules can be ordered while any of them is open.
+//
+// @Summary Create an availability schedule
+// @Description Limit a product, menu or category to weekdays, daily hours and/or a date range (restaurant timezone)
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param schedule body models.AvailabilitySchedule true "Schedule details"
+// @Success 201 {object} models.AvailabilitySchedule
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /schedules [post]
+func CreateSchedule(c *gin.Context) {
+	var schedule models.AvailabilitySchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateSchedule(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule.ID = 0
+	if err := config.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// scheduleFilters lists the filters accepted by GetSchedules.
+var scheduleFilters = []listFilter{
+	{Param: "product_id", Column: "product_id", Kind: filterID},
+	{Param: "menu_id", Column: "menu_id", Kind: filterID},
+	{Param: "category_id", Column: "category_id", Kind: filterID},
+}
+
+// GetSchedules returns the availability schedules, optionally those of one product, menu or category.
+// Filters: ?product_id=, ?menu_id=, ?category_id=.
+//
+// @Summary Get availability schedules
+// @Description Retrieve the availability schedules
+// @Tags Schedules
+// @Produce json
+// @Param product_id query int false "Schedules of a product"
+// @Param menu_id query int false "Schedules of a menu"
+// @Param category_id query int false "Schedules of a category"
+// @Success 200 {array} models.AvailabilitySchedule
+// @Failure 400 {object} map[string]string "Invalid filter"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /schedules [get]
+func GetSchedules(c *gin.Context) {
+	query, err := applyFilters(c, config.DB.Model(&models.AvailabilitySchedule{}), scheduleFilters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list := []models.AvailabilitySchedule{}
+	if err := query.Order("id").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetSchedule returns a single availability schedule by ID.
+//
+// @Summary Get an availability schedule by ID
+// @Description Retrieve a single availability schedule by its ID
+// @Tags Schedules
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} models.AvailabilitySchedule
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Schedule not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /schedules/{id} [get]
+func GetSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var schedule models.AvailabilitySchedule
+	if err := config.DB.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule replaces an availability schedule. It applies to orders from then on.
+//
+// @Summary Update an availability schedule
+// @Description Replace an availability schedule by ID
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Param schedule body models.AvailabilitySchedule true "Schedule details"
+// @Success 200 {object} models.AvailabilitySchedule
+// @Failure 400 {object} map[string]string "Invalid ID or data"
+// @Failure 404 {object} map[string]string "Schedule not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /schedules/{id} [put]
+func UpdateSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var schedule models.AvailabilitySchedule
+	if err := config.DB.First(&schedule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	}
+
+	var input models.AvailabilitySchedule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	if err := validateSchedule(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every field is replaced, so hours or dates left out are removed
+	input.ID = schedule.ID
+	input.CreatedAt = schedule.CreatedAt
+	if err := config.DB.Save(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+// DeleteSchedule removes an availability schedule. Without schedules left, its product, menu or category
+// can be ordered at any time again (as long as it is marked available).
+//
+// @Summary Delete an availability schedule
+// @Description Delete an availability schedule by ID
+// @Tags Schedules
+// @Produce json
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]string "Schedule deleted"
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Schedule not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /schedules/{id} [delete]
+func DeleteSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var schedule models.AvailabilitySchedule
+	if err := config.DB.First(&schedule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	}
+
+	if err := config.DB.Delete(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted"})
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func scheduleRouter(userID uint) *gin.Engine {
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(userID), "admin"))
+	r.GET("/schedules", GetSchedules)
+	r.POST("/schedules", CreateSchedule)
+	r.PUT("/schedules/:id", UpdateSchedule)
+	r.DELETE("/schedules/:id", DeleteSchedule)
+	r.GET("/products", GetProducts)
+	r.GET("/products/:id", GetProduct)
+	r.GET("/menus/:id", GetMenu)
+	r.POST("/orders", CreateOrder)
+	return r
+}
+
+// Wednesday 4 March 2026, 08:00
+var breakfastTime = time.Date(2026, 3, 4, 8, 0, 0, 0, time.Local)
+
+func effectiveAvailability(resp map[string]interface{}) (bool, interface{}) {
+	availability := resp["effective_availability"].(map[string]interface{})
+	return availability["available"].(bool), availability["reason"]
+}
+
+func TestCreateSchedule_Validation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Breakfast")
+	product := testutils.SeedProduct(db, "Croissant", 1.50, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Breakfast Menu", 4.00, true)
+
+	r := scheduleRouter(1)
+
+	cases := []struct {
+		body map[string]interface{}
+		code int
+	}{
+		{map[string]interface{}{"name": "Breakfast", "product_id": product.ID, "start_time": "07:00", "end_time": "10:30"}, http.StatusCreated},
+		{map[string]interface{}{"name": "Winter", "menu_id": menu.ID, "start_date": "2026-12-01", "end_date": "2027-02-28"}, http.StatusCreated},
+		{map[string]interface{}{"name": "Weekends", "category_id": cat.ID, "weekdays": []int{0, 6}}, http.StatusCreated},
+		{map[string]interface{}{"start_time": "07:00", "end_time": "10:30"}, http.StatusBadRequest},                                                 // no target
+		{map[string]interface{}{"product_id": product.ID, "menu_id": menu.ID, "weekdays": []int{1}}, http.StatusBadRequest},                         // two targets
+		{map[string]interface{}{"product_id": 9999, "weekdays": []int{1}}, http.StatusBadRequest},                                                   // unknown product
+		{map[string]interface{}{"product_id": product.ID}, http.StatusBadRequest},                                                                   // restricts nothing
+		{map[string]interface{}{"product_id": product.ID, "start_time": "07:00"}, http.StatusBadRequest},                                            // no end
+		{map[string]interface{}{"product_id": product.ID, "weekdays": []int{7}}, http.StatusBadRequest},                                             // no such day
+		{map[string]interface{}{"product_id": product.ID, "start_date": "2026-13-01"}, http.StatusBadRequest},                                       // invalid date
+		{map[string]interface{}{"product_id": product.ID, "start_date": "2026-03-01", "end_date": "2026-02-01"}, http.StatusBadRequest},             // backwards
+		{map[string]interface{}{"product_id": product.ID, "start_time": "7am", "end_time": "10am"}, http.StatusBadRequest},                          // not HH:MM
+		{map[string]interface{}{"product_id": product.ID, "start_time": "10:00", "end_time": "10:00", "weekdays": []int{1}}, http.StatusBadRequest}, // empty window
+	}
+	for _, tc := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/schedules", tc.body))
+		assert.Equal(t, tc.code, w.Code, "%v: %s", tc.body, w.Body.String())
+	}
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", fmt.Sprintf("/schedules?product_id=%d", product.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Breakfast"`)
+	assert.NotContains(t, w.Body.String(), "Winter")
+}
+
+func TestCatalog_EffectiveAvailability(t *testing.T) {
+	db := testutils.SetupTestDB()
+	breakfast := testutils.SeedCategory(db, "Breakfast")
+	drinks := testutils.SeedCategory(db, "Drinks")
+	croissant := testutils.SeedProduct(db, "Croissant", 1.50, breakfast.ID, true)
+	coffee := testutils.SeedProduct(db, "Coffee", 2.00, drinks.ID, true)
+	muffin := testutils.SeedProduct(db, "Muffin", 2.50, breakfast.ID, false)
+	menu := testutils.SeedMenu(db, "Breakfast Menu", 3.00, true)
+	testutils.SeedMenuProduct(db, menu.ID, croissant.ID, 1, false)
+	testutils.SeedMenuProduct(db, menu.ID, coffee.ID, 1, false)
+	db.Create(&models.AvailabilitySchedule{Name: "Breakfast", CategoryID: &breakfast.ID, Window: models.Window{StartTime: "07:00", EndTime: "10:30"}})
+
+	r := scheduleRouter(1)
+
+	freezeTime(t, breakfastTime)
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", croissant.ID), nil))
+	available, reason := effectiveAvailability(testutils.ParseResponse(w))
+	assert.True(t, available)
+	assert.Nil(t, reason)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/menus", menu.ID), nil))
+	available, _ = effectiveAvailability(testutils.ParseResponse(w))
+	assert.True(t, available)
+
+	// At noon breakfast is over: the croissant's category is closed, and so is the menu that contains it
+	freezeTime(t, breakfastTime.Add(4*time.Hour))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", croissant.ID), nil))
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, true, resp["is_available"], "the stored flag is unchanged")
+	available, reason = effectiveAvailability(resp)
+	assert.False(t, available)
+	assert.Equal(t, models.UnavailableCategoryOutsideSchedule, reason)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/menus", menu.ID), nil))
+	available, reason = effectiveAvailability(testutils.ParseResponse(w))
+	assert.False(t, available)
+	assert.Equal(t, models.UnavailableComponentOutsideSchedule, reason)
+
+	// A product switched off keeps its own reason; the others come with theirs in the list
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/products?sort=id", nil))
+	data := testutils.ParseResponse(w)["data"].([]interface{})
+	if assert.Len(t, data, 3) {
+		available, _ = effectiveAvailability(data[1].(map[string]interface{}))
+		assert.True(t, available, "coffee is not restricted")
+		assert.Equal(t, float64(muffin.ID), data[2].(map[string]interface{})["id"])
+		available, reason = effectiveAvailability(data[2].(map[string]interface{}))
+		assert.False(t, available)
+		assert.Equal(t, models.UnavailableManual, reason)
+	}
+}
+
+func TestCreateOrder_Schedules(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	porridge := testutils.SeedProduct(db, "Porridge", 3.00, cat.ID, true)
+	fries := testutils.SeedProduct(db, "Fries", 2.00, cat.ID, true)
+	salad := testutils.SeedProduct(db, "Salad", 2.50, cat.ID, true)
+	burger := testutils.SeedProduct(db, "Burger", 5.00, cat.ID, true)
+	menu := testutils.SeedMenu(db, "Burger Menu", 8.00, true)
+	testutils.SeedMenuProduct(db, menu.ID, burger.ID, 1, false)
+	friesMP := testutils.SeedMenuProduct(db, menu.ID, fries.ID, 1, true)
+	db.Create(&models.MenuProductSubstitute{MenuProductID: friesMP.ID, ProductID: salad.ID})
+	winter := testutils.SeedMenu(db, "Winter Menu", 9.00, true)
+	testutils.SeedMenuProduct(db, winter.ID, burger.ID, 1, false)
+
+	db.Create(&models.AvailabilitySchedule{Name: "Breakfast", ProductID: &porridge.ID, Window: models.Window{StartTime: "07:00", EndTime: "10:30"}})
+	db.Create(&models.AvailabilitySchedule{Name: "Not before lunch", ProductID: &fries.ID, Window: models.Window{StartTime: "11:00", EndTime: "23:00"}})
+	db.Create(&models.AvailabilitySchedule{Name: "Winter", MenuID: &winter.ID, StartDate: "2026-12-01", EndDate: "2027-02-28"})
+
+	r := scheduleRouter(user.ID)
+	order := func(item map[string]interface{}) (int, string) {
+		body := map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{item}}
+		w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+		return w.Code, w.Body.String()
+	}
+
+	freezeTime(t, breakfastTime)
+	code, _ := order(map[string]interface{}{"product_id": porridge.ID, "quantity": 1})
+	assert.Equal(t, http.StatusCreated, code)
+
+	// The fries of the menu are not served yet, unless they are replaced
+	code, body := order(map[string]interface{}{"menu_id": menu.ID, "quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "product 'Fries' is not available at this time")
+	code, body = order(map[string]interface{}{"menu_id": menu.ID, "quantity": 1, "substitutions": []map[string]interface{}{{"menu_product_id": friesMP.ID, "product_id": salad.ID}}})
+	assert.Equal(t, http.StatusCreated, code, body)
+
+	code, body = order(map[string]interface{}{"menu_id": winter.ID, "quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "menu 'Winter Menu' is not available at this time")
+
+	freezeTime(t, breakfastTime.Add(4*time.Hour))
+	code, body = order(map[string]interface{}{"product_id": porridge.ID, "quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "product 'Porridge' is not available at this time")
+	code, _ = order(map[string]interface{}{"menu_id": menu.ID, "quantity": 1})
+	assert.Equal(t, http.StatusCreated, code)
+
+	// Seasonal menu, on the last day of the season
+	freezeTime(t, time.Date(2027, 2, 28, 20, 0, 0, 0, time.Local))
+	code, _ = order(map[string]interface{}{"menu_id": winter.ID, "quantity": 1})
+	assert.Equal(t, http.StatusCreated, code)
+
+	// Nothing was reserved by the refused orders
+	assert.Equal(t, uint(testutils.DefaultStock)-1, stockOf(porridge.ID))
+}
+
+func TestSchedules_RestaurantTimezone(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Food")
+	porridge := testutils.SeedProduct(db, "Porridge", 3.00, cat.ID, true)
+	db.Create(&models.AvailabilitySchedule{Name: "Breakfast", ProductID: &porridge.ID, Window: models.Window{StartTime: "07:00", EndTime: "10:30"}})
+
+	previous := config.Location
+	config.Location = time.FixedZone("UTC+10", 10*3600)
+	t.Cleanup(func() { config.Location = previous })
+
+	r := scheduleRouter(user.ID)
+	body := map[string]interface{}{"order_type": "counter", "order_items": []map[string]interface{}{{"product_id": porridge.ID, "quantity": 1}}}
+
+	// 22:00 UTC is 08:00 at the restaurant
+	freezeTime(t, time.Date(2026, 3, 4, 22, 0, 0, 0, time.UTC))
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// 08:00 UTC is 18:00 at the restaurant
+	freezeTime(t, time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC))
+	w = testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateAndDeleteSchedule(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Food")
+	porridge := testutils.SeedProduct(db, "Porridge", 3.00, cat.ID, true)
+	schedule := models.AvailabilitySchedule{Name: "Breakfast", ProductID: &porridge.ID, Window: models.Window{StartTime: "07:00", EndTime: "10:30"}}
+	db.Create(&schedule)
+
+	r := scheduleRouter(1)
+	url := testutils.IDParam("/schedules", schedule.ID)
+
+	// A full replace: the hours left out are removed
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PUT", url, map[string]interface{}{"name": "Weekends", "product_id": porridge.ID, "weekdays": []int{0, 6}}))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stored models.AvailabilitySchedule
+	db.First(&stored, schedule.ID)
+	assert.Equal(t, "", stored.StartTime)
+	assert.Equal(t, []time.Weekday{time.Sunday, time.Saturday}, stored.Weekdays)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", url, map[string]interface{}{"product_id": porridge.ID}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", url, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", url, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+	"wacdo/config"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// Access tokens are short-lived: a revoked session stops working at once anyway (the middleware checks it),
+// but a short expiry also bounds how long a leaked token is useful. The client refreshes them with
+// its refresh token, which lasts as long as the session is used at least once a week.
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// TokenResponse is returned by Login and RefreshSession.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`  // JWT to send as "Authorization: Bearer ..."
+	RefreshToken string `json:"refresh_token"` // Single-use, exchanged at /users/refresh for a new pair
+	TokenType    string `json:"token_type"`    // Always "Bearer"
+	ExpiresIn    int    `json:"expires_in"`    // Access token lifetime in seconds
+}
+
+// errRefreshInvalid rejects a refresh token that is unknown, expired, or whose session or user is gone.
+var errRefreshInvalid = errors.New("invalid refresh token")
+
+// randomToken returns n random bytes, URL-safe base64 encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a refresh token, as stored. Refresh tokens are random,
+// so a plain hash is enough to keep the table useless to whoever reads it.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// signAccessToken returns a JWT for a user within a session. It carries the session ID, checked
+// by middlewares.Authentication on every request, a unique jti, and the permissions of the user's
+// role (loaded in user.Role) so the frontend can show what the user may do.
+func signAccessToken(user models.Users, roleName string, sessionID uint) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	issuedAt := time.Now() // the JWT library checks expiry against the real clock
+	claim := &CustomClaim{
+		UserID:      user.ID,
+		RoleName:    roleName,
+		SessionID:   sessionID,
+		Permissions: user.Role.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(accessTokenTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claim).SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// issueTokens creates a new refresh token for a session and signs an access token to go with it.
+// The user's role must be loaded.
+func issueTokens(tx *gorm.DB, session models.Session, user models.Users) (TokenResponse, error) {
+	refresh, err := randomToken(32)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if err := tx.Create(&models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now().Add(refreshTokenTTL),
+	}).Error; err != nil {
+		return TokenResponse{}, err
+	}
+
+	access, err := signAccessToken(user, user.Role.RoleName, session.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{AccessToken: access, RefreshToken: refresh, TokenType: "Bearer", ExpiresIn: int(accessTokenTTL.Seconds())}, nil
+}
+
+// openSession starts a session for a user who just logged in and returns its first tokens.
+// The user's role must be loaded.
+func openSession(tx *gorm.DB, c *gin.Context, user models.Users) (TokenResponse, error) {
+	session := models.Session{
+		UserID:     user.ID,
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		IP:         c.ClientIP(),
+		LastUsedAt: now(),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return TokenResponse{}, err
+	}
+	return issueTokens(tx, session, user)
+}
+
+// truncate cuts s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// revokeSessions closes the open sessions of a user, except keep (0 to close them all). Their access
+// tokens are refused from the next request and their refresh tokens can no longer be exchanged.
+// Once the transaction is committed, call middlewares.ForgetSessions so the cache drops them.
+func revokeSessions(tx *gorm.DB, userID uint, reason string, keep uint) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND id <> ?", userID, keep).
+		Updates(map[string]interface{}{"revoked_at": now(), "revoked_reason": reason}).Error
+}
+
+// currentSessionID returns the session of the authenticated request (set by middlewares.Authentication), or 0.
+func currentSessionID(c *gin.Context) uint {
+	return uint(c.GetInt("sessionID"))
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token.
+// Each refresh token works once: presenting one again means it was copied, so the whole session is
+// revoked and its holder, legitimate or not, has to log in again.
+//
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and refresh token (rotation)
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param token body object true "Refresh token ({\"refresh_token\": \"...\"})"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string "Invalid data"
+// @Failure 401 {object} map[string]string "Invalid, expired, reused or revoked refresh token"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /users/refresh [post]
+func RefreshSession(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	var tokens TokenResponse
+	var reusedSession uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(input.RefreshToken)).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshInvalid
+			}
+			return err
+		}
+
+		// Claiming the token and checking it was unused is one statement, so two concurrent refreshes
+		// with the same token cannot both succeed
+		at := now()
+		claimed := tx.Model(&models.RefreshToken{}).Where("id = ? AND used_at IS NULL", token.ID).Update("used_at", at)
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected == 0 {
+			reusedSession = token.SessionID
+			return tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", token.SessionID).
+				Updates(map[string]interface{}{"revoked_at": at, "revoked_reason": models.SessionRefreshReused}).Error
+		}
+
+		var session models.Session
+		if err := tx.First(&session, token.SessionID).Error; err != nil || session.RevokedAt != nil || !token.ExpiresAt.After(at) {
+			return errRefreshInvalid
+		}
+		var user models.Users
+		if err := tx.Preload("Role").First(&user, session.UserID).Error; err != nil || !user.IsActive {
+			return errRefreshInvalid
+		}
+
+		if err := tx.Model(&session).Update("last_used_at", at).Error; err != nil {
+			return err
+		}
+		var err error
+		tokens, err = issueTokens(tx, session, user)
+		return err
+	})
+	switch {
+	case reusedSession != 0:
+		middlewares.ForgetSession(reusedSession)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token already used, the session has been revoked"})
+		return
+	case errors.Is(err, errRefreshInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the session of the access token used to call it: the access token stops working
+// at once and the refresh token can no longer be exchanged. Other devices stay signed in.
+//
+// @Summary Log out
+// @Description Revoke the current session
+// @Tags Users
+// @Produce json
+// @Success 200 {object} map[string]string "Logged out"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /users/logout [post]
+func Logout(c *gin.Context) {
+	sessionID := currentSessionID(c)
+	if sessionID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		return
+	}
+
+	if err := config.DB.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": now(), "revoked_reason": models.SessionLogout}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	middlewares.ForgetSession(sessionID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/middlewares"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionRouter serves the session endpoints behind the real authentication middleware,
+// with GET /whoami standing for any protected endpoint.
+func sessionRouter() *gin.Engine {
+	r := testutils.SetupRouter()
+	r.POST("/users/login", Login)
+	r.POST("/users/refresh", RefreshSession)
+	authenticated := r.Group("/", middlewares.Authentication())
+	authenticated.POST("/users/logout", Logout)
+	authenticated.PATCH("/users/:id/password", ChangePassword)
+	authenticated.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt("userID"), "session_id": c.GetInt("sessionID"), "role": c.GetString("userRole")})
+	})
+	authenticated.GET("/reports", middlewares.RequirePermission(models.PermReportsView), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	// Admin actions, called without a token
+	r.PATCH("/users/:id/status", ToggleUserStatus)
+	r.PATCH("/users/:id/role", UpdateUserRole)
+	r.PATCH("/roles/:id/permissions", UpdateRolePermissions)
+	r.PATCH("/users/:id/reset-password", ResetPassword)
+	r.DELETE("/users/:id", DeleteUser)
+	return r
+}
+
+func login(t *testing.T, r *gin.Engine, email, password string) TokenResponse {
+	t.Helper()
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/users/login", map[string]string{"email": email, "password": password}))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tokens TokenResponse
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	return tokens
+}
+
+func refresh(r *gin.Engine, refreshToken string) (int, TokenResponse) {
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/users/refresh", map[string]string{"refresh_token": refreshToken}))
+	var tokens TokenResponse
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	return w.Code, tokens
+}
+
+// authorized performs a request with an access token and returns the status code.
+func authorized(r *gin.Engine, method, url, accessToken string, body interface{}) int {
+	req := testutils.JSONRequest(method, url, body)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return testutils.PerformRequest(r, req).Code
+}
+
+func TestRefreshSession_Rotation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+
+	r := sessionRouter()
+	first := login(t, r, "counter@test.com", "P@ssw0rd")
+	assert.Equal(t, 900, first.ExpiresIn)
+	assert.Equal(t, http.StatusOK, authorized(r, "GET", "/whoami", first.AccessToken, nil))
+
+	code, second := refresh(r, first.RefreshToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken, "refresh tokens rotate")
+	assert.Equal(t, http.StatusOK, authorized(r, "GET", "/whoami", second.AccessToken, nil))
+
+	var stored models.RefreshToken
+	config.DB.Last(&stored)
+	assert.Equal(t, hashToken(second.RefreshToken), stored.TokenHash, "only the hash is stored")
+
+	// The first refresh token was used: presenting it again revokes the whole session
+	code, _ = refresh(r, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", second.AccessToken, nil))
+	code, _ = refresh(r, second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	var session models.Session
+	config.DB.First(&session)
+	assert.NotNil(t, session.RevokedAt)
+	assert.Equal(t, models.SessionRefreshReused, session.RevokedReason)
+
+	code, _ = refresh(r, "not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestRefreshSession_Expired(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+
+	r := sessionRouter()
+	tokens := login(t, r, "counter@test.com", "P@ssw0rd")
+
+	freezeTime(t, time.Now().Add(refreshTokenTTL+time.Minute))
+	code, _ := refresh(r, tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestLogout(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+
+	r := sessionRouter()
+	tablet := login(t, r, "counter@test.com", "P@ssw0rd")
+	desk := login(t, r, "counter@test.com", "P@ssw0rd")
+
+	assert.Equal(t, http.StatusOK, authorized(r, "POST", "/users/logout", tablet.AccessToken, nil))
+
+	// The access token stops working before it expires, and cannot be refreshed
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", tablet.AccessToken, nil))
+	code, _ := refresh(r, tablet.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Other devices stay signed in
+	assert.Equal(t, http.StatusOK, authorized(r, "GET", "/whoami", desk.AccessToken, nil))
+}
+
+func TestSessions_RevokedByUserChanges(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	role := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", role.ID)
+	userURL := testutils.IDParam("/users", user.ID)
+
+	r := sessionRouter()
+
+	// Deactivation
+	tokens := login(t, r, "counter@test.com", "P@ssw0rd")
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", userURL+"/status", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", tokens.AccessToken, nil))
+	code, _ := refresh(r, tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", userURL+"/status", nil)) // reactivate
+
+	// Password change: the session making it stays open, the others are closed
+	current := login(t, r, "counter@test.com", "P@ssw0rd")
+	other := login(t, r, "counter@test.com", "P@ssw0rd")
+	body := map[string]string{"old_password": "P@ssw0rd", "new_password": "N3wP@ssword"}
+	assert.Equal(t, http.StatusOK, authorized(r, "PATCH", userURL+"/password", current.AccessToken, body))
+	assert.Equal(t, http.StatusOK, authorized(r, "GET", "/whoami", current.AccessToken, nil))
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", other.AccessToken, nil))
+
+	// Password reset
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", userURL+"/reset-password", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", current.AccessToken, nil))
+	tempPassword := testutils.ParseResponse(w)["temp_password"].(string)
+
+	// Deletion
+	tokens = login(t, r, "counter@test.com", tempPassword)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("DELETE", userURL, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, authorized(r, "GET", "/whoami", tokens.AccessToken, nil))
+
+	var open int64
+	config.DB.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&open)
+	assert.Equal(t, int64(0), open)
+}
+
+func TestSessions_RoleChangesApplyAtOnce(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	counter := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", counter.ID)
+
+	r := sessionRouter()
+	tokens := login(t, r, "counter@test.com", "P@ssw0rd")
+	assert.Equal(t, http.StatusForbidden, authorized(r, "GET", "/reports", tokens.AccessToken, nil))
+
+	// New permissions for the role: the same token gets them on its next request
+	body := map[string]interface{}{"permissions": []string{models.PermReportsView}}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/roles", counter.ID)+"/permissions", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, authorized(r, "GET", "/reports", tokens.AccessToken, nil))
+
+	// Moved to another role: the role claim of the token no longer counts
+	kitchen := testutils.SeedRole(db, "preparation")
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/role", map[string]uint{"roles_id": kitchen.ID}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusForbidden, authorized(r, "GET", "/reports", tokens.AccessToken, nil))
+
+	req := testutils.JSONRequest("GET", "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	assert.Equal(t, "preparation", testutils.ParseResponse(testutils.PerformRequest(r, req))["role"])
+}
@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// stockShortageError is returned when a product does not have enough stock left for an order item.
+type stockShortageError struct {
+	Product   string
+	Requested uint
+	Available uint
+}
+
+func (e *stockShortageError) Error() string {
+	return fmt.Sprintf("insufficient stock for '%s': %d requested, %d available", e.Product, e.Requested, e.Available)
+}
+
+var errStockChanged = errors.New("stock changed concurrently")
+
+// StockDrift reports a product whose stock no longer matches the sum of its ledger movements.
+type StockDrift struct {
+	ProductID     uint   `json:"product_id"`
+	Name          string `json:"name"`
+	StockQuantity int    `json:"stock_quantity"`
+	LedgerTotal   int    `json:"ledger_total"`
+	Difference    int    `json:"difference"` // stock_quantity - ledger_total
+}
+
+// currentUserID returns the authenticated user's ID, or nil when the request is anonymous.
+func currentUserID(c *gin.Context) *uint {
+	id := c.GetInt("userID")
+	if id <= 0 {
+		return nil
+	}
+	userID := uint(id)
+	return &userID
+}
+
+// applyStockMovement changes a product's stock by movement.Delta and records the movement in the ledger.
+// Decreases are applied with a single conditional UPDATE, so two orders placed at the same time can
+// never both take the last unit (row lock on Postgres, write lock on SQLite).
+func applyStockMovement(tx *gorm.DB, movement models.StockMovement) error {
+	query := tx.Model(&models.Products{}).Where("id = ?", movement.ProductID)
+	if movement.Delta < 0 {
+		query = query.Where("stock_quantity >= ?", -movement.Delta)
+	}
+
+	res := query.Update("stock_quantity", gorm.Expr("stock_quantity + ?", movement.Delta))
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		var product models.Products
+		if err := tx.First(&product, movement.ProductID).Error; err != nil {
+			return errors.New("product not found")
+		}
+		return &stockShortageError{Product: product.Name, Requested: uint(-movement.Delta), Available: product.StockQuantity}
+	}
+
+	if err := tx.Create(&movement).Error; err != nil {
+		return err
+	}
+
+	return syncStockAvailability(tx, movement.ProductID)
+}
+
+// setStockLevel applies movement.Delta only if the product still holds expected units,
+// so an absolute stock count entered by staff never overwrites a concurrent sale.
+func setStockLevel(tx *gorm.DB, movement models.StockMovement, expected uint) error {
+	res := tx.Model(&models.Products{}).
+		Where("id = ? AND stock_quantity = ?", movement.ProductID, expected).
+		Update("stock_quantity", int(expected)+movement.Delta)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errStockChanged
+	}
+
+	if err := tx.Create(&movement).Error; err != nil {
+		return err
+	}
+
+	return syncStockAvailability(tx, movement.ProductID)
+}
+
+// syncStockAvailability switches a product off when its stock reaches zero and back on when it is
+// restocked, then re-evaluates every menu that uses it. Items an admin switched off manually stay off.
+func syncStockAvailability(tx *gorm.DB, productID uint) error {
+	var product models.Products
+	if err := tx.First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case product.StockQuantity == 0 && product.IsAvailable:
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"is_available":       false,
+			"unavailable_reason": models.UnavailableOutOfStock,
+		}).Error; err != nil {
+			return err
+		}
+	case product.StockQuantity > 0 && !product.IsAvailable && product.UnavailableReason == models.UnavailableOutOfStock:
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"is_available":       true,
+			"unavailable_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	var menuIDs []uint
+	if err := tx.Model(&models.MenuProduct{}).Where("product_id = ?", productID).Distinct().Pluck("menu_id", &menuIDs).Error; err != nil {
+		return err
+	}
+	for _, menuID := range menuIDs {
+		if err := syncMenuAvailability(tx, menuID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncMenuAvailability switches a menu off when any required (non-optional) component no longer has
+// enough stock for one menu, and back on once every required component is restocked.
+// Menus an admin switched off manually stay off.
+func syncMenuAvailability(tx *gorm.DB, menuID uint) error {
+	var menu models.Menu
+	if err := tx.Preload("MenuProducts.Product").First(&menu, menuID).Error; err != nil {
+		return err
+	}
+
+	outOfStock := menuOutOfStock(menu)
+
+	switch {
+	case outOfStock && menu.IsAvailable:
+		return tx.Model(&menu).Updates(map[string]interface{}{
+			"is_available":       false,
+			"unavailable_reason": models.UnavailableOutOfStock,
+		}).Error
+	case !outOfStock && !menu.IsAvailable && menu.UnavailableReason == models.UnavailableOutOfStock:
+		return tx.Model(&menu).Updates(map[string]interface{}{
+			"is_available":       true,
+			"unavailable_reason": "",
+		}).Error
+	}
+
+	return nil
+}
+
+// menuOutOfStock reports whether a required component of the menu cannot cover one more menu.
+// MenuProducts must be preloaded with their Product.
+func menuOutOfStock(menu models.Menu) bool {
+	for _, mp := range menu.MenuProducts {
+		if !mp.IsOptional && mp.Product.StockQuantity < mp.Quantity {
+			return true
+		}
+	}
+	return false
+}
+
+// reserveStock takes quantity units of a product for an order item and records a sale movement.
+func reserveStock(tx *gorm.DB, item models.OrderItem, productID, quantity uint, userID *uint) error {
+	return applyStockMovement(tx, models.StockMovement{
+		ProductID:   productID,
+		Delta:       -int(quantity),
+		Reason:      models.StockReasonSale,
+		UserID:      userID,
+		OrderID:     &item.OrderID,
+		OrderItemID: &item.ID,
+	})
+}
+
+// checkStock is the read-only counterpart of reserveStock, for quotes: it checks that a product has quantity
+// units left on top of those taken by the previous items of the order (taken, by product), and adds them.
+func checkStock(tx *gorm.DB, productID, quantity uint, taken map[uint]uint) error {
+	var product models.Products
+	if err := tx.First(&product, productID).Error; err != nil {
+		return errors.New("product not found")
+	}
+	available := product.StockQuantity - min(taken[productID], product.StockQuantity)
+	if quantity > available {
+		return &stockShortageError{Product: product.Name, Requested: quantity, Available: available}
+	}
+	taken[productID] += quantity
+	return nil
+}
+
+// reserveMenuStock reserves the component products of a menu item.
+// Each component consumes MenuProduct.Quantity units per menu ordered.
+func reserveMenuStock(tx *gorm.DB, item models.OrderItem, menu models.Menu, userID *uint) error {
+	for _, mp := range menu.MenuProducts {
+		if err := reserveStock(tx, item, mp.ProductID, mp.Quantity*item.Quantity, userID); err != nil {
+			return fmt.Errorf("menu '%s': %w", menu.Name, err)
+		}
+	}
+	return nil
+}
+
+// releaseOrderStock gives back the stock still reserved by an order, recorded with the given reason
+// (cancellation, or order_edit when the items are replaced).
+// The reserved quantities are read from the ledger rather than recomputed from the menus,
+// so later changes to a menu's composition do not affect what is returned.
+func releaseOrderStock(tx *gorm.DB, orderID uint, reason string, userID *uint) error {
+	return releaseReservedStock(tx, orderID, nil, reason, userID)
+}
+
+// releaseOrderItemStock gives back the stock still reserved by one item of an order,
+// before the item is removed or repriced. It is recorded as an order edit.
+func releaseOrderItemStock(tx *gorm.DB, item models.OrderItem, userID *uint) error {
+	return releaseReservedStock(tx, item.OrderID, &item.ID, models.StockReasonOrderEdit, userID)
+}
+
+// releaseReservedStock returns the stock reserved by an order, or by a single item when itemID is set.
+func releaseReservedStock(tx *gorm.DB, orderID uint, itemID *uint, reason string, userID *uint) error {
+	var balances []struct {
+		ProductID   uint
+		OrderItemID *uint
+		Total       int
+	}
+	query := tx.Model(&models.StockMovement{}).
+		Select("product_id, order_item_id, SUM(delta) AS total").
+		Where("order_id = ?", orderID)
+	if itemID != nil {
+		query = query.Where("order_item_id = ?", *itemID)
+	}
+	if err := query.Group("product_id, order_item_id").Scan(&balances).Error; err != nil {
+		return err
+	}
+
+	for _, b := range balances {
+		if b.Total >= 0 {
+			continue
+		}
+		if err := applyStockMovement(tx, models.StockMovement{
+			ProductID:   b.ProductID,
+			Delta:       -b.Total,
+			Reason:      reason,
+			UserID:      userID,
+			OrderID:     &orderID,
+			OrderItemID: b.OrderItemID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseDateParam reads an optional date query parameter, accepting RFC 3339 timestamps or YYYY-MM-DD dates.
+// A plain date used as an upper bound ("to") covers the whole day.
+func parseDateParam(c *gin.Context, name string, endOfDay bool) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, errors.New("invalid '" + name + "' date, expected YYYY-MM-DD or RFC 3339")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// GetProductStockMovements returns the stock ledger of a single product, most recent first.
+//
+// @Summary Get stock movements of a product
+// @Description Retrieve the stock ledger entries of a product
+// @Tags Stock
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.StockMovement
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /products/{id}/stock/movements [get]
+func GetProductStockMovements(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var product models.Products
+	if err := config.DB.First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var movements []models.StockMovement
+	if err := config.DB.Where("product_id = ?", id).Order("created_at DESC, id DESC").Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// stockMovementListSpec lists the sort fields and filters accepted by GetStockMovements.
+var stockMovementListSpec = listSpec{
+	Sort: map[string]string{
+		"created_at": "created_at",
+		"delta":      "delta",
+	},
+	DefaultSort: "created_at DESC, id DESC",
+	Filters: []listFilter{
+		{Param: "from", Column: "created_at", Kind: filterDateFrom},
+		{Param: "to", Column: "created_at", Kind: filterDateTo},
+		{Param: "product_id", Column: "product_id", Kind: filterID},
+		{Param: "reason", Column: "reason", Kind: filterString},
+	},
+}
+
+// GetStockMovements returns a page of stock ledger entries across all products over a date range.
+// Use ?from=2025-01-01&to=2025-01-31 for the range, plus optional ?product_id= and ?reason= filters.
+//
+// @Summary Get stock movements
+// @Description Retrieve stock ledger entries over a date range
+// @Tags Stock
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD or RFC 3339)"
+// @Param product_id query int false "Filter by product"
+// @Param reason query string false "Filter by reason"
+// @Param sort query string false "Sort fields: created_at, delta (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of movements to skip"
+// @Success 200 {object} Page{data=[]models.StockMovement}
+// @Failure 400 {object} map[string]string "Invalid filter"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /stock/movements [get]
+func GetStockMovements(c *gin.Context) {
+	var movements []models.StockMovement
+
+	page, err := paginate(c, config.DB.Model(&models.StockMovement{}), stockMovementListSpec, nil, &movements)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetLowStockProducts returns the products whose stock is at or below their low-stock threshold.
+// Products without a threshold (0) are only reported once they are out of stock.
+//
+// @Summary Get low-stock products
+// @Description List products at or below their low-stock threshold
+// @Tags Stock
+// @Produce json
+// @Success 200 {array} models.Products
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /stock/low [get]
+func GetLowStockProducts(c *gin.Context) {
+	var products []models.Products
+
+	if err := config.DB.Preload("Category").
+		Where("stock_quantity <= low_stock_threshold").
+		Order("stock_quantity ASC").
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve low-stock products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+// GetStockDrift compares each product's stock with the sum of its ledger movements
+// and reports every product where the two disagree. An empty list means the ledger is consistent.
+//
+// @Summary Check stock ledger consistency
+// @Description List products whose stock quantity does not match the sum of their stock movements
+// @Tags Stock
+// @Produce json
+// @Success 200 {array} StockDrift
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /stock/drift [get]
+func GetStockDrift(c *gin.Context) {
+	drift := []StockDrift{}
+
+	err := config.DB.Table("products").
+		Select("products.id AS product_id, products.name, products.stock_quantity, COALESCE(SUM(stock_movements.delta), 0) AS ledger_total").
+		Joins("LEFT JOIN stock_movements ON stock_movements.product_id = products.id").
+		Group("products.id, products.name, products.stock_quantity").
+		Having("products.stock_quantity <> COALESCE(SUM(stock_movements.delta), 0)").
+		Scan(&drift).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stock ledger"})
+		return
+	}
+
+	for i := range drift {
+		drift[i].Difference = drift[i].StockQuantity - drift[i].LedgerTotal
+	}
+
+	c.JSON(http.StatusOK, drift)
+}
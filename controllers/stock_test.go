@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ledgerTotal sums the stock movements recorded for a product.
+func ledgerTotal(productID uint) int {
+	var total int
+	config.DB.Model(&models.StockMovement{}).Select("COALESCE(SUM(delta), 0)").Where("product_id = ?", productID).Scan(&total)
+	return total
+}
+
+func TestUpdateProductStock_DeliveryRecordsMovement(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	body := map[string]interface{}{"delta": 20, "reason": "delivery", "note": "Morning truck"}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock+20), stockOf(p.ID))
+
+	var movement models.StockMovement
+	db.Where("product_id = ? AND reason = ?", p.ID, "delivery").First(&movement)
+	assert.Equal(t, 20, movement.Delta)
+	assert.Equal(t, "Morning truck", movement.Note)
+	assert.Equal(t, user.ID, *movement.UserID)
+	assert.Equal(t, int(stockOf(p.ID)), ledgerTotal(p.ID))
+}
+
+func TestUpdateProductStock_AbsoluteQuantityRecordsDifference(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	body := map[string]interface{}{"stock_quantity": 40}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var movement models.StockMovement
+	db.Where("product_id = ?", p.ID).Order("id DESC").First(&movement)
+	assert.Equal(t, "correction", movement.Reason)
+	assert.Equal(t, 40-testutils.DefaultStock, movement.Delta)
+	assert.Equal(t, 40, ledgerTotal(p.ID))
+}
+
+func TestUpdateProductStock_InvalidReason(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	cases := []map[string]interface{}{
+		{"delta": -5, "reason": "sale"},          // reserved for orders
+		{"delta": -5, "reason": "delivery"},      // deliveries add stock
+		{"delta": 5, "reason": "waste"},          // waste removes stock
+		{"delta": 5, "stock_quantity": 10},       // both forms at once
+		{"delta": -(testutils.DefaultStock + 1)}, // below zero
+	}
+	for _, body := range cases {
+		w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "body %v should be rejected", body)
+	}
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(p.ID))
+}
+
+func TestUpdateProduct_DoesNotChangeStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.PUT("/products/:id", UpdateProduct)
+
+	body := map[string]interface{}{"name": "Big Mac", "stock_quantity": 5}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/products", p.ID), body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(testutils.DefaultStock), stockOf(p.ID))
+}
+
+func TestUpdateProduct_CannotSwitchOnOutOfStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	testutils.SetStock(db, p.ID, 0)
+	db.Model(&p).Updates(map[string]interface{}{"is_available": false, "unavailable_reason": models.UnavailableOutOfStock})
+
+	r := testutils.SetupRouter()
+	r.PUT("/products/:id", UpdateProduct)
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	body := map[string]interface{}{"name": "Big Mac", "is_available": true}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/products", p.ID), body))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var product models.Products
+	db.First(&product, p.ID)
+	assert.False(t, product.IsAvailable)
+	assert.Equal(t, models.UnavailableOutOfStock, product.UnavailableReason)
+
+	// Other changes still go through while it stays off
+	body = map[string]interface{}{"name": "Big Mac XL", "is_available": false}
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PUT", testutils.IDParam("/products", p.ID), body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, testutils.ParseResponse(w)["is_available"])
+
+	// Once restocked it is back on
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", map[string]interface{}{"delta": 5, "reason": "delivery"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	db.First(&product, p.ID)
+	assert.True(t, product.IsAvailable)
+}
+
+func TestCreateProduct_RecordsInitialStock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+
+	r := testutils.SetupRouter()
+	r.POST("/products", CreateProduct)
+
+	body := map[string]interface{}{"name": "Big Mac", "price": 5.99, "category_id": cat.ID, "stock_quantity": 30}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/products", body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	id := uint(testutils.ParseResponse(w)["id"].(float64))
+	assert.Equal(t, uint(30), stockOf(id))
+	assert.Equal(t, 30, ledgerTotal(id))
+}
+
+func TestDeleteProduct_RemovesStockLedger(t *testing.T) {
+	db := testutils.SetupTestDB()
+	testutils.EnableForeignKeys(db)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	other := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.DELETE("/products/:id", DeleteProduct)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("DELETE", testutils.IDParam("/products", p.ID), nil))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var movements int64
+	db.Model(&models.StockMovement{}).Where("product_id = ?", p.ID).Count(&movements)
+	assert.Zero(t, movements)
+	assert.Equal(t, testutils.DefaultStock, ledgerTotal(other.ID))
+}
+
+func TestOrderLifecycle_WritesSaleAndCancellationMovements(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/orders/:id/cancel", CancelOrder)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": p.ID, "quantity": 2}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	orderID := uint(testutils.ParseResponse(w)["id"].(float64))
+
+	var sale models.StockMovement
+	db.Where("order_id = ? AND reason = ?", orderID, "sale").First(&sale)
+	assert.Equal(t, -2, sale.Delta)
+	assert.NotNil(t, sale.OrderItemID)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/orders", orderID)+"/cancel", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var cancellation models.StockMovement
+	db.Where("order_id = ? AND reason = ?", orderID, "cancellation").First(&cancellation)
+	assert.Equal(t, 2, cancellation.Delta)
+	assert.Equal(t, int(stockOf(p.ID)), ledgerTotal(p.ID))
+}
+
+func TestGetStockMovements_DateRange(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	db.Create(&models.StockMovement{ProductID: p.ID, Delta: 10, Reason: "delivery", CreatedAt: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)})
+	db.Create(&models.StockMovement{ProductID: p.ID, Delta: -2, Reason: "waste", CreatedAt: time.Date(2025, 3, 2, 22, 0, 0, 0, time.UTC)})
+	db.Create(&models.StockMovement{ProductID: p.ID, Delta: 5, Reason: "delivery", CreatedAt: time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)})
+
+	r := testutils.SetupRouter()
+	r.GET("/stock/movements", GetStockMovements)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/stock/movements?from=2025-03-01&to=2025-03-02", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page struct {
+		Data  []models.StockMovement `json:"data"`
+		Total int64                  `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, int64(2), page.Total)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/stock/movements?from=2025-03-01&reason=delivery", nil))
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Len(t, page.Data, 2)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/stock/movements?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetProductStockMovements_Success(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	other := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+	testutils.SetStock(db, other.ID, 3)
+
+	r := testutils.SetupRouter()
+	r.GET("/products/:id/stock/movements", GetProductStockMovements)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", testutils.IDParam("/products", p.ID)+"/stock/movements", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var movements []models.StockMovement
+	json.Unmarshal(w.Body.Bytes(), &movements)
+	assert.Len(t, movements, 1)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("GET", "/products/999/stock/movements", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetStockDrift_ReportsMismatch(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	drifted := testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+
+	// Simulate a change that bypassed the ledger
+	db.Model(&models.Products{}).Where("id = ?", drifted.ID).Update("stock_quantity", 90)
+
+	r := testutils.SetupRouter()
+	r.GET("/stock/drift", GetStockDrift)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/stock/drift", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var drift []StockDrift
+	json.Unmarshal(w.Body.Bytes(), &drift)
+	assert.Len(t, drift, 1)
+	assert.Equal(t, drifted.ID, drift[0].ProductID)
+	assert.Equal(t, -10, drift[0].Difference)
+}
+
+// availabilityOf reads the stored availability flag and reason of a product.
+func availabilityOf(productID uint) (bool, string) {
+	var product models.Products
+	config.DB.First(&product, productID)
+	return product.IsAvailable, product.UnavailableReason
+}
+
+// menuAvailabilityOf reads the stored availability flag and reason of a menu.
+func menuAvailabilityOf(menuID uint) (bool, string) {
+	var menu models.Menu
+	config.DB.First(&menu, menuID)
+	return menu.IsAvailable, menu.UnavailableReason
+}
+
+func TestStockAvailability_ProductOffWhenSoldOutAndBackOnRestock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	role := testutils.SeedRole(db, "admin")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", role.ID)
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	testutils.SetStock(db, p.ID, 2)
+
+	r := testutils.SetupRouter()
+	r.Use(testutils.AuthMiddleware(int(user.ID), "admin"))
+	r.POST("/orders", CreateOrder)
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	body := map[string]interface{}{
+		"order_type":  "counter",
+		"order_items": []map[string]interface{}{{"product_id": p.ID, "quantity": 2}},
+	}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("POST", "/orders", body))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	available, reason := availabilityOf(p.ID)
+	assert.False(t, available)
+	assert.Equal(t, "out_of_stock", reason)
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", map[string]interface{}{"delta": 10, "reason": "delivery"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	available, reason = availabilityOf(p.ID)
+	assert.True(t, available)
+	assert.Equal(t, "", reason)
+}
+
+func TestStockAvailability_ManualSwitchOffSurvivesRestock(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/products/:id/availability", ToggleProductAvailability)
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/availability", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "manual", testutils.ParseResponse(w)["unavailable_reason"])
+
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", map[string]interface{}{"stock_quantity": 0}))
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", map[string]interface{}{"delta": 10, "reason": "delivery"}))
+
+	available, reason := availabilityOf(p.ID)
+	assert.False(t, available)
+	assert.Equal(t, "manual", reason)
+}
+
+func TestToggleProductAvailability_OutOfStockCannotBeEnabled(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	p := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/products/:id/availability", ToggleProductAvailability)
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/stock", map[string]interface{}{"stock_quantity": 0}))
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", p.ID)+"/availability", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestStockAvailability_MenuFollowsRequiredComponents(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	burger := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	toy := testutils.SeedProduct(db, "Toy", 0.50, cat.ID, true)
+	m := testutils.SeedMenu(db, "Big Mac Menu", 9.99, true)
+	testutils.SeedMenuProduct(db, m.ID, burger.ID, 2, false)
+	testutils.SeedMenuProduct(db, m.ID, toy.ID, 1, true)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/products/:id/stock", UpdateProductStock)
+	r.PATCH("/menus/:id/availability", ToggleMenuAvailability)
+
+	// An optional component running out does not affect the menu
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", toy.ID)+"/stock", map[string]interface{}{"stock_quantity": 0}))
+	available, _ := menuAvailabilityOf(m.ID)
+	assert.True(t, available)
+
+	// One burger left is not enough for a menu that needs two
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", burger.ID)+"/stock", map[string]interface{}{"stock_quantity": 1}))
+	available, reason := menuAvailabilityOf(m.ID)
+	assert.False(t, available)
+	assert.Equal(t, "out_of_stock", reason)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/menus", m.ID)+"/availability", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/products", burger.ID)+"/stock", map[string]interface{}{"delta": 5, "reason": "delivery"}))
+	available, reason = menuAvailabilityOf(m.ID)
+	assert.True(t, available)
+	assert.Equal(t, "", reason)
+}
+
+func TestGetLowStockProducts_Success(t *testing.T) {
+	db := testutils.SetupTestDB()
+	cat := testutils.SeedCategory(db, "Burgers")
+	low := testutils.SeedProduct(db, "Big Mac", 5.99, cat.ID, true)
+	testutils.SeedProduct(db, "Fries", 2.49, cat.ID, true)
+	db.Model(&models.Products{}).Where("id = ?", low.ID).Update("low_stock_threshold", 150)
+
+	r := testutils.SetupRouter()
+	r.GET("/stock/low", GetLowStockProducts)
+
+	w := testutils.PerformRequest(r, testutils.JSONRequest("GET", "/stock/low", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var products []models.Products
+	json.Unmarshal(w.Body.Bytes(), &products)
+	assert.Len(t, products, 1)
+	assert.Equal(t, low.ID, products[0].ID)
+}
@@ -4,10 +4,9 @@ package controllers
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strconv"
-	"time"
 	"wacdo/config"
+	"wacdo/middlewares"
 	"wacdo/models"
 	"wacdo/utils"
 
@@ -18,24 +17,31 @@ import (
 )
 
 type CustomClaim struct {
-	UserID   uint   `json:"UserID"`
-	RoleName string `json:"RoleName"`
+	UserID      uint                  `json:"UserID"`
+	RoleName    string                `json:"RoleName"`
+	SessionID   uint                  `json:"SessionID"`   // Session the token belongs to, refused once revoked
+	Permissions models.PermissionList `json:"Permissions"` // For the frontend only: the API reads them from the role on each request
 	jwt.RegisteredClaims
 }
 
-// Login authenticates a user by email and password, then returns a signed JWT token.
-// The token contains the user's ID and role name, and expires after 2 hours.
+// Login authenticates a user by email and password, opens a session and returns its tokens:
+// a JWT access token with the user's ID, role name, permissions and session ID, valid 15 minutes, and a
+// refresh token to get the next ones from /users/refresh (see RefreshSession).
 // Deactivated users are rejected even if credentials are valid.
 // Both email-not-found and wrong-password return the same error to prevent user enumeration.
+// Every attempt is recorded; repeated failures for an email or from an IP make the next attempts
+// wait, and lock the email for a while (see login_throttle.go), answering 429 with Retry-After.
 //
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return an access token and a refresh token
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param credentials body models.Users true "User credentials (email and password)"
-// @Success 200 {string} string "JWT token"
+// @Success 200 {object} TokenResponse
 // @Failure 400 {object} map[string]string "Invalid credentials"
+// @Failure 429 {object} map[string]interface{} "Too many failed attempts, retry after the given seconds"
+// @Failure 500 {object} map[string]string "Server error"
 // @Router /users/login [post]
 func Login(c *gin.Context) {
 
@@ -49,21 +55,48 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	email := normalizeLoginEmail(input.Email)
+	ip := c.ClientIP()
+	at := now()
+
+	// Throttle repeated failures before looking at the password
+	wait, err := loginRetryAfter(config.DB, email, ip, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+		return
+	}
+	if wait > 0 {
+		recordLoginAttempt(config.DB, email, ip, nil, models.LoginThrottled, at)
+		c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later", "retry_after": retryAfterSeconds(wait)})
+		return
+	}
+
 	// Check for email
 	var existingUser models.Users
 	if err := config.DB.Where("email = ?", input.Email).First(&existingUser).Error; err != nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(input.Password))
+		if err := recordLoginFailure(config.DB, email, ip, nil, at); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record login attempt"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	// Check for Password
 	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(input.Password)); err != nil {
+		if err := recordLoginFailure(config.DB, email, ip, &existingUser, at); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record login attempt"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
 	// Check if user account is active
 	if !existingUser.IsActive {
+		recordLoginAttempt(config.DB, email, ip, &existingUser.ID, models.LoginDeactivated, at)
 		c.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
 		return
 	}
@@ -75,25 +108,29 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Build JWT claims with user ID and role
-	claim := &CustomClaim{
-		UserID:   existingUser.ID,
-		RoleName: role.RoleName,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(2 * time.Hour)),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	existingUser.Role = role
 
+	// Open a session and issue its first tokens; the success ends any run of failures on the email
+	var tokens TokenResponse
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := recordLoginAttempt(tx, email, ip, &existingUser.ID, models.LoginSucceeded, at); err != nil {
+			return err
+		}
+		if existingUser.LockedUntil != nil {
+			if err := tx.Model(&existingUser).Update("locked_until", nil).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		tokens, err = openSession(tx, c, existingUser)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tokenString)
+	c.JSON(http.StatusOK, tokens)
 
 }
 
@@ -163,7 +200,7 @@ func CreateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User Created"})
 }
 
-// DeleteUser soft-deletes a user (sets deleted_at timestamp).
+// DeleteUser soft-deletes a user (sets deleted_at timestamp) and revokes their sessions.
 // The user record is preserved for order audit trails but hidden from all queries.
 // The user's email becomes available for reuse after deletion.
 //
@@ -209,16 +246,24 @@ func DeleteUser(c *gin.Context) {
 		}
 	}
 
-	// Soft-delete user (sets deleted_at, preserves record for order audit trails)
-	if err := config.DB.Delete(&user).Error; err != nil {
+	// Soft-delete user (sets deleted_at, preserves record for order audit trails) and sign them out everywhere
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return revokeSessions(tx, user.ID, models.SessionUserDeleted, 0)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
+	middlewares.ForgetUser(user.ID)
+	middlewares.ForgetSessions(user.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 }
 
-// ToggleUserStatus flips the IsActive flag on a user account.
+// ToggleUserStatus flips the IsActive flag on a user account. Deactivating revokes the user's sessions.
 // Deactivated users cannot log in but their data and order history are preserved.
 // This is the preferred way to revoke access without losing audit trails.
 //
@@ -262,18 +307,147 @@ func ToggleUserStatus(c *gin.Context) {
 
 	user.IsActive = !user.IsActive
 
-	if err := config.DB.Save(&user).Error; err != nil {
+	// A deactivated user is signed out everywhere
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if user.IsActive {
+			return nil
+		}
+		return revokeSessions(tx, user.ID, models.SessionUserDeactivated, 0)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
 		return
 	}
+	middlewares.ForgetUser(user.ID)
+	middlewares.ForgetSessions(user.ID)
 
 	config.DB.Preload("Role").First(&user, id)
 
 	c.JSON(http.StatusOK, user)
 }
 
+// UpdateUserRole moves a user to another role. The new role's permissions apply from the user's
+// next request, without signing them out. The change is refused if no active user would be left
+// able to manage roles.
+//
+// @Summary Change a user's role
+// @Description Assign another role to a user; takes effect on their next request
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body object true "Role, e.g. {\"roles_id\": 2}"
+// @Success 200 {object} models.Users
+// @Failure 400 {object} map[string]string "Invalid data or role"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Nobody would be left to manage roles"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /users/{id}/role [patch]
+func UpdateUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var input struct {
+		RolesID uint `json:"roles_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		return
+	}
+
+	var role models.Roles
+	if err := config.DB.First(&role, input.RolesID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found"})
+		return
+	}
+
+	var user models.Users
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("roles_id", role.ID).Error; err != nil {
+			return err
+		}
+
+		managed, err := roleManagerLeft(tx)
+		if err != nil {
+			return err
+		}
+		if !managed {
+			return errLastRoleManager
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errors.Is(err, errLastRoleManager):
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot change role: no active user would be left able to manage roles"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	middlewares.ForgetUser(user.ID)
+
+	config.DB.Preload("Role").First(&user, id)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UnlockUser lifts the login lockout of a user after repeated failed logins, so that they can try
+// again at once. The failures recorded so far stop counting.
+//
+// @Summary Unlock a user's login
+// @Description Lift the lockout set after repeated failed logins
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.Users
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Security BearerAuth
+// @Router /users/{id}/unlock [patch]
+func UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var user models.Users
+	if err := config.DB.Preload("Role").First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("locked_until", nil).Error; err != nil {
+			return err
+		}
+		return recordLoginAttempt(tx, normalizeLoginEmail(user.Email), c.ClientIP(), &user.ID, models.LoginUnlocked, now())
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 // ChangePassword updates a user's password after verifying the current one.
-// Non-admin users can only change their own password. Admins can change any user's password.
+// Users can only change their own password, unless their role grants users:manage.
+// The user's other sessions are revoked; the session used to change one's own password stays open.
 // The new password must pass the same strength validation as during user creation.
 //
 // @Summary Change user password
@@ -297,10 +471,9 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Non-admin users can only change their own password
+	// Users without the users:manage permission can only change their own password
 	currentUserID := c.GetInt("userID")
-	role := c.GetString("userRole")
-	if role != "admin" && currentUserID != id {
+	if !middlewares.HasPermission(c, models.PermUsersManage) && currentUserID != id {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own password"})
 		return
 	}
@@ -339,16 +512,29 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	// Sign the user out of their other sessions; the one changing its own password stays open
+	keep := uint(0)
+	if currentUserID == id {
+		keep = currentSessionID(c)
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return revokeSessions(tx, user.ID, models.SessionPasswordChanged, keep)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
+	middlewares.ForgetSessions(user.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated"})
 }
 
 // ResetPassword allows an admin to reset any user's password to a temporary value.
 // The user should change this password on their next login via the ChangePassword endpoint.
+// All of the user's sessions are revoked.
 //
 // @Summary Reset user password (admin only)
 // @Description Admin resets a user's password and receives a temporary password
@@ -388,25 +574,55 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	// Whoever holds the old password is signed out everywhere
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return revokeSessions(tx, user.ID, models.SessionPasswordReset, 0)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
 		return
 	}
+	middlewares.ForgetSessions(user.ID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":        "Password reset successful",
-		"temp_password":  tempPassword,
+		"message":       "Password reset successful",
+		"temp_password": tempPassword,
 	})
 }
 
-// GetUsers returns all users with their associated role preloaded.
+// userListSpec lists the sort fields and filters accepted by GetUsers.
+var userListSpec = listSpec{
+	Sort: map[string]string{
+		"id":         "id",
+		"username":   "username",
+		"email":      "email",
+		"created_at": "created_at",
+	},
+	DefaultSort: "id ASC",
+	Filters: []listFilter{
+		{Param: "role_id", Column: "roles_id", Kind: filterID},
+		{Param: "is_active", Column: "is_active", Kind: filterBool},
+	},
+}
+
+// GetUsers returns a page of users with their associated role preloaded.
 // Password fields are excluded from the JSON response via the json:"-" tag on the model.
+// Filters: ?role_id=, ?is_active=true|false. Paging and sorting: ?limit=, ?offset=, ?sort=username.
 //
 // @Summary Get all users
-// @Description Retrieve a list of all users with their roles
+// @Description Retrieve a page of users with their roles
 // @Tags Users
 // @Produce json
-// @Success 200 {array} models.Users
+// @Param role_id query int false "Filter by role"
+// @Param is_active query bool false "Filter by active status"
+// @Param sort query string false "Sort fields: id, username, email, created_at (prefix with - for descending)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {object} Page{data=[]models.Users}
+// @Failure 400 {object} map[string]string "Invalid filter, sort or paging parameter"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Security BearerAuth
 // @Router /users [get]
@@ -414,12 +630,22 @@ func GetUsers(c *gin.Context) {
 
 	var users []models.Users
 
-	if err := config.DB.Preload("Role").Find(&users).Error; err != nil {
+	page, err := paginate(c, config.DB.Model(&models.Users{}), userListSpec, userPreloads, &users)
+	if err != nil {
+		if isListQueryError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, page)
+}
+
+// userPreloads loads the user's role.
+func userPreloads(db *gorm.DB) *gorm.DB {
+	return db.Preload("Role")
 }
 
 // GetUser returns a single user by ID with their role preloaded.
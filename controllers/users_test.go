@@ -23,10 +23,12 @@ func TestLogin_Success(t *testing.T) {
 	w := testutils.PerformRequest(r, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	// Response is a JWT string
-	var token string
-	json.Unmarshal(w.Body.Bytes(), &token)
-	assert.NotEmpty(t, token)
+	// Response holds a JWT access token and a refresh token
+	var tokens TokenResponse
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, "Bearer", tokens.TokenType)
 }
 
 func TestLogin_WrongPassword(t *testing.T) {
@@ -414,6 +416,47 @@ func TestToggleUserStatus_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestUpdateUserRole_Success(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	counter := testutils.SeedRole(db, "accueil")
+	testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+	user := testutils.SeedUser(db, "counter", "counter@test.com", "P@ssw0rd", counter.ID)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/users/:id/role", UpdateUserRole)
+
+	body := map[string]uint{"roles_id": admin.ID}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/role", body))
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := testutils.ParseResponse(w)
+	assert.Equal(t, float64(admin.ID), resp["roles_id"])
+	assert.Equal(t, "admin", resp["Role"].(map[string]interface{})["role_name"])
+
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/role", map[string]uint{"roles_id": 999}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = testutils.PerformRequest(r, testutils.JSONRequest("PATCH", "/users/999/role", body))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateUserRole_LastRoleManagerBlocked(t *testing.T) {
+	db := testutils.SetupTestDB()
+	admin := testutils.SeedRole(db, "admin")
+	counter := testutils.SeedRole(db, "accueil")
+	user := testutils.SeedUser(db, "admin", "admin@test.com", "P@ssw0rd", admin.ID)
+
+	r := testutils.SetupRouter()
+	r.PATCH("/users/:id/role", UpdateUserRole)
+
+	body := map[string]uint{"roles_id": counter.ID}
+	w := testutils.PerformRequest(r, testutils.JSONRequest("PATCH", testutils.IDParam("/users", user.ID)+"/role", body))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var stored models.Users
+	db.First(&stored, user.ID)
+	assert.Equal(t, admin.ID, stored.RolesID, "rolled back")
+}
+
 func TestChangePassword_Success(t *testing.T) {
 	db := testutils.SetupTestDB()
 	role := testutils.SeedRole(db, "admin")
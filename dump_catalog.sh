@@ -1,7 +1,7 @@
 #!/bin/bash
 # WacDo — Dump product catalog from local DB
 # Usage: bash dump_catalog.sh
-# Output: catalog_dump.sql
+# Output: catalog_dump.sql (raw rows: amounts are integer cents, restore into a database at the same schema version)
 
 set -e
 
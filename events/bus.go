@@ -0,0 +1,88 @@
+// Package events provides an in-process publish/subscribe bus used to push order updates
+// to connected screens (kitchen and counter) over Server-Sent Events.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Order event types published by the order controllers.
+const (
+	OrderCreated           = "order.created"
+	OrderUpdated           = "order.updated"
+	OrderStatusChanged     = "order.status_changed"
+	OrderCancelled         = "order.cancelled"
+	OrderItemStatusChanged = "order.item_status_changed"
+)
+
+// OrderEvent describes a change to an order. It carries the order's status before and after
+// the change so that subscribers can decide whether the order entered or left their view.
+type OrderEvent struct {
+	Type           string    `json:"type"` // order.created, order.updated, order.status_changed, order.cancelled, order.item_status_changed
+	OrderID        uint      `json:"order_id"`
+	Status         string    `json:"status"`                    // Status after the change
+	PreviousStatus string    `json:"previous_status,omitempty"` // Status before the change (empty for created)
+	ItemID         uint      `json:"item_id,omitempty"`         // Order item concerned (item events only)
+	ItemStatus     string    `json:"item_status,omitempty"`     // New status of the item (item events only)
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// Bus fans out published events to every current subscriber.
+// Publishing never blocks: a subscriber whose buffer is full misses the event
+// rather than stalling the request that published it.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[chan OrderEvent]struct{}
+}
+
+// NewBus creates an empty bus.
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan OrderEvent]struct{})}
+}
+
+// Orders is the application-wide bus for order events.
+var Orders = NewBus()
+
+// Subscribe registers a new subscriber with the given buffer size and returns its channel.
+// Callers must Unsubscribe when done.
+func (b *Bus) Subscribe(buffer int) chan OrderEvent {
+	ch := make(chan OrderEvent, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (b *Bus) Unsubscribe(ch chan OrderEvent) {
+	b.mu.Lock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+}
+
+// Publish delivers an event to every subscriber. OccurredAt is set if empty.
+func (b *Bus) Publish(event OrderEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of active subscribers.
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_PublishReachesEverySubscriber(t *testing.T) {
+	bus := NewBus()
+	a := bus.Subscribe(1)
+	b := bus.Subscribe(1)
+
+	bus.Publish(OrderEvent{Type: OrderCreated, OrderID: 7, Status: "pending"})
+
+	for _, ch := range []chan OrderEvent{a, b} {
+		event := <-ch
+		assert.Equal(t, uint(7), event.OrderID)
+		assert.False(t, event.OccurredAt.IsZero())
+	}
+}
+
+func TestBus_FullSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewBus()
+	ch := bus.Subscribe(1)
+
+	bus.Publish(OrderEvent{OrderID: 1})
+	bus.Publish(OrderEvent{OrderID: 2}) // dropped, buffer full
+
+	assert.Equal(t, uint(1), (<-ch).OrderID)
+	assert.Len(t, ch, 0)
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+	ch := bus.Subscribe(1)
+	assert.Equal(t, 1, bus.Subscribers())
+
+	bus.Unsubscribe(ch)
+	bus.Unsubscribe(ch) // second call is a no-op
+
+	_, open := <-ch
+	assert.False(t, open)
+	assert.Equal(t, 0, bus.Subscribers())
+
+	bus.Publish(OrderEvent{OrderID: 1})
+}
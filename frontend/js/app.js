@@ -3,12 +3,54 @@ const App = {
   API: window.WACDO_API_BASE || (location.protocol === 'file:' ? 'http://localhost:8000' : location.origin),
 
   // --- Auth ---
+  // Login and refresh return {access_token, refresh_token}; the access token lasts 15 minutes
+  // and is renewed with the single-use refresh token when the API answers 401.
   getToken()  { return localStorage.getItem('wacdo_token'); },
-  setToken(t) { localStorage.setItem('wacdo_token', t); },
-  clearToken(){ localStorage.removeItem('wacdo_token'); },
+  setTokens(t) {
+    localStorage.setItem('wacdo_token', t.access_token);
+    localStorage.setItem('wacdo_refresh_token', t.refresh_token);
+  },
+  clearToken(){
+    localStorage.removeItem('wacdo_token');
+    localStorage.removeItem('wacdo_refresh_token');
+  },
   isLoggedIn(){ return !!this.getToken(); },
 
-  // Decode JWT payload to read claims (UserID, RoleName)
+  // Exchange the refresh token for a new pair. Concurrent callers share one request,
+  // since a refresh token can only be used once.
+  refreshing: null,
+  refreshTokens() {
+    if (!this.refreshing) {
+      const refreshToken = localStorage.getItem('wacdo_refresh_token');
+      this.refreshing = (async () => {
+        if (!refreshToken) return false;
+        try {
+          const res = await fetch(this.API + '/users/refresh', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+          });
+          if (!res.ok) return false;
+          this.setTokens(await res.json());
+          return true;
+        } catch { return false; }
+      })().finally(() => { this.refreshing = null; });
+    }
+    return this.refreshing;
+  },
+
+  // Revoke the session on the server, then forget the tokens
+  async logout() {
+    this.closeStream();
+    const token = this.getToken();
+    if (token) {
+      fetch(this.API + '/users/logout', { method: 'POST', headers: { 'Authorization': 'Bearer ' + token } }).catch(() => {});
+    }
+    this.clearToken();
+    this.navigate('login');
+  },
+
+  // Decode JWT payload to read claims (UserID, RoleName, Permissions)
   getTokenPayload() {
     const token = this.getToken();
     if (!token) return null;
@@ -21,9 +63,16 @@ const App = {
     const p = this.getTokenPayload();
     return p ? (p.RoleName || '') : '';
   },
+  // Whether the user's role grants a permission, directly, through "*" or a prefix such as "orders:*".
+  // Only decides what to show: the API checks permissions itself.
+  can(permission) {
+    const p = this.getTokenPayload();
+    return ((p && p.Permissions) || []).some(g =>
+      g === '*' || g === permission || (g.endsWith('*') && permission.startsWith(g.slice(0, -1))));
+  },
 
   // --- API helper ---
-  async api(path, opts = {}) {
+  async api(path, opts = {}, retried = false) {
     const url = this.API + path;
     const headers = { 'Content-Type': 'application/json' };
     const token = this.getToken();
@@ -35,6 +84,10 @@ const App = {
       body: opts.body ? JSON.stringify(opts.body) : undefined,
     });
 
+    // An expired access token is renewed once; a revoked session sends back to the login page
+    if (res.status === 401 && token && !retried && await this.refreshTokens()) {
+      return this.api(path, opts, true);
+    }
     if (res.status === 401) {
       this.clearToken();
       this.toast('Session expired. Please log in again.', 'error');
@@ -53,6 +106,57 @@ const App = {
     return data;
   },
 
+  // Fetch the rows of a paginated list endpoint ({data, total, ...}), up to the server's page maximum
+  async list(path, params = {}) {
+    const qs = new URLSearchParams({ limit: 200, ...params }).toString();
+    const page = await this.api(path + (path.includes('?') ? '&' : '?') + qs);
+    return page && Array.isArray(page.data) ? page.data : [];
+  },
+
+  // --- Server-Sent Events ---
+  // EventSource cannot send the Authorization header, so the stream is read with fetch.
+  // Only one stream is open at a time; it is closed whenever the page changes.
+  activeStream: null,
+  stream(path, onEvent) {
+    this.closeStream();
+    const ctrl = new AbortController();
+    this.activeStream = ctrl;
+
+    const connect = async () => {
+      try {
+        const res = await fetch(this.API + path, {
+          headers: { 'Authorization': 'Bearer ' + this.getToken(), 'Accept': 'text/event-stream' },
+          signal: ctrl.signal,
+        });
+        if (res.status === 401 && !(await this.refreshTokens())) { this.logout(); return; }
+        if (!res.ok || !res.body) throw new Error('Stream unavailable');
+
+        const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+        let buffer = '';
+        for (;;) {
+          const { value, done } = await reader.read();
+          if (done) break;
+          buffer += value;
+          let sep;
+          while ((sep = buffer.indexOf('\n\n')) !== -1) {
+            const block = buffer.slice(0, sep);
+            buffer = buffer.slice(sep + 2);
+            const data = block.split('\n').filter(l => l.startsWith('data:')).map(l => l.slice(5)).join('\n');
+            if (!data) continue; // comment / heartbeat
+            try { onEvent(JSON.parse(data)); } catch {}
+          }
+        }
+      } catch {}
+      // Reconnect unless the stream was closed on purpose
+      if (!ctrl.signal.aborted) setTimeout(() => { if (!ctrl.signal.aborted) connect(); }, 3000);
+    };
+    connect();
+  },
+  closeStream() {
+    if (this.activeStream) this.activeStream.abort();
+    this.activeStream = null;
+  },
+
   // --- Toast ---
   toast(msg, type = 'info') {
     const el = document.createElement('div');
@@ -82,6 +186,7 @@ const App = {
 
   async route() {
     const hash = (window.location.hash || '#login').slice(1).split('/')[0];
+    this.closeStream();
 
     if (!this.isLoggedIn() && hash !== 'login') {
       window.location.hash = 'login';
@@ -110,16 +215,16 @@ const App = {
       const role = this.getRole();
       document.getElementById('user-label').textContent = role ? role : '';
 
-      // Hide sidebar links based on role
+      // Hide sidebar links the role's permissions do not cover (other pages are open to everyone)
       const access = {
-        admin:       ['dashboard', 'products', 'menus', 'orders', 'customers', 'users', 'privacy'],
-        accueil:     ['dashboard', 'orders', 'customers', 'privacy'],
-        preparation: ['dashboard', 'orders', 'privacy'],
+        products:  'catalog:write',
+        menus:     'catalog:write',
+        customers: 'customers:manage',
+        users:     'users:manage',
       };
-      const allowed = access[role] || [];
       document.querySelectorAll('#sidebar-nav a').forEach(a => {
-        const page = a.dataset.page;
-        a.style.display = (allowed.length === 0 || allowed.includes(page)) ? '' : 'none';
+        const needed = access[a.dataset.page];
+        a.style.display = (!needed || this.can(needed)) ? '' : 'none';
       });
     }
 
@@ -153,10 +258,7 @@ const App = {
     });
 
     // Logout
-    document.getElementById('logout-btn').addEventListener('click', () => {
-      this.clearToken();
-      this.navigate('login');
-    });
+    document.getElementById('logout-btn').addEventListener('click', () => this.logout());
 
     // Sidebar toggle (mobile)
     document.getElementById('sidebar-toggle').addEventListener('click', () => {
@@ -212,5 +314,23 @@ function availBadge(v) {
     : '<span class="badge badge-unavailable">unavailable</span>';
 }
 
+/* ===== Helper: automatic unavailability badge ===== */
+function stockBadge(reason) {
+  return reason === 'out_of_stock'
+    ? ' <span class="badge badge-unavailable">out of stock</span>'
+    : '';
+}
+
+// Shown when the stored flag says available but an availability schedule is closed right now
+const SCHEDULE_REASONS = {
+  outside_schedule: 'outside its hours',
+  category_outside_schedule: 'category outside its hours',
+  component_outside_schedule: 'a product is outside its hours',
+};
+function scheduleBadge(availability) {
+  const label = availability && SCHEDULE_REASONS[availability.reason];
+  return label ? ` <span class="badge badge-unavailable">${label}</span>` : '';
+}
+
 /* ===== Boot ===== */
 document.addEventListener('DOMContentLoaded', () => App.init());
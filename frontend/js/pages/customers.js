@@ -6,7 +6,7 @@ App.registerPage('customers', async () => {
 
   async function loadCustomers() {
     try {
-      const data = await App.api('/customers/');
+      const data = await App.list('/customers/');
       allCustomers = Array.isArray(data) ? data : [];
       renderList(allCustomers);
     } catch (err) { render(`<div class="empty-msg">${err.message}</div>`); }
@@ -115,8 +115,7 @@ App.registerPage('customers', async () => {
 
   window.viewCustOrders = async function(id, name) {
     try {
-      const orders = await App.api('/customers/' + id + '/orders');
-      const list = Array.isArray(orders) ? orders : [];
+      const list = await App.list('/customers/' + id + '/orders', { sort: '-created_at' });
       App.modal('Orders for ' + name, list.length === 0 ? '<p class="text-muted">No orders</p>' : `
         <table class="sub-table">
           <thead><tr><th>#</th><th>Type</th><th>Status</th><th>Total</th><th>Date</th></tr></thead>
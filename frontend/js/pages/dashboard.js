@@ -1,12 +1,14 @@
 App.registerPage('dashboard', async () => {
   render('<div class="loading">Loading dashboard...</div>');
 
-  const role = App.getRole();
+  // The dashboard follows the user's permissions: managers get the overview, the kitchen its queue,
+  // everyone else the counter view
+  const view = App.can('users:manage') ? 'admin' : App.can('kitchen:view') ? 'preparation' : 'accueil';
 
   try {
-    if (role === 'preparation') {
+    if (view === 'preparation') {
       await renderPreparationDashboard();
-    } else if (role === 'accueil') {
+    } else if (view === 'accueil') {
       await renderAccueilDashboard();
     } else {
       await renderAdminDashboard();
@@ -15,29 +17,40 @@ App.registerPage('dashboard', async () => {
     render(`<div class="empty-msg">Error loading dashboard: ${esc(err.message)}</div>`);
   }
 
+  // Live updates: the server only sends events relevant to the user's permissions
+  let reloadTimer = null;
+  App.stream('/orders/stream', () => {
+    clearTimeout(reloadTimer);
+    reloadTimer = setTimeout(() => {
+      if (view === 'preparation') renderPreparationDashboard();
+      else if (view === 'accueil') renderAccueilDashboard();
+      else renderAdminDashboard();
+    }, 300);
+  });
+
   // ===== ADMIN DASHBOARD =====
   async function renderAdminDashboard() {
-    const [products, categories, menus, customers, orders] = await Promise.all([
-      App.api('/products/'),
+    // List endpoints are paginated: counts come from the envelope total, not the rows
+    const total = (path, filter = '') => App.api(path + '?limit=1' + filter).then(p => (p && p.total) || 0);
+    const [prodCount, categories, menuCount, custCount, ordCount, pendingCount, preparingCount, recentPage] = await Promise.all([
+      total('/products/'),
       App.api('/categories/'),
-      App.api('/menus/'),
-      App.api('/customers/'),
-      App.api('/orders/'),
+      total('/menus/'),
+      total('/customers/'),
+      total('/orders/'),
+      total('/orders/', '&status=pending'),
+      total('/orders/', '&status=preparing'),
+      App.api('/orders/?limit=5'),
     ]);
 
-    const prodList = Array.isArray(products) ? products : [];
-    const catList  = Array.isArray(categories) ? categories : [];
-    const menuList = Array.isArray(menus) ? menus : [];
-    const custList = Array.isArray(customers) ? customers : [];
-    const ordList  = Array.isArray(orders) ? orders : [];
-
-    const openOrders = ordList.filter(o => o.status === 'pending' || o.status === 'preparing');
-    const recent = ordList.slice(0, 5);
+    const catList = Array.isArray(categories) ? categories : [];
+    const openOrders = pendingCount + preparingCount;
+    const recent = recentPage && Array.isArray(recentPage.data) ? recentPage.data : [];
 
     render(`
       <div class="stat-grid">
         <div class="stat-card">
-          <div class="stat-value">${prodList.length}</div>
+          <div class="stat-value">${prodCount}</div>
           <div class="stat-label">Products</div>
         </div>
         <div class="stat-card">
@@ -45,19 +58,19 @@ App.registerPage('dashboard', async () => {
           <div class="stat-label">Categories</div>
         </div>
         <div class="stat-card">
-          <div class="stat-value">${menuList.length}</div>
+          <div class="stat-value">${menuCount}</div>
           <div class="stat-label">Menus</div>
         </div>
         <div class="stat-card">
-          <div class="stat-value">${custList.length}</div>
+          <div class="stat-value">${custCount}</div>
           <div class="stat-label">Customers</div>
         </div>
         <div class="stat-card">
-          <div class="stat-value">${openOrders.length}</div>
+          <div class="stat-value">${openOrders}</div>
           <div class="stat-label">Open Orders</div>
         </div>
         <div class="stat-card">
-          <div class="stat-value">${ordList.length}</div>
+          <div class="stat-value">${ordCount}</div>
           <div class="stat-label">Total Orders</div>
         </div>
       </div>
@@ -88,21 +101,17 @@ App.registerPage('dashboard', async () => {
   }
 
   // ===== PREPARATION DASHBOARD =====
-  async function renderPreparationDashboard() {
-    const orders = await App.api('/orders/');
-    const ordList = Array.isArray(orders) ? orders : [];
+  let prepProducts = null;
 
-    const prepOrders = ordList
-      .filter(o => o.status === 'pending' || o.status === 'preparing')
-      .sort((a, b) => {
-        if (!a.scheduled_time && !b.scheduled_time) return 0;
-        if (!a.scheduled_time) return 1;
-        if (!b.scheduled_time) return -1;
-        return new Date(a.scheduled_time) - new Date(b.scheduled_time);
-      });
+  async function renderPreparationDashboard() {
+    // The server returns the queue already in priority order, with timing flags
+    const queue = await App.api('/kitchen/queue');
+    const entries = Array.isArray(queue) ? queue : [];
+    // Product names, to tell which part of a menu each option goes with
+    if (!prepProducts) prepProducts = await App.list('/products/').catch(() => []);
 
-    const pending = prepOrders.filter(o => o.status === 'pending');
-    const preparing = prepOrders.filter(o => o.status === 'preparing');
+    const pending = entries.filter(e => e.order.status === 'pending');
+    const preparing = entries.filter(e => e.order.status === 'preparing');
 
     render(`
       <div class="stat-grid">
@@ -121,35 +130,60 @@ App.registerPage('dashboard', async () => {
           <div class="kanban-col-header">Pending <span class="count">${pending.length}</span></div>
           <div class="kanban-col-body">
             ${pending.length === 0 ? '<p class="text-muted" style="text-align:center;font-size:12px;">No orders</p>' :
-              pending.map(o => renderPrepCard(o)).join('')}
+              pending.map(e => renderPrepCard(e)).join('')}
           </div>
         </div>
         <div class="kanban-col">
           <div class="kanban-col-header">Preparing <span class="count">${preparing.length}</span></div>
           <div class="kanban-col-body">
             ${preparing.length === 0 ? '<p class="text-muted" style="text-align:center;font-size:12px;">No orders</p>' :
-              preparing.map(o => renderPrepCard(o)).join('')}
+              preparing.map(e => renderPrepCard(e)).join('')}
           </div>
         </div>
       </div>
     `);
   }
 
-  function renderPrepCard(o) {
+  function renderPrepCard(entry) {
+    const o = entry.order;
+    const flag = entry.late ? ' <span class="badge badge-late">late</span>'
+      : entry.at_risk ? ' <span class="badge badge-at-risk">at risk</span>' : '';
+    // One line per item with its own progress button, so stations can finish items independently
     const items = (o.order_items || []).map(it => {
       const name = it.product ? it.product.name : (it.menu ? it.menu.name : 'Item');
-      return `${it.quantity}x ${esc(name)}`;
-    }).join(', ');
+      const btn = it.status === 'done' ? '<span class="text-muted">done</span>'
+        : it.status === 'preparing'
+          ? `<button class="btn btn-sm btn-success" onclick="updateItemStatus(${o.id},${it.id},'done')">Done</button>`
+          : `<button class="btn btn-sm btn-outline" onclick="updateItemStatus(${o.id},${it.id},'preparing')">Start</button>`;
+      const componentProduct = menuProductId => {
+        const mp = it.menu ? (it.menu.menu_products || []).find(x => x.id === menuProductId) : null;
+        return mp ? (prepProducts || []).find(p => p.id === mp.product_id) : null;
+      };
+      // Replaced menu components first ("Fries → Salad"), then options of the product actually served
+      const swaps = (it.substitutions || []).map(sub => {
+        const prod = componentProduct(sub.menu_product_id);
+        return esc(prod ? prod.name : '?') + ' → ' + esc(sub.product ? sub.product.name : 'Product #' + sub.product_id);
+      });
+      const opts = swaps.concat((it.order_item_options || []).map(opt => {
+        const value = opt.option_value ? esc(opt.option_value.value) : '';
+        if (!opt.menu_product_id) return value;
+        const sub = (it.substitutions || []).find(x => x.menu_product_id === opt.menu_product_id);
+        const prod = sub ? sub.product : componentProduct(opt.menu_product_id);
+        return prod && value ? esc(prod.name) + ': ' + value : value;
+      })).filter(Boolean);
+      const optsLine = opts.length ? `<div style="padding-left:12px;">${opts.join(', ')}</div>` : '';
+      return `<div class="inline-flex" style="justify-content:space-between;">${it.quantity}x ${esc(name)} ${btn}</div>${optsLine}`;
+    }).join('');
     const notes = o.notes ? esc(o.notes.length > 60 ? o.notes.slice(0, 60) + '...' : o.notes) : '';
     const action = o.status === 'pending'
       ? `<button class="btn btn-sm btn-info" onclick="updateOrderStatus(${o.id},'preparing')">Start</button>`
       : `<button class="btn btn-sm btn-success" onclick="updateOrderStatus(${o.id},'prepared')">Ready</button>`;
 
     return `<div class="kanban-card">
-      <div class="order-id">#${o.id}</div>
-      <div class="order-meta">${esc(o.order_type)} | ${o.order_items ? o.order_items.length : 0} items</div>
+      <div class="order-id">#${entry.position} · #${o.id}${flag}</div>
+      <div class="order-meta">${esc(o.order_type)} | ${o.order_items ? o.order_items.length : 0} items | ~${entry.prep_minutes} min</div>
+      <div class="order-meta text-muted" style="font-size:11px;">Due: ${fmtDate(entry.due_at)}</div>
       ${items ? `<div class="order-meta text-muted" style="font-size:11px;">${items}</div>` : ''}
-      ${o.scheduled_time ? `<div class="order-meta text-muted" style="font-size:11px;">Scheduled: ${fmtDate(o.scheduled_time)}</div>` : ''}
       ${notes ? `<div class="order-meta text-muted" style="font-size:11px;font-style:italic;">${notes}</div>` : ''}
       <div class="order-actions">${action}</div>
     </div>`;
@@ -158,8 +192,8 @@ App.registerPage('dashboard', async () => {
   // ===== ACCUEIL DASHBOARD =====
   async function renderAccueilDashboard() {
     const [orders, customers] = await Promise.all([
-      App.api('/orders/'),
-      App.api('/customers/'),
+      App.list('/orders/'),
+      App.list('/customers/'),
     ]);
     const ordList = Array.isArray(orders) ? orders : [];
     const custList = Array.isArray(customers) ? customers : [];
@@ -219,6 +253,13 @@ App.registerPage('dashboard', async () => {
     `);
   }
 
+  window.updateItemStatus = async function(orderId, itemId, status) {
+    try {
+      await App.api('/orders/' + orderId + '/items/' + itemId + '/status', { method: 'PATCH', body: { status } });
+      App.route();
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
   // Global handlers for dashboard action buttons
   window.updateOrderStatus = async function(id, status) {
     try {
@@ -31,16 +31,15 @@ App.registerPage('login', async () => {
     btn.textContent = 'Signing in...';
 
     try {
-      const token = await App.api('/users/login', {
+      const tokens = await App.api('/users/login', {
         method: 'POST',
         body: {
           email: document.getElementById('login-email').value,
           password: document.getElementById('login-pass').value,
         }
       });
-      // Backend returns raw JSON string: c.JSON(200, tokenString)
-      // await res.json() gives the string directly
-      App.setToken(token);
+      // Backend returns {access_token, refresh_token, token_type, expires_in}
+      App.setTokens(tokens);
       App.toast('Login successful', 'success');
       App.navigate('dashboard');
     } catch (err) {
@@ -2,13 +2,13 @@ App.registerPage('menus', async () => {
   render('<div class="loading">Loading menus...</div>');
 
   let allProducts = [];
-  try { allProducts = await App.api('/products/'); if (!Array.isArray(allProducts)) allProducts = []; } catch {}
+  try { allProducts = await App.list('/products/'); if (!Array.isArray(allProducts)) allProducts = []; } catch {}
 
   await loadMenus();
 
   async function loadMenus() {
     try {
-      const menus = await App.api('/menus/');
+      const menus = await App.list('/menus/');
       const list = Array.isArray(menus) ? menus : [];
 
       render(`
@@ -29,7 +29,7 @@ App.registerPage('menus', async () => {
                     <label class="toggle">
                       <input type="checkbox" ${m.is_available ? 'checked' : ''} onchange="toggleMenuAvail(${m.id})">
                       <span class="toggle-slider"></span>
-                    </label>
+                    </label>${stockBadge(m.unavailable_reason)}${scheduleBadge(m.effective_availability)}
                   </td>
                   <td class="inline-flex">
                     <button class="btn btn-sm btn-info" onclick="expandMenu(${m.id}, this)">Products</button>
@@ -131,7 +131,7 @@ App.registerPage('menus', async () => {
         </div>
         ${list.length === 0 ? '<p class="text-muted">No products in this menu</p>' : `
           <table class="sub-table">
-            <thead><tr><th>Product</th><th>Qty</th><th>Optional</th><th>Actions</th></tr></thead>
+            <thead><tr><th>Product</th><th>Qty</th><th>Optional</th><th>Substitutes</th><th>Actions</th></tr></thead>
             <tbody>
               ${list.map(mp => {
                 const prod = allProducts.find(p => p.id === mp.product_id);
@@ -140,6 +140,7 @@ App.registerPage('menus', async () => {
                 <td>${prodLabel}</td>
                 <td>${mp.quantity}</td>
                 <td>${mp.is_optional ? 'Yes' : 'No'}</td>
+                <td>${mp.is_optional ? substitutesHTML(mp, menuId) : '-'}</td>
                 <td><button class="btn btn-sm btn-danger" onclick="removeMenuProduct(${mp.id}, ${menuId})">Remove</button></td>
               </tr>`;
               }).join('')}
@@ -149,6 +150,47 @@ App.registerPage('menus', async () => {
     } catch (err) { el.innerHTML = `<p class="text-muted">${err.message}</p>`; }
   }
 
+  // Optional components can be replaced at order entry by one of their substitutes
+  function substitutesHTML(mp, menuId) {
+    const subs = (mp.substitutes || []).map(sub => {
+      const prod = allProducts.find(p => p.id === sub.product_id);
+      const delta = sub.price_delta ? ' (' + (sub.price_delta > 0 ? '+' : '') + fmtPrice(sub.price_delta) + ')' : '';
+      return `<div class="inline-flex">${prod ? prod.name : 'Product #' + sub.product_id}${delta}
+        <button class="btn btn-sm btn-danger" onclick="removeMenuSubstitute(${sub.id}, ${menuId})">✕</button></div>`;
+    }).join('');
+    return `${subs}
+      <div class="inline-flex">
+        <select id="sub-prod-${mp.id}">
+          <option value="">Add substitute...</option>
+          ${allProducts.filter(p => p.id !== mp.product_id).map(p => `<option value="${p.id}">${p.name}</option>`).join('')}
+        </select>
+        <input type="number" id="sub-delta-${mp.id}" value="0" step="0.01" style="width:60px;padding:4px;background:var(--bg-input);border:1px solid var(--border);border-radius:4px;color:var(--text);" placeholder="± €">
+        <button class="btn btn-sm" onclick="addMenuSubstitute(${mp.id}, ${menuId})">Add</button>
+      </div>`;
+  }
+
+  window.addMenuSubstitute = async function(mpId, menuId) {
+    const prodId = document.getElementById('sub-prod-' + mpId).value;
+    const delta = document.getElementById('sub-delta-' + mpId).value;
+    if (!prodId) return App.toast('Select a product', 'error');
+    try {
+      await App.api('/menus/products/' + mpId + '/substitutes', {
+        method: 'POST',
+        body: { product_id: Number(prodId), price_delta: Number(delta) || 0 }
+      });
+      App.toast('Substitute added', 'success');
+      loadMenuProducts(menuId);
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
+  window.removeMenuSubstitute = async function(subId, menuId) {
+    try {
+      await App.api('/menus/substitutes/' + subId, { method: 'DELETE' });
+      App.toast('Removed', 'success');
+      loadMenuProducts(menuId);
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
   window.addMenuProduct = async function(menuId) {
     const prodId = document.getElementById('mp-prod-' + menuId).value;
     const qty = document.getElementById('mp-qty-' + menuId).value;
@@ -2,17 +2,24 @@ App.registerPage('orders', async () => {
   render('<div class="loading">Loading orders...</div>');
 
   let allProducts = [], allMenus = [], allCustomers = [], allCategories = [], allOptions = {};
+  let workflow = { states: [], transitions: [] };
 
   try {
-    [allProducts, allMenus, allCustomers, allCategories] = await Promise.all([
-      App.api('/products/').then(r => Array.isArray(r) ? r : []),
-      App.api('/menus/').then(r => Array.isArray(r) ? r : []),
-      App.api('/customers/').then(r => Array.isArray(r) ? r : []),
+    [allProducts, allMenus, allCustomers, allCategories, workflow] = await Promise.all([
+      App.list('/products/').then(r => Array.isArray(r) ? r : []),
+      App.list('/menus/').then(r => Array.isArray(r) ? r : []),
+      App.list('/customers/').then(r => Array.isArray(r) ? r : []),
       App.api('/categories/').then(r => Array.isArray(r) ? r : []),
+      App.api('/orders/workflow'),
     ]);
   } catch {}
 
-  const STATUSES = ['pending', 'preparing', 'prepared', 'delivered', 'cancelled'];
+  // Products and menus that cannot be ordered now (switched off, out of stock or outside their schedule) are not offered
+  const orderable = x => x.effective_availability ? x.effective_availability.available : x.is_available;
+
+  // Statuses and actions come from the server-side order workflow
+  const STATUSES = (workflow.states || []).map(s => s.name);
+  const ACTION_LABELS = { preparing: 'Prepare', prepared: 'Ready', delivered: 'Deliver', cancelled: 'Cancel' };
 
   render(`
     <div class="toolbar">
@@ -36,12 +43,22 @@ App.registerPage('orders', async () => {
 
   loadOrders('');
 
+  // Live updates: reload the current view whenever an order changes
+  let reloadTimer = null;
+  App.stream('/orders/stream', () => {
+    clearTimeout(reloadTimer);
+    reloadTimer = setTimeout(() => {
+      const active = document.querySelector('.toolbar .tab-btn.active');
+      if (active) loadOrders(active.dataset.filter);
+    }, 300);
+  });
+
   async function loadOrders(statusFilter) {
     const el = document.getElementById('orders-view');
     el.innerHTML = '<div class="loading">Loading...</div>';
     try {
       const url = statusFilter ? '/orders/?status=' + statusFilter : '/orders/';
-      const orders = await App.api(url);
+      const orders = await App.list(url);
       let list = Array.isArray(orders) ? orders : [];
 
       // Sort by scheduled_time ASC (nulls last) for preparation-relevant views
@@ -105,14 +122,19 @@ App.registerPage('orders', async () => {
 
   function orderActionButtons(o) {
     const btns = [];
-    if (o.status === 'pending') {
-      btns.push(`<button class="btn btn-sm btn-info" onclick="updateOrderStatus(${o.id},'preparing')">Prepare</button>`);
-      btns.push(`<button class="btn btn-sm btn-danger" onclick="cancelOrder(${o.id})">Cancel</button>`);
-    } else if (o.status === 'preparing') {
-      btns.push(`<button class="btn btn-sm btn-success" onclick="updateOrderStatus(${o.id},'prepared')">Ready</button>`);
-    } else if (o.status === 'prepared') {
-      btns.push(`<button class="btn btn-sm btn-success" onclick="updateOrderStatus(${o.id},'delivered')">Deliver</button>`);
-    }
+    // Only offer the transitions the user's permissions allow
+    (workflow.transitions || [])
+      .filter(t => t.from === o.status && App.can(t.permission))
+      .forEach(t => {
+        const state = workflow.states.find(s => s.name === t.to);
+        const label = ACTION_LABELS[t.to] || (state ? state.label : t.to);
+        if (t.to === 'cancelled') {
+          btns.push(`<button class="btn btn-sm btn-danger" onclick="cancelOrder(${o.id})">${esc(label)}</button>`);
+        } else {
+          const style = t.to === 'preparing' ? 'btn-info' : 'btn-success';
+          btns.push(`<button class="btn btn-sm ${style}" onclick="updateOrderStatus(${o.id},'${esc(t.to)}')">${esc(label)}</button>`);
+        }
+      });
     btns.push(`<button class="btn btn-sm btn-outline" onclick="viewOrderDetail(${o.id})">View</button>`);
     return btns.join('');
   }
@@ -138,20 +160,26 @@ App.registerPage('orders', async () => {
 
   window.viewOrderDetail = async function(id) {
     try {
-      const o = await App.api('/orders/' + id);
+      const [o, history] = await Promise.all([
+        App.api('/orders/' + id),
+        App.api('/orders/' + id + '/history'),
+      ]);
+      // Pending orders can still be corrected at the counter
+      const editable = o.status === 'pending' && App.can('orders:create');
       App.modal('Order #' + o.id, `
         <div class="mb-16">
-          <p><strong>Type:</strong> ${esc(o.order_type)}</p>
+          <p><strong>Type:</strong> ${esc(o.order_type)} (${o.service_mode === 'takeaway' ? 'takeaway' : 'on site'})</p>
           <p><strong>Status:</strong> ${statusBadge(o.status)}</p>
           <p><strong>Customer:</strong> ${o.customer ? esc(o.customer.name) : 'Walk-in'}</p>
           <p><strong>Notes:</strong> ${esc(o.notes) || '-'}</p>
           <p><strong>Scheduled:</strong> ${fmtDate(o.scheduled_time)}</p>
+          ${(o.discounts || []).map(d => `<p><strong>${esc(d.name)}:</strong> −${fmtPrice(d.amount)}</p>`).join('')}
           <p><strong>Total:</strong> <span class="text-accent">${fmtPrice(o.total_price)}</span></p>
           <p><strong>Created:</strong> ${fmtDate(o.created_at)}</p>
         </div>
         <div class="section-title">Items</div>
         <table class="sub-table">
-          <thead><tr><th>Item</th><th>Qty</th><th>Unit Price</th><th>Total</th></tr></thead>
+          <thead><tr><th>Item</th><th>Qty</th><th>Status</th><th>Unit Price</th><th>Total</th>${editable ? '<th></th>' : ''}</tr></thead>
           <tbody>
             ${(o.order_items || []).map(it => {
               let itemName;
@@ -162,25 +190,92 @@ App.registerPage('orders', async () => {
                 const menu = allMenus.find(m => m.id === it.menu_id);
                 itemName = menu ? menu.name : 'Menu #' + it.menu_id;
               }
-              const opts = (it.order_item_options || []).map(o => o.option_value ? o.option_value.value : '').filter(Boolean);
+              const menu = it.menu_id ? allMenus.find(m => m.id === it.menu_id) : null;
+              const componentName = menuProductId => {
+                const sub = (it.substitutions || []).find(s => s.menu_product_id === menuProductId);
+                if (sub) return sub.product ? sub.product.name : '';
+                const mp = menu ? (menu.menu_products || []).find(x => x.id === menuProductId) : null;
+                const prod = mp ? allProducts.find(p => p.id === mp.product_id) : null;
+                return prod ? prod.name : '';
+              };
+              // Replaced components first, e.g. "Fries → Salad"
+              const swaps = (it.substitutions || []).map(s => {
+                const mp = menu ? (menu.menu_products || []).find(x => x.id === s.menu_product_id) : null;
+                const prod = mp ? allProducts.find(p => p.id === mp.product_id) : null;
+                return esc(prod ? prod.name : '?') + ' → ' + esc(s.product ? s.product.name : 'Product #' + s.product_id);
+              });
+              const opts = swaps.concat((it.order_item_options || []).map(o => {
+                const value = o.option_value ? esc(o.option_value.value) : '';
+                // Menu options are shown with the component they go with, e.g. "Coke: Large"
+                const name = o.menu_product_id ? componentName(o.menu_product_id) : '';
+                return name && value ? esc(name) + ': ' + value : value;
+              })).filter(Boolean);
               const optsStr = opts.length ? '<div class="text-muted" style="font-size:11px;">' + opts.join(', ') + '</div>' : '';
               return `<tr>
               <td>${itemName}${optsStr}</td>
               <td>${it.quantity}</td>
+              <td>${esc(it.status)}</td>
               <td>${fmtPrice(it.unit_price)}</td>
               <td>${fmtPrice(it.item_total)}</td>
+              ${editable ? `<td>
+                <button class="btn btn-sm btn-outline" onclick="changeOrderItemQty(${o.id},${it.id},${it.quantity - 1})" ${it.quantity <= 1 ? 'disabled' : ''}>−</button>
+                <button class="btn btn-sm btn-outline" onclick="changeOrderItemQty(${o.id},${it.id},${it.quantity + 1})">+</button>
+                <button class="btn btn-sm btn-danger" onclick="removeOrderLine(${o.id},${it.id})" ${(o.order_items || []).length <= 1 ? 'disabled' : ''}>✕</button>
+              </td>` : ''}
             </tr>`;
             }).join('')}
           </tbody>
         </table>
+        ${(o.tax_lines || []).length ? `
+        <div class="section-title">VAT</div>
+        <table class="sub-table">
+          <thead><tr><th>Rate</th><th>Net</th><th>VAT</th><th>Total</th></tr></thead>
+          <tbody>
+            ${o.tax_lines.map(t => `<tr>
+              <td>${t.rate / 100} %</td>
+              <td>${fmtPrice(t.net)}</td>
+              <td>${fmtPrice(t.tax)}</td>
+              <td>${fmtPrice(t.gross)}</td>
+            </tr>`).join('')}
+          </tbody>
+        </table>` : ''}
+        <div class="section-title">History</div>
+        <table class="sub-table">
+          <thead><tr><th>When</th><th>Status</th><th>By</th></tr></thead>
+          <tbody>
+            ${(history || []).map(h => `<tr>
+              <td>${fmtDate(h.created_at)}</td>
+              <td>${h.from_status ? esc(h.from_status) + ' → ' : ''}${statusBadge(h.to_status)}</td>
+              <td>${h.user ? esc(h.user.username) : '-'}</td>
+            </tr>`).join('')}
+          </tbody>
+        </table>
       `);
     } catch (err) { App.toast(err.message, 'error'); }
   };
 
+  window.changeOrderItemQty = async function(orderId, itemId, quantity) {
+    try {
+      await App.api('/orders/' + orderId + '/items/' + itemId, { method: 'PATCH', body: { quantity } });
+      viewOrderDetail(orderId);
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
+  window.removeOrderLine = async function(orderId, itemId) {
+    if (!confirm('Remove this item from the order?')) return;
+    try {
+      await App.api('/orders/' + orderId + '/items/' + itemId, { method: 'DELETE' });
+      App.toast('Item removed', 'success');
+      viewOrderDetail(orderId);
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
   // ===== NEW ORDER FORM =====
   function showNewOrderForm() {
     let items = [];
     let nextItemId = 0;
+    let quoteTimer = null;
+    let quoteSeq = 0;
 
     App.modal('New Order', `
       <form id="order-form">
@@ -199,6 +294,13 @@ App.registerPage('orders', async () => {
               <label><input type="radio" name="order_type" value="phone"> Phone</label>
             </div>
           </div>
+          <div class="form-group">
+            <label>Service</label>
+            <div class="radio-group">
+              <label><input type="radio" name="service_mode" value="on_site" checked> On site</label>
+              <label><input type="radio" name="service_mode" value="takeaway"> Takeaway</label>
+            </div>
+          </div>
         </div>
         <div class="form-row">
           <div class="form-group grow"><label>Notes</label><input id="of-notes" placeholder="Special instructions..."></div>
@@ -216,12 +318,17 @@ App.registerPage('orders', async () => {
 
     document.getElementById('add-item-btn').addEventListener('click', addItem);
     document.getElementById('order-form').addEventListener('submit', submitOrder);
+    // Phone orders are picked up: default them to takeaway (VAT depends on it)
+    document.querySelectorAll('input[name="order_type"]').forEach(radio => radio.addEventListener('change', () => {
+      const mode = radio.value === 'phone' ? 'takeaway' : 'on_site';
+      document.querySelector(`input[name="service_mode"][value="${mode}"]`).checked = true;
+    }));
 
     addItem(); // start with one item
 
     function addItem() {
       const id = nextItemId++;
-      items.push({ id, type: 'product', product_id: null, menu_id: null, quantity: 1, option_values: [] });
+      items.push({ id, type: 'product', product_id: null, menu_id: null, quantity: 1, option_values: [], substitutions: [] });
 
       const row = document.createElement('div');
       row.className = 'item-row';
@@ -264,6 +371,7 @@ App.registerPage('orders', async () => {
       item.product_id = null;
       item.menu_id = null;
       item.option_values = [];
+      item.substitutions = [];
 
       const catGroup = document.getElementById('item-category-' + id);
       const sel = document.querySelector(`#item-${id} [data-field="item_id"]`);
@@ -278,7 +386,7 @@ App.registerPage('orders', async () => {
         catGroup.style.display = 'none';
         sel.disabled = false;
         sel.innerHTML = `<option value="">Select...</option>
-          ${allMenus.map(x => `<option value="${x.id}">${x.name} (${fmtPrice(x.price)})</option>`).join('')}`;
+          ${allMenus.map(x => `<option value="${x.id}"${orderable(x) ? '' : ' disabled'}>${x.name} (${fmtPrice(x.price)})</option>`).join('')}`;
       }
       document.getElementById('item-options-' + id).innerHTML = '';
       updatePrice();
@@ -299,7 +407,7 @@ App.registerPage('orders', async () => {
       } else {
         const filtered = allProducts.filter(p => p.category_id === Number(categoryId));
         sel.innerHTML = `<option value="">Select...</option>
-          ${filtered.map(p => `<option value="${p.id}">${p.name} (${fmtPrice(p.price)})</option>`).join('')}`;
+          ${filtered.map(p => `<option value="${p.id}"${orderable(p) ? '' : ' disabled'}>${p.name} (${fmtPrice(p.price)})</option>`).join('')}`;
         sel.disabled = false;
       }
       updatePrice();
@@ -317,7 +425,10 @@ App.registerPage('orders', async () => {
       } else {
         item.menu_id = val ? Number(val) : null;
         item.product_id = null;
-        document.getElementById('item-options-' + id).innerHTML = '';
+        item.option_values = [];
+        item.substitutions = [];
+        if (val) await loadMenuOptions(id, Number(val));
+        else document.getElementById('item-options-' + id).innerHTML = '';
       }
       updatePrice();
     };
@@ -325,51 +436,97 @@ App.registerPage('orders', async () => {
     async function loadItemOptions(itemId, productId) {
       const el = document.getElementById('item-options-' + itemId);
       try {
-        const opts = await App.api('/options/product/' + productId);
-        const optList = Array.isArray(opts) ? opts : [];
-        if (optList.length === 0) { el.innerHTML = ''; return; }
+        el.innerHTML = await optionGroupsHTML(itemId, productId, null);
+      } catch { el.innerHTML = ''; }
+    }
 
+    // Menu items pick options per component (e.g. the size of the drink of the menu),
+    // and optional components can be swapped for one of their substitutes
+    async function loadMenuOptions(itemId, menuId) {
+      const el = document.getElementById('item-options-' + itemId);
+      const menu = allMenus.find(m => m.id === menuId);
+      try {
         let html = '';
-        for (const opt of optList) {
-          let vals;
-          try { vals = await App.api('/options/' + opt.id + '/values/'); } catch { vals = []; }
-          const valList = Array.isArray(vals) ? vals : [];
-          if (valList.length === 0) continue;
-
-          const isSingle = opt.is_unique === 'single';
-          const inputType = isSingle ? 'radio' : 'checkbox';
-          const inputName = isSingle ? `name="opt-${itemId}-${opt.id}"` : '';
-
-          html += `<div class="option-group">
-            <label class="option-group-title">${opt.name}${opt.is_required ? ' *' : ''}</label>`;
-
-          if (isSingle) {
-            html += '<div class="option-values-single">';
-            for (const v of valList) {
-              const priceTag = v.option_price > 0 ? ' <span class="option-price">(+' + fmtPrice(v.option_price) + ')</span>' : '';
-              html += `<label class="option-label"><input type="${inputType}" ${inputName} value="${v.id}" data-item-id="${itemId}" data-price="${v.option_price}" onchange="toggleItemOption(${itemId})">${v.value}${priceTag}</label>`;
-            }
-            html += '</div>';
-          } else {
-            html += '<div class="option-values-grid">';
-            for (const v of valList) {
-              const priceTag = v.option_price > 0 ? ' <span class="option-price">(+' + fmtPrice(v.option_price) + ')</span>' : '';
-              html += `<label class="option-label"><input type="${inputType}" value="${v.id}" data-item-id="${itemId}" data-price="${v.option_price}" onchange="toggleItemOption(${itemId})">${v.value}${priceTag}</label>`;
-            }
-            html += '</div>';
-          }
-          html += '</div>';
+        for (const mp of (menu ? menu.menu_products || [] : [])) {
+          const substitutes = mp.is_optional ? mp.substitutes || [] : [];
+          const groups = await optionGroupsHTML(itemId, mp.product_id, mp.id);
+          if (!groups && substitutes.length === 0) continue;
+          const prod = allProducts.find(p => p.id === mp.product_id);
+          const name = esc(prod ? prod.name : 'Product #' + mp.product_id);
+          const swap = substitutes.length === 0 ? '' : `
+            <select onchange="menuSubstituteChange(${itemId}, ${mp.id}, this.value)">
+              <option value="">${name}</option>
+              ${substitutes.map(s => {
+                const sub = allProducts.find(p => p.id === s.product_id);
+                const delta = s.price_delta ? ' (' + (s.price_delta > 0 ? '+' : '') + fmtPrice(s.price_delta) + ')' : '';
+                return `<option value="${s.product_id}" data-delta="${s.price_delta}">${esc(sub ? sub.name : 'Product #' + s.product_id)}${delta}</option>`;
+              }).join('')}
+            </select>`;
+          html += `<div class="option-component"><div class="text-muted" style="font-size:12px;">${name}${mp.is_optional ? ' (optional)' : ''}${swap}</div>
+            <div id="item-component-${itemId}-${mp.id}">${groups}</div></div>`;
         }
         el.innerHTML = html;
       } catch { el.innerHTML = ''; }
     }
 
+    window.menuSubstituteChange = async function(itemId, menuProductId, productId) {
+      const item = items.find(i => i.id === itemId);
+      if (!item) return;
+      const menu = allMenus.find(m => m.id === item.menu_id);
+      const mp = menu ? (menu.menu_products || []).find(x => x.id === menuProductId) : null;
+      if (!mp) return;
+
+      item.substitutions = (item.substitutions || []).filter(s => s.menu_product_id !== menuProductId);
+      if (productId) {
+        const sub = (mp.substitutes || []).find(s => s.product_id === Number(productId));
+        item.substitutions.push({ menu_product_id: menuProductId, product_id: Number(productId), price_delta: sub ? sub.price_delta : 0 });
+      }
+
+      // The options offered are those of the product actually served
+      const el = document.getElementById(`item-component-${itemId}-${menuProductId}`);
+      try {
+        el.innerHTML = await optionGroupsHTML(itemId, productId ? Number(productId) : mp.product_id, menuProductId);
+      } catch { el.innerHTML = ''; }
+      toggleItemOption(itemId);
+    };
+
+    async function optionGroupsHTML(itemId, productId, menuProductId) {
+      const opts = await App.api('/options/product/' + productId);
+      const optList = Array.isArray(opts) ? opts : [];
+
+      let html = '';
+      for (const opt of optList) {
+        let vals;
+        try { vals = await App.api('/options/' + opt.id + '/values/'); } catch { vals = []; }
+        const valList = Array.isArray(vals) ? vals : [];
+        if (valList.length === 0) continue;
+
+        const isSingle = opt.is_unique === 'single';
+        const inputType = isSingle ? 'radio' : 'checkbox';
+        const inputName = isSingle ? `name="opt-${itemId}-${menuProductId || 0}-${opt.id}"` : '';
+        const mpAttr = menuProductId ? `data-menu-product-id="${menuProductId}"` : '';
+
+        html += `<div class="option-group">
+          <label class="option-group-title">${opt.name}${opt.is_required ? ' *' : ''}</label>`;
+
+        html += isSingle ? '<div class="option-values-single">' : '<div class="option-values-grid">';
+        for (const v of valList) {
+          const priceTag = v.option_price > 0 ? ' <span class="option-price">(+' + fmtPrice(v.option_price) + ')</span>' : '';
+          html += `<label class="option-label"><input type="${inputType}" ${inputName} value="${v.id}" ${mpAttr} data-item-id="${itemId}" data-price="${v.option_price}" onchange="toggleItemOption(${itemId})">${v.value}${priceTag}</label>`;
+        }
+        html += '</div>';
+        html += '</div>';
+      }
+      return html;
+    }
+
     window.toggleItemOption = function(itemId) {
       const item = items.find(i => i.id === itemId);
       if (!item) return;
       const checked = document.querySelectorAll(`#item-options-${itemId} input:checked`);
       item.option_values = Array.from(checked).map(cb => ({
         option_value_id: Number(cb.value),
+        menu_product_id: cb.dataset.menuProductId ? Number(cb.dataset.menuProductId) : undefined,
         price: Number(cb.dataset.price)
       }));
       updatePrice();
@@ -400,16 +557,36 @@ App.registerPage('orders', async () => {
           if (m) unitPrice = m.price;
         }
         const optPrice = (item.option_values || []).reduce((s, v) => s + (v.price || 0), 0);
-        total += (unitPrice + optPrice) * item.quantity;
+        const subPrice = item.type === 'menu' ? (item.substitutions || []).reduce((s, v) => s + (v.price_delta || 0), 0) : 0;
+        total += (unitPrice + optPrice + subPrice) * item.quantity;
       }
       const el = document.getElementById('price-preview');
       if (el) el.textContent = 'Total: ' + fmtPrice(total);
+
+      // The estimate above ignores promotions: ask the server for the exact quote once editing pauses
+      clearTimeout(quoteTimer);
+      quoteTimer = setTimeout(refreshQuote, 400);
     }
 
-    async function submitOrder(e) {
-      e.preventDefault();
+    async function refreshQuote() {
+      const body = orderBody();
+      if (!body) return;
+      const seq = ++quoteSeq;
+      try {
+        const quote = await App.api('/orders/quote', { method: 'POST', body });
+        const el = document.getElementById('price-preview');
+        if (!el || seq !== quoteSeq) return; // form closed or a newer quote is on its way
+        const discounts = (quote.discounts || []).map(d => esc(d.name) + ' −' + fmtPrice(d.amount));
+        el.innerHTML = 'Total: ' + fmtPrice(quote.total_price) +
+          (discounts.length ? '<div class="text-muted" style="font-size:11px;">' + discounts.join(', ') + '</div>' : '');
+      } catch { /* incomplete order: keep the estimate, creation reports the problem */ }
+    }
+
+    // orderBody builds the order as sent to the server, or null when no item is chosen yet
+    function orderBody() {
       const custVal = document.getElementById('of-customer').value;
       const orderType = document.querySelector('input[name="order_type"]:checked').value;
+      const serviceMode = document.querySelector('input[name="service_mode"]:checked').value;
       const notes = document.getElementById('of-notes').value;
       const scheduledRaw = document.getElementById('of-scheduled-time').value;
 
@@ -418,19 +595,30 @@ App.registerPage('orders', async () => {
         if (i.type === 'product') obj.product_id = i.product_id;
         else obj.menu_id = i.menu_id;
         if (i.option_values && i.option_values.length > 0) {
-          obj.order_item_options = i.option_values.map(v => ({ option_value_id: v.option_value_id }));
+          obj.options = i.option_values.map(v => ({ option_value_id: v.option_value_id, menu_product_id: v.menu_product_id }));
+        }
+        if (i.type === 'menu' && i.substitutions && i.substitutions.length > 0) {
+          obj.substitutions = i.substitutions.map(s => ({ menu_product_id: s.menu_product_id, product_id: s.product_id }));
         }
         return obj;
       });
-      if (orderItems.length === 0) return App.toast('Add at least one item', 'error');
+      if (orderItems.length === 0) return null;
 
       const body = {
         order_type: orderType,
+        service_mode: serviceMode,
         notes,
         order_items: orderItems,
       };
       if (custVal) body.customer_id = Number(custVal);
       if (scheduledRaw) body.scheduled_time = new Date(scheduledRaw).toISOString();
+      return body;
+    }
+
+    async function submitOrder(e) {
+      e.preventDefault();
+      const body = orderBody();
+      if (!body) return App.toast('Add at least one item', 'error');
 
       try {
         await App.api('/orders/', { method: 'POST', body });
@@ -23,6 +23,11 @@ App.registerPage('products', async () => {
     else if (tab === 'options') await loadOptions();
   }
 
+  // VAT rates are stored in basis points (1000 = 10%); a blank field means no rate (null)
+  function percentToBasisPoints(value) {
+    return value === '' ? null : Math.round(Number(value) * 100);
+  }
+
   // ===== CATEGORIES =====
   async function loadCategories() {
     const el = document.getElementById('tab-content');
@@ -55,7 +60,7 @@ App.registerPage('products', async () => {
   }
 
   window.showCategoryForm = async function(id) {
-    let cat = { name: '', description: '', display_order: 0, image_url: '' };
+    let cat = { name: '', description: '', display_order: 0, image_url: '', tax_rate_on_site: 1000, tax_rate_takeaway: 550 };
     if (id) {
       try { cat = await App.api('/categories/' + id); } catch { return App.toast('Failed to load', 'error'); }
     }
@@ -67,6 +72,10 @@ App.registerPage('products', async () => {
           <div class="form-group"><label>Display Order</label><input type="number" id="cf-order" value="${cat.display_order || 0}"></div>
           <div class="form-group"><label>Image URL</label><input id="cf-img" value="${cat.image_url || ''}"></div>
         </div>
+        <div class="form-row">
+          <div class="form-group"><label>VAT on site (%)</label><input type="number" step="0.01" min="0" max="100" id="cf-vat-onsite" value="${cat.tax_rate_on_site / 100}" required></div>
+          <div class="form-group"><label>VAT takeaway (%)</label><input type="number" step="0.01" min="0" max="100" id="cf-vat-takeaway" value="${cat.tax_rate_takeaway / 100}" required></div>
+        </div>
         <button type="submit" class="btn btn-block">${id ? 'Update' : 'Create'}</button>
       </form>
     `);
@@ -80,6 +89,8 @@ App.registerPage('products', async () => {
             description: document.getElementById('cf-desc').value,
             display_order: Number(document.getElementById('cf-order').value),
             image_url: document.getElementById('cf-img').value,
+            tax_rate_on_site: percentToBasisPoints(document.getElementById('cf-vat-onsite').value),
+            tax_rate_takeaway: percentToBasisPoints(document.getElementById('cf-vat-takeaway').value),
           }
         });
         App.closeModal();
@@ -102,7 +113,7 @@ App.registerPage('products', async () => {
   async function loadProducts() {
     const el = document.getElementById('tab-content');
     try {
-      const [prods, cats] = await Promise.all([App.api('/products/'), App.api('/categories/')]);
+      const [prods, cats] = await Promise.all([App.list('/products/'), App.api('/categories/')]);
       const list = Array.isArray(prods) ? prods : [];
       const catList = Array.isArray(cats) ? cats : [];
 
@@ -151,7 +162,7 @@ App.registerPage('products', async () => {
         <label class="toggle">
           <input type="checkbox" ${p.is_available ? 'checked' : ''} onchange="toggleProductAvail(${p.id})">
           <span class="toggle-slider"></span>
-        </label>
+        </label>${stockBadge(p.unavailable_reason)}${scheduleBadge(p.effective_availability)}
       </td>
       <td class="inline-flex">
         <button class="btn btn-sm" onclick="showProductForm(${p.id})">Edit</button>
@@ -199,6 +210,10 @@ App.registerPage('products', async () => {
           <div class="form-group"><label>Prep Time (min)</label><input type="number" id="pf-prep" value="${prod.preparation_time || 0}"></div>
         </div>
         <div class="form-group"><label>Image URL</label><input id="pf-img" value="${prod.image_url || ''}" placeholder="https://..."></div>
+        <div class="form-row">
+          <div class="form-group"><label>VAT on site (%)</label><input type="number" step="0.01" min="0" max="100" id="pf-vat-onsite" value="${prod.tax_rate_on_site != null ? prod.tax_rate_on_site / 100 : ''}" placeholder="Category rate"></div>
+          <div class="form-group"><label>VAT takeaway (%)</label><input type="number" step="0.01" min="0" max="100" id="pf-vat-takeaway" value="${prod.tax_rate_takeaway != null ? prod.tax_rate_takeaway / 100 : ''}" placeholder="Category rate"></div>
+        </div>
         <button type="submit" class="btn btn-block">${id ? 'Update' : 'Create'}</button>
       </form>
     `);
@@ -216,6 +231,8 @@ App.registerPage('products', async () => {
             preparation_time: Number(document.getElementById('pf-prep').value),
             image_url: document.getElementById('pf-img').value,
             is_available: true,
+            tax_rate_on_site: percentToBasisPoints(document.getElementById('pf-vat-onsite').value),
+            tax_rate_takeaway: percentToBasisPoints(document.getElementById('pf-vat-takeaway').value),
           }
         });
         App.closeModal();
@@ -238,7 +255,7 @@ App.registerPage('products', async () => {
   async function loadOptions() {
     const el = document.getElementById('tab-content');
     try {
-      const prods = await App.api('/products/');
+      const prods = await App.list('/products/');
       const list = Array.isArray(prods) ? prods : [];
 
       el.innerHTML = `
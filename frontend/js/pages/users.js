@@ -26,7 +26,7 @@ App.registerPage('users', async () => {
     const el = document.getElementById('tab-content');
     try {
       const [users, roles] = await Promise.all([
-        App.api('/users/'),
+        App.list('/users/'),
         App.api('/roles/'),
       ]);
       const userList = Array.isArray(users) ? users : [];
@@ -44,7 +44,11 @@ App.registerPage('users', async () => {
                 <td>${u.id}</td>
                 <td>${esc(u.username)}</td>
                 <td>${esc(u.email)}</td>
-                <td>${u.Role ? esc(u.Role.role_name) : u.roles_id}</td>
+                <td>
+                  <select onchange="changeUserRole(${u.id}, this.value)">
+                    ${roleList.map(r => `<option value="${r.id}" ${r.id === u.roles_id ? 'selected' : ''}>${esc(r.role_name)}</option>`).join('')}
+                  </select>
+                </td>
                 <td>
                   <label class="toggle">
                     <input type="checkbox" ${u.is_active ? 'checked' : ''} onchange="toggleUserStatus(${u.id})">
@@ -53,6 +57,9 @@ App.registerPage('users', async () => {
                 </td>
                 <td>${fmtDate(u.created_at)}</td>
                 <td>
+                  ${u.locked_until && new Date(u.locked_until) > new Date()
+                    ? `<button class="btn btn-sm btn-danger" title="Locked until ${fmtDate(u.locked_until)} after failed logins" onclick="unlockUser(${u.id})">Unlock</button>`
+                    : ''}
                   <button class="btn btn-sm btn-info" onclick="resetPassword(${u.id}, '${esc(u.username)}')">Reset PW</button>
                   <button class="btn btn-sm btn-danger" onclick="deleteUser(${u.id})">Del</button>
                 </td>
@@ -107,6 +114,22 @@ App.registerPage('users', async () => {
     } catch (err) { App.toast(err.message, 'error'); loadUsers(); }
   };
 
+  // The new role applies from the user's next request, without signing them out
+  window.changeUserRole = async function(id, rolesId) {
+    try {
+      await App.api('/users/' + id + '/role', { method: 'PATCH', body: { roles_id: Number(rolesId) } });
+      App.toast('Role updated', 'success');
+    } catch (err) { App.toast(err.message, 'error'); loadUsers(); }
+  };
+
+  window.unlockUser = async function(id) {
+    try {
+      await App.api('/users/' + id + '/unlock', { method: 'PATCH' });
+      App.toast('User unlocked', 'success');
+      loadUsers();
+    } catch (err) { App.toast(err.message, 'error'); }
+  };
+
   window.toggleUserStatus = async function(id) {
     try {
       await App.api('/users/' + id + '/status', { method: 'PATCH' });
@@ -131,43 +154,67 @@ App.registerPage('users', async () => {
   };
 
   // ===== ROLES & PERMISSIONS =====
-  function loadRoles() {
+  // Same matching as the API: exact name, "*" or a prefix such as "orders:*"
+  const grants = (list, permission) => (list || []).some(g =>
+    g === '*' || g === permission || (g.endsWith('*') && permission.startsWith(g.slice(0, -1))));
+
+  async function loadRoles() {
     const el = document.getElementById('tab-content');
+    try {
+      const [roles, permissions] = await Promise.all([
+        App.api('/roles/'),
+        App.api('/roles/permissions'),
+      ]);
+      const roleList = Array.isArray(roles) ? roles : [];
+      const permissionList = Array.isArray(permissions) ? permissions : [];
 
-    const permissions = [
-      { resource: 'Users',      actions: 'Create, View, Delete, Activate/Deactivate, Reset Password', admin: true, accueil: false, preparation: false },
-      { resource: 'Roles',      actions: 'View',                                      admin: true, accueil: false, preparation: false },
-      { resource: 'Products',   actions: 'View',                                      admin: true, accueil: true,  preparation: true },
-      { resource: 'Products',   actions: 'Create, Edit, Delete, Stock, Availability', admin: true, accueil: false, preparation: false },
-      { resource: 'Categories', actions: 'View',                                      admin: true, accueil: true,  preparation: true },
-      { resource: 'Categories', actions: 'Create, Edit, Delete',                      admin: true, accueil: false, preparation: false },
-      { resource: 'Menus',      actions: 'View',                                      admin: true, accueil: true,  preparation: true },
-      { resource: 'Menus',      actions: 'Create, Edit, Delete, Availability',        admin: true, accueil: false, preparation: false },
-      { resource: 'Customers',  actions: 'Create, View, Edit, Delete',                admin: true, accueil: true,  preparation: false },
-      { resource: 'Orders',     actions: 'View',                                      admin: true, accueil: true,  preparation: true },
-      { resource: 'Orders',     actions: 'Create, Cancel',                            admin: true, accueil: true,  preparation: false },
-      { resource: 'Orders',     actions: 'Update Status',                             admin: true, accueil: true,  preparation: true },
-    ];
-
-    const check = v => v ? '<span class="text-accent">&#10003;</span>' : '<span class="text-muted">&#10007;</span>';
-
-    el.innerHTML = `
-      <div class="section-title mt-16">Roles & Permissions</div>
-      <p class="text-muted mb-16">Roles are predefined. The table below shows what each role can access.</p>
-      <div class="table-wrap">
-        <table>
-          <thead><tr><th>Resource</th><th>Actions</th><th>Admin</th><th>Accueil</th><th>Preparation</th></tr></thead>
-          <tbody>
-            ${permissions.map(p => `<tr>
-              <td><strong>${p.resource}</strong></td>
-              <td class="text-muted">${p.actions}</td>
-              <td style="text-align:center">${check(p.admin)}</td>
-              <td style="text-align:center">${check(p.accueil)}</td>
-              <td style="text-align:center">${check(p.preparation)}</td>
-            </tr>`).join('')}
-          </tbody>
-        </table>
-      </div>
-    `;
+      const check = v => v ? '<span class="text-accent">&#10003;</span>' : '<span class="text-muted">&#10007;</span>';
+
+      el.innerHTML = `
+        <div class="section-title mt-16">Roles & Permissions</div>
+        <p class="text-muted mb-16">Each role grants a set of permissions. Changes apply from the next request of its users.</p>
+        <div class="table-wrap">
+          <table>
+            <thead><tr><th>Permission</th><th>Description</th>
+              ${roleList.map(r => `<th style="text-align:center">${esc(r.role_name)}<br>
+                <button class="btn btn-sm btn-info" onclick="editRolePermissions(${r.id})">Edit</button></th>`).join('')}
+            </tr></thead>
+            <tbody>
+              ${permissionList.map(p => `<tr>
+                <td><strong>${esc(p.name)}</strong></td>
+                <td class="text-muted">${esc(p.description)}</td>
+                ${roleList.map(r => `<td style="text-align:center">${check(grants(r.permissions, p.name))}</td>`).join('')}
+              </tr>`).join('')}
+            </tbody>
+          </table>
+        </div>
+      `;
+
+      window.editRolePermissions = function(id) {
+        const role = roleList.find(r => r.id === id);
+        const held = role.permissions || [];
+        // Wildcards other than the listed permissions are kept as they are
+        const wildcards = held.filter(g => g.endsWith('*'));
+        App.modal('Permissions of ' + role.role_name, `
+          <form id="role-form">
+            ${wildcards.length ? `<p class="text-muted mb-16">Also granted: ${wildcards.map(esc).join(', ')}</p>` : ''}
+            ${permissionList.map(p => `<div class="form-group"><label>
+              <input type="checkbox" value="${esc(p.name)}" ${held.includes(p.name) ? 'checked' : ''}>
+              ${esc(p.name)} <span class="text-muted">${esc(p.description)}</span></label></div>`).join('')}
+            <button type="submit" class="btn btn-block">Save</button>
+          </form>
+        `);
+        document.getElementById('role-form').addEventListener('submit', async e => {
+          e.preventDefault();
+          const checked = [...document.querySelectorAll('#role-form input:checked')].map(i => i.value);
+          try {
+            await App.api('/roles/' + id + '/permissions', { method: 'PATCH', body: { permissions: [...wildcards, ...checked] } });
+            App.closeModal();
+            App.toast('Permissions updated', 'success');
+            loadRoles();
+          } catch (err) { App.toast(err.message, 'error'); }
+        });
+      };
+    } catch (err) { el.innerHTML = `<div class="empty-msg">${err.message}</div>`; }
   }
 });
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // RESTAURANT_TIMEZONE works on hosts without a timezone database
 	"wacdo/config"
+	"wacdo/controllers"
+	"wacdo/middlewares"
 	"wacdo/models"
 	"wacdo/routes"
+	"wacdo/workflow"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	_ "wacdo/docs"
 
@@ -30,15 +38,32 @@ func main() {
 		log.Println("file not found: .ENV")
 	}
 
+	// Schedules and promotions are read in the restaurant's timezone
+	if err := config.LoadLocation(); err != nil {
+		log.Fatal("Invalid RESTAURANT_TIMEZONE: ", err)
+	}
+
+	// Rate limits can be tuned per route group (must happen before the routes are registered)
+	if err := config.LoadRateLimits(); err != nil {
+		log.Fatal("Invalid rate limit: ", err)
+	}
+
+	// Refuse to start with an inconsistent order workflow
+	if err := workflow.Orders.Validate(); err != nil {
+		log.Fatal("Invalid order workflow: ", err)
+	}
+
 	router := gin.Default()
 
-	// Proxie rules
-	router.SetTrustedProxies(nil)
+	// Client IP behind a reverse proxy (TRUSTED_PROXIES, CLIENT_IP_HEADER)
+	if err := config.LoadTrustedProxies(router); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
 
 	// Security middleware
 	router.Use(config.SecurityMiddleware())
 	router.Use(config.CORSMiddleware())
-	router.Use(config.RateLimit(100))
+	router.Use(config.RateLimit("default", middlewares.ClientKey))
 	// API router definition
 	routes.UsersRoutes(router)
 	routes.RolesRoutes(router)
@@ -49,6 +74,13 @@ func main() {
 	routes.MenuRoutes(router)
 	routes.CustomerRoutes(router)
 	routes.OrderRoutes(router)
+	routes.StockRoutes(router)
+	routes.KitchenRoutes(router)
+	routes.ReportRoutes(router)
+	routes.PromotionRoutes(router)
+	routes.ScheduleRoutes(router)
+	routes.PriceRoutes(router)
+	routes.CatalogRoutes(router)
 
 	// Swagger routes
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -56,6 +88,11 @@ func main() {
 	// Connect to DB
 	config.ConnectDB()
 
+	// Amounts stored in euros become integer cents before AutoMigrate sees the new column types
+	migrateMoneyToCents()
+	// Likewise, is_unique values are normalized before the column is narrowed to 10 characters
+	migrateOptionSelection()
+
 	// DB migration
 	config.DB.AutoMigrate(
 		&models.Users{},
@@ -66,15 +103,38 @@ func main() {
 		&models.OptionValues{},
 		&models.Menu{},
 		&models.MenuProduct{},
+		&models.MenuProductSubstitute{},
 		&models.Customer{},
 		&models.Order{},
 		&models.OrderItem{},
 		&models.OrderItemOption{},
+		&models.OrderItemSubstitution{},
+		&models.OrderItemTax{},
+		&models.OrderTaxLine{},
+		&models.Promotion{},
+		&models.OrderDiscount{},
+		&models.AvailabilitySchedule{},
+		&models.PriceChange{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.LoginAttempt{},
+		&models.StockMovement{},
+		&models.OrderStatusEvent{},
 	)
 
 	// Seed default roles and admin user on first install
 	seedDefaults()
 
+	// Give products created before the stock ledger existed an opening balance
+	backfillStockLedger()
+	backfillOrderItemStatus()
+	backfillOrderHistory()
+	backfillPriceHistory()
+	backfillRolePermissions()
+
+	// Copy scheduled prices to the catalog once they are due
+	go controllers.ApplyDuePrices(time.Minute)
+
 	// Start Server on PORT from env (Render sets this), fallback to 8000
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -99,9 +159,9 @@ func seedDefaults() {
 	log.Println("First install detected — seeding default roles and admin user...")
 
 	roles := []models.Roles{
-		{RoleName: "admin", Description: "Full access to all features"},
-		{RoleName: "preparation", Description: "View orders and mark as prepared"},
-		{RoleName: "accueil", Description: "Create and deliver orders"},
+		{RoleName: "admin", Description: "Full access to all features", Permissions: models.DefaultRolePermissions["admin"]},
+		{RoleName: "preparation", Description: "View orders and mark as prepared", Permissions: models.DefaultRolePermissions["preparation"]},
+		{RoleName: "accueil", Description: "Create and deliver orders", Permissions: models.DefaultRolePermissions["accueil"]},
 	}
 	for i := range roles {
 		config.DB.Create(&roles[i])
@@ -126,3 +186,142 @@ func seedDefaults() {
 	log.Println("Default admin user created — email: admin@wacdo.fr / password: Admin@1234")
 	log.Println("Change the admin password after first login!")
 }
+
+// backfillStockLedger records an opening "correction" movement for every product that has stock
+// but no ledger entries yet (products created before the stock ledger existed), so that the
+// stock of every product matches the sum of its movements.
+func backfillStockLedger() {
+	var products []models.Products
+	config.DB.
+		Where("stock_quantity > 0").
+		Where("NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.product_id = products.id)").
+		Find(&products)
+
+	for _, product := range products {
+		config.DB.Create(&models.StockMovement{
+			ProductID: product.ID,
+			Delta:     int(product.StockQuantity),
+			Reason:    models.StockReasonCorrection,
+			Note:      "Opening balance",
+		})
+	}
+
+	if len(products) > 0 {
+		log.Printf("Stock ledger: opening balance recorded for %d products", len(products))
+	}
+}
+
+// backfillOrderItemStatus marks as done the items of orders that were already prepared or delivered
+// before item-level tracking existed (the new column defaults every existing item to "pending").
+func backfillOrderItemStatus() {
+	config.DB.Model(&models.OrderItem{}).
+		Where("status = ?", "pending").
+		Where("order_id IN (SELECT id FROM orders WHERE status IN ?)", []string{"prepared", "delivered"}).
+		Update("status", "done")
+}
+
+// backfillOrderHistory records the creation event of orders placed before status history existed,
+// so every order's history starts with its creation.
+func backfillOrderHistory() {
+	config.DB.Exec(`INSERT INTO order_status_events (order_id, from_status, to_status, user_id, created_at)
+		SELECT id, '', 'pending', created_by_id, created_at FROM orders
+		WHERE NOT EXISTS (SELECT 1 FROM order_status_events WHERE order_status_events.order_id = orders.id)`)
+}
+
+// moneyColumns lists the columns holding amounts, stored as integer cents (models.Money).
+var moneyColumns = []struct {
+	Model  interface{}
+	Column string
+}{
+	{&models.Products{}, "price"},
+	{&models.OptionValues{}, "option_price"},
+	{&models.Menu{}, "price"},
+	{&models.MenuProductSubstitute{}, "price_delta"},
+	{&models.Order{}, "total_price"},
+	{&models.OrderItem{}, "unit_price"},
+	{&models.OrderItem{}, "item_total"},
+	{&models.OrderItemOption{}, "price_applied"},
+	{&models.OrderItemSubstitution{}, "price_delta"},
+}
+
+// migrateMoneyToCents converts amount columns still stored as decimal euros into integer cents,
+// rounding each amount to the nearest cent (halves away from zero, as models.Money does).
+// A column already holding integers is left alone, so the migration runs once per column.
+func migrateMoneyToCents() {
+	migrator := config.DB.Migrator()
+	for _, mc := range moneyColumns {
+		if !migrator.HasTable(mc.Model) {
+			continue // fresh install: AutoMigrate creates the column as cents
+		}
+		columnTypes, err := migrator.ColumnTypes(mc.Model)
+		if err != nil {
+			log.Fatal("Money migration: ", err)
+		}
+		for _, ct := range columnTypes {
+			if ct.Name() != mc.Column || strings.Contains(strings.ToLower(ct.DatabaseTypeName()), "int") {
+				continue
+			}
+
+			stmt := &gorm.Statement{DB: config.DB}
+			if err := stmt.Parse(mc.Model); err != nil {
+				log.Fatal("Money migration: ", err)
+			}
+			table := stmt.Schema.Table
+			// ROUND on numeric rounds halves away from zero; the cast keeps 1.005 from becoming 1.00499…
+			if err := config.DB.Exec(fmt.Sprintf(
+				`ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING ROUND(%q::numeric * 100)`,
+				table, mc.Column, mc.Column)).Error; err != nil {
+				log.Fatal("Money migration: ", err)
+			}
+			log.Printf("Money migration: %s.%s converted to cents", table, mc.Column)
+		}
+	}
+}
+
+// migrateOptionSelection normalizes option groups saved with an unknown is_unique value before it was validated.
+// They become "multiple", the mode that accepts any selection, so existing order flows keep working.
+// It runs before AutoMigrate, which narrows the free-text column to the 10 characters of the known values:
+// a longer value left in place would make that change fail.
+func migrateOptionSelection() {
+	if !config.DB.Migrator().HasTable(&models.ProductOptions{}) {
+		return // fresh install: nothing to normalize
+	}
+	res := config.DB.Model(&models.ProductOptions{}).
+		Where("is_unique IS NULL OR is_unique NOT IN ?", []models.OptionSelection{models.OptionSingle, models.OptionMultiple}).
+		Update("is_unique", models.OptionMultiple)
+	if res.Error != nil {
+		log.Fatal("Option selection migration: ", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("Product options: %d option groups with an unknown is_unique set to 'multiple'", res.RowsAffected)
+	}
+}
+
+// backfillPriceHistory opens the price history of the products, menus and option values created before it existed
+// with their current price: from their creation for products and menus, from now for option values (which have no
+// creation date), so the price in effect at any later moment can be looked up.
+func backfillPriceHistory() {
+	config.DB.Exec(`INSERT INTO price_changes (product_id, price, effective_at, applied_at, created_at)
+		SELECT id, price, created_at, created_at, CURRENT_TIMESTAMP FROM products
+		WHERE NOT EXISTS (SELECT 1 FROM price_changes WHERE price_changes.product_id = products.id)`)
+	config.DB.Exec(`INSERT INTO price_changes (menu_id, price, effective_at, applied_at, created_at)
+		SELECT id, price, created_at, created_at, CURRENT_TIMESTAMP FROM menus
+		WHERE NOT EXISTS (SELECT 1 FROM price_changes WHERE price_changes.menu_id = menus.id)`)
+	config.DB.Exec(`INSERT INTO price_changes (option_value_id, price, effective_at, applied_at, created_at)
+		SELECT id, option_price, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM option_values
+		WHERE NOT EXISTS (SELECT 1 FROM price_changes WHERE price_changes.option_value_id = option_values.id)`)
+}
+
+// backfillRolePermissions gives the seeded roles their default permissions when they have none,
+// which is the case for installs from before routes were guarded by permission. Roles that already
+// have permissions, and custom roles, are left alone.
+func backfillRolePermissions() {
+	for name, permissions := range models.DefaultRolePermissions {
+		res := config.DB.Model(&models.Roles{}).
+			Where("role_name = ? AND (permissions IS NULL OR permissions = '')", name).
+			Update("permissions", permissions)
+		if res.RowsAffected > 0 {
+			log.Printf("Roles: default permissions given to '%s'", name)
+		}
+	}
+}
@@ -5,11 +5,30 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// parseToken checks the signature and expiry of an access token.
+func parseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrTokenMalformed
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+}
+
+// Authentication accepts requests bearing a valid access token whose session is still open
+// (see controllers.Login) and whose user is still active, and sets in the context the user
+// (see CurrentUser), userID, userRole, userPermissions, sessionID and tokenExpiresAt.
+// Tokens of a session revoked by logout, deactivation, deletion or a password change are refused
+// even before they expire. The session and the user are checked through short-lived caches (see
+// sessionCache and userCache); the role and its permissions are the user's current ones, not the
+// role name the token was issued with.
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -21,13 +40,7 @@ func Authentication() gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenMalformed
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
+		token, err := parseToken(tokenString)
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token Invalid or Expired"})
 			return
@@ -45,11 +58,54 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 
-		roleName, _ := claims["RoleName"].(string)
+		// Tokens without a session predate refresh tokens and are refused like revoked ones
+		sessionID, ok := claims["SessionID"].(float64)
+		if !ok || !sessionOpen(uint(sessionID), uint(userID)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session revoked, please log in again"})
+			return
+		}
 
-		c.Set("userID", int(userID))
-		c.Set("userRole", roleName)
+		user, ok := loadUser(uint(userID))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Account disabled, please log in again"})
+			return
+		}
+
+		setUser(c, user)
+		c.Set("sessionID", int(sessionID))
+		if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+			c.Set("tokenExpiresAt", expiresAt.Time)
+		}
 
 		c.Next()
 	}
 }
+
+// setUser sets the authenticated user and their current role in the context.
+func setUser(c *gin.Context, user models.Users) {
+	c.Set("user", user)
+	c.Set("userID", int(user.ID))
+	c.Set("userRole", user.Role.RoleName)
+	c.Set("userPermissions", user.Role.Permissions)
+}
+
+// Reauthenticate checks again, for a request that stays open such as a stream, what Authentication
+// checked when it started: the access token has not expired, its session is still open and its user
+// is still active. The user, role and permissions in the context are refreshed. It returns false
+// when the request should no longer be served.
+func Reauthenticate(c *gin.Context) bool {
+	if expiresAt := c.GetTime("tokenExpiresAt"); !expiresAt.IsZero() && !time.Now().Before(expiresAt) {
+		return false
+	}
+
+	userID, sessionID := uint(c.GetInt("userID")), uint(c.GetInt("sessionID"))
+	if !sessionOpen(sessionID, userID) {
+		return false
+	}
+	user, ok := loadUser(userID)
+	if !ok {
+		return false
+	}
+	setUser(c, user)
+	return true
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"testing"
 	"time"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -17,11 +20,35 @@ func init() {
 	os.Setenv("JWT_SECRET", "test-secret-key")
 }
 
+// openSession opens a session for a user in the test DB, as a login would.
+func openSession(userID float64) models.Session {
+	session := models.Session{UserID: uint(userID), LastUsedAt: time.Now()}
+	config.DB.Create(&session)
+	return session
+}
+
+// seedUser creates an active user with the given ID and role in the test DB, creating the role if needed.
+func seedUser(userID float64, roleName string) models.Users {
+	var role models.Roles
+	if config.DB.Where("role_name = ?", roleName).First(&role).Error != nil {
+		role = testutils.SeedRole(config.DB, roleName)
+	}
+	user := models.Users{ID: uint(userID), Username: roleName, Email: roleName + "@test.com", RolesID: role.ID, IsActive: true}
+	config.DB.Create(&user)
+	return user
+}
+
 func generateToken(userID float64, roleName string, expiry time.Duration) string {
+	seedUser(userID, roleName)
+	return generateSessionToken(userID, roleName, float64(openSession(userID).ID), expiry)
+}
+
+func generateSessionToken(userID float64, roleName string, sessionID float64, expiry time.Duration) string {
 	claims := jwt.MapClaims{
-		"UserID":   userID,
-		"RoleName": roleName,
-		"exp":      jwt.NewNumericDate(time.Now().Add(expiry)),
+		"UserID":    userID,
+		"RoleName":  roleName,
+		"SessionID": sessionID,
+		"exp":       jwt.NewNumericDate(time.Now().Add(expiry)),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
@@ -29,6 +56,7 @@ func generateToken(userID float64, roleName string, expiry time.Duration) string
 }
 
 func setupAuthRouter() *gin.Engine {
+	testutils.SetupTestDB()
 	r := gin.New()
 	r.Use(Authentication())
 	r.GET("/test", func(c *gin.Context) {
@@ -128,3 +156,140 @@ func TestAuthentication_SetsContextValues(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `"userID":42`)
 	assert.Contains(t, w.Body.String(), `"userRole":"preparation"`)
 }
+
+func TestAuthentication_RevokedSession(t *testing.T) {
+	r := setupAuthRouter()
+	session := openSession(1)
+	token := generateSessionToken(1, "admin", float64(session.ID), 2*time.Hour)
+
+	revokedAt := time.Now()
+	config.DB.Model(&session).Updates(map[string]interface{}{"revoked_at": &revokedAt, "revoked_reason": models.SessionLogout})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthentication_SessionOfAnotherUser(t *testing.T) {
+	r := setupAuthRouter()
+	session := openSession(1)
+	token := generateSessionToken(2, "admin", float64(session.ID), 2*time.Hour)
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthentication_TokenWithoutSession(t *testing.T) {
+	r := setupAuthRouter()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"UserID":   float64(1),
+		"RoleName": "admin",
+		"exp":      jwt.NewNumericDate(time.Now().Add(2 * time.Hour)),
+	})
+	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "issued before sessions existed")
+}
+
+// get performs GET /test with a token and returns the recorder.
+func get(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthentication_UsesCurrentRole(t *testing.T) {
+	r := setupAuthRouter()
+	token := generateToken(7, "admin", 2*time.Hour)
+	assert.Contains(t, get(r, token).Body.String(), `"userRole":"admin"`)
+
+	// The role claim of the token is not trusted once the user has been moved to another role
+	counter := testutils.SeedRole(config.DB, "accueil")
+	config.DB.Model(&models.Users{}).Where("id = ?", 7).Update("roles_id", counter.ID)
+	ForgetUser(7)
+	assert.Contains(t, get(r, token).Body.String(), `"userRole":"accueil"`)
+}
+
+func TestAuthentication_DeletedOrDeactivatedUser(t *testing.T) {
+	r := setupAuthRouter()
+	token := generateToken(7, "admin", 2*time.Hour)
+	assert.Equal(t, http.StatusOK, get(r, token).Code)
+
+	config.DB.Model(&models.Users{}).Where("id = ?", 7).Update("is_active", false)
+	ForgetUser(7)
+	assert.Equal(t, http.StatusUnauthorized, get(r, token).Code)
+
+	config.DB.Model(&models.Users{}).Where("id = ?", 7).Update("is_active", true)
+	config.DB.Delete(&models.Users{}, 7)
+	ForgetUser(7)
+	assert.Equal(t, http.StatusUnauthorized, get(r, token).Code)
+}
+
+func TestAuthentication_UserCache(t *testing.T) {
+	r := setupAuthRouter()
+	token := generateToken(7, "admin", 2*time.Hour)
+	assert.Equal(t, http.StatusOK, get(r, token).Code)
+
+	// A change made behind the API's back is only seen once the entry expires
+	config.DB.Model(&models.Users{}).Where("id = ?", 7).Update("is_active", false)
+	assert.Equal(t, http.StatusOK, get(r, token).Code, "served from the cache")
+
+	userCache.Lock()
+	entry := userCache.users[7]
+	entry.expires = time.Now().Add(-time.Second)
+	userCache.users[7] = entry
+	userCache.Unlock()
+	assert.Equal(t, http.StatusUnauthorized, get(r, token).Code)
+}
+
+func TestAuthentication_SessionCache(t *testing.T) {
+	r := setupAuthRouter()
+	seedUser(7, "admin")
+	session := openSession(7)
+	token := generateSessionToken(7, "admin", float64(session.ID), 2*time.Hour)
+	assert.Equal(t, http.StatusOK, get(r, token).Code)
+
+	// A revocation made behind the API's back is only seen once the entry expires
+	revokedAt := time.Now()
+	config.DB.Model(&session).Updates(map[string]interface{}{"revoked_at": &revokedAt, "revoked_reason": models.SessionLogout})
+	assert.Equal(t, http.StatusOK, get(r, token).Code, "served from the cache")
+
+	// Revocations made through the API drop the session at once
+	ForgetSessions(7)
+	assert.Equal(t, http.StatusUnauthorized, get(r, token).Code)
+
+	// A cached session is not accepted for another user
+	other := openSession(7)
+	assert.Equal(t, http.StatusOK, get(r, generateSessionToken(7, "admin", float64(other.ID), 2*time.Hour)).Code)
+	assert.Equal(t, http.StatusUnauthorized, get(r, generateSessionToken(8, "admin", float64(other.ID), 2*time.Hour)).Code)
+}
+
+func TestAuthentication_SetsCurrentUser(t *testing.T) {
+	testutils.SetupTestDB()
+	r := gin.New()
+	r.Use(Authentication())
+	r.GET("/test", func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		assert.True(t, ok)
+		c.JSON(http.StatusOK, gin.H{"email": user.Email, "permissions": Permissions(c)})
+	})
+
+	w := get(r, generateToken(7, "preparation", 2*time.Hour))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"preparation@test.com"`)
+	assert.Contains(t, w.Body.String(), models.PermKitchenView)
+}
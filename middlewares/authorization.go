@@ -2,24 +2,35 @@ package middlewares
 
 import (
 	"net/http"
-	"slices"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Authorization checks that the authenticated user's role is in the allowed list.
-// Must be used after Authentication() so that "userRole" is set in the context.
-func Authorization(allowedRoles ...string) gin.HandlerFunc {
+// Permissions returns the permissions of the authenticated user's role, as set by Authentication()
+// from the user's current role. Without authentication the list is empty, so nothing is granted.
+func Permissions(c *gin.Context) models.PermissionList {
+	cached, _ := c.Get("userPermissions")
+	permissions, _ := cached.(models.PermissionList)
+	return permissions
+}
+
+// HasPermission reports whether the authenticated user's role grants a permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	return Permissions(c).Grants(permission)
+}
+
+// RequirePermission lets the request through only if the authenticated user's role grants the
+// permission (see models.KnownPermissions). Must be used after Authentication().
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("userRole")
-		if !exists {
+		if _, exists := c.Get("userRole"); !exists {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 			return
 		}
 
-		roleName, ok := role.(string)
-		if !ok || !slices.Contains(allowedRoles, roleName) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource"})
+		if !HasPermission(c, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to access this resource", "permission": permission})
 			return
 		}
 
@@ -4,81 +4,91 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"wacdo/config"
+	"wacdo/models"
+	"wacdo/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupAuthzRouter(allowedRoles ...string) *gin.Engine {
+func setupAuthzRouter(permission string) *gin.Engine {
+	testutils.SetupTestDB()
 	r := gin.New()
-	// Simulate authentication by setting userRole in context
+	// Simulate authentication by setting the role and its current permissions in context
 	r.Use(func(c *gin.Context) {
 		if role := c.GetHeader("X-Test-Role"); role != "" {
-			c.Set("userRole", role)
+			testutils.SetAuth(c, 0, role)
 		}
 		c.Next()
 	})
-	r.Use(Authorization(allowedRoles...))
+	r.Use(RequirePermission(permission))
 	r.GET("/test", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "ok"})
 	})
 	return r
 }
 
-func TestAuthorization_AllowedRole(t *testing.T) {
-	r := setupAuthzRouter("admin", "accueil")
-
+func requestAs(r *gin.Engine, role string) int {
 	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Test-Role", "admin")
+	if role != "" {
+		req.Header.Set("X-Test-Role", role)
+	}
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
-
-	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Code
 }
 
-func TestAuthorization_DeniedRole(t *testing.T) {
-	r := setupAuthzRouter("admin")
-
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Test-Role", "preparation")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+func TestRequirePermission_GrantedByRole(t *testing.T) {
+	r := setupAuthzRouter(models.PermKitchenView)
+	testutils.SeedRole(config.DB, "preparation")
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusOK, requestAs(r, "preparation"))
 }
 
-func TestAuthorization_NoRoleInContext(t *testing.T) {
-	r := setupAuthzRouter("admin")
+func TestRequirePermission_Denied(t *testing.T) {
+	r := setupAuthzRouter(models.PermCatalogWrite)
+	testutils.SeedRole(config.DB, "preparation")
+	testutils.SeedRole(config.DB, "accueil")
 
-	req := httptest.NewRequest("GET", "/test", nil)
-	// No X-Test-Role header, so no userRole in context
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, requestAs(r, "preparation"))
+	assert.Equal(t, http.StatusForbidden, requestAs(r, "accueil"))
+}
+
+func TestRequirePermission_AdminHasEverything(t *testing.T) {
+	r := setupAuthzRouter(models.PermReportsView)
+	testutils.SeedRole(config.DB, "admin")
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusOK, requestAs(r, "admin"))
 }
 
-func TestAuthorization_MultipleAllowedRoles(t *testing.T) {
-	r := setupAuthzRouter("admin", "accueil", "preparation")
+func TestRequirePermission_PrefixWildcard(t *testing.T) {
+	r := setupAuthzRouter(models.OrderStatusPermission("prepared"))
+	config.DB.Create(&models.Roles{RoleName: "shift_lead", Permissions: models.PermissionList{"orders:*"}})
+	config.DB.Create(&models.Roles{RoleName: "host", Permissions: models.PermissionList{"customers:*"}})
 
-	roles := []string{"admin", "accueil", "preparation"}
-	for _, role := range roles {
-		req := httptest.NewRequest("GET", "/test", nil)
-		req.Header.Set("X-Test-Role", role)
-		w := httptest.NewRecorder()
-		r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, requestAs(r, "shift_lead"))
+	assert.Equal(t, http.StatusForbidden, requestAs(r, "host"))
+}
 
-		assert.Equal(t, http.StatusOK, w.Code, "role %s should be allowed", role)
-	}
+func TestRequirePermission_RoleChangeAppliesAtOnce(t *testing.T) {
+	r := setupAuthzRouter(models.PermKitchenView)
+	role := testutils.SeedRole(config.DB, "accueil")
+	assert.Equal(t, http.StatusForbidden, requestAs(r, "accueil"))
+
+	config.DB.Model(&role).Update("permissions", models.PermissionList{models.PermKitchenView})
+	assert.Equal(t, http.StatusOK, requestAs(r, "accueil"))
 }
 
-func TestAuthorization_EmptyStringRole(t *testing.T) {
-	r := setupAuthzRouter("admin")
+func TestRequirePermission_NoRoleInContext(t *testing.T) {
+	r := setupAuthzRouter(models.PermKitchenView)
 
-	req := httptest.NewRequest("GET", "/test", nil)
-	req.Header.Set("X-Test-Role", "")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	// No X-Test-Role header, so no userRole in context
+	assert.Equal(t, http.StatusForbidden, requestAs(r, ""))
+}
+
+func TestRequirePermission_UnknownRole(t *testing.T) {
+	r := setupAuthzRouter(models.PermKitchenView)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusForbidden, requestAs(r, "ghost"))
 }
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientKey identifies the client of a request for rate limiting: the authenticated user, or else
+// the client IP. A validly signed bearer token is enough, so it may run before Authentication.
+func ClientKey(c *gin.Context) string {
+	if userID, ok := c.Get("userID"); ok {
+		return "user:" + strconv.Itoa(userID.(int))
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if tokenString, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+		if token, err := parseToken(tokenString); err == nil && token.Valid {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if userID, ok := claims["UserID"].(float64); ok {
+					return "user:" + strconv.Itoa(int(userID))
+				}
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wacdo/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientKeyOf returns the rate limiting key of a request with the given Authorization header.
+func clientKeyOf(authHeader string, userID any) string {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/test", nil)
+	c.Request.RemoteAddr = "10.0.0.1:4242"
+	if authHeader != "" {
+		c.Request.Header.Set("Authorization", authHeader)
+	}
+	if userID != nil {
+		c.Set("userID", userID)
+	}
+	return ClientKey(c)
+}
+
+func TestClientKey(t *testing.T) {
+	// Authenticated requests are keyed by user
+	assert.Equal(t, "user:7", clientKeyOf("", 7))
+
+	// Before Authentication, a validly signed token is enough
+	token := generateSessionToken(3, "admin", 1, time.Hour)
+	assert.Equal(t, "user:3", clientKeyOf("Bearer "+token, nil))
+
+	// Otherwise the client IP
+	assert.Equal(t, "ip:10.0.0.1", clientKeyOf("", nil))
+	assert.Equal(t, "ip:10.0.0.1", clientKeyOf("Bearer "+generateSessionToken(3, "admin", 1, -time.Hour), nil), "expired")
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"UserID": 3})
+	forgedString, _ := forged.SignedString([]byte("another-secret"))
+	assert.Equal(t, "ip:10.0.0.1", clientKeyOf("Bearer "+forgedString, nil), "forged")
+}
+
+// proxiedLogin sends a login request from the proxy at 10.0.0.1, forwarding it for the client IP.
+func proxiedLogin(r *gin.Engine, clientIP string) int {
+	req := httptest.NewRequest("POST", "/users/login", nil)
+	req.RemoteAddr = "10.0.0.1:4242"
+	req.Header.Set("X-Forwarded-For", clientIP)
+	req.Header.Set("CF-Connecting-IP", clientIP)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestClientKey_BehindProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.RateLimits
+	config.RateLimits = map[string]config.RateRule{"login": {Requests: 1, Period: time.Minute}}
+	t.Cleanup(func() { config.RateLimits = previous })
+
+	loginRouter := func() *gin.Engine {
+		r := gin.New()
+		assert.NoError(t, config.LoadTrustedProxies(r))
+		r.POST("/users/login", config.RateLimit("login", ClientKey), func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+
+	// Forwarded by a trusted proxy: each client has its own bucket
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	r := loginRouter()
+	assert.Equal(t, http.StatusOK, proxiedLogin(r, "203.0.113.7"))
+	assert.Equal(t, http.StatusOK, proxiedLogin(r, "198.51.100.20"))
+	assert.Equal(t, http.StatusTooManyRequests, proxiedLogin(r, "203.0.113.7"))
+
+	// Or named by the platform's header
+	t.Setenv("TRUSTED_PROXIES", "")
+	t.Setenv("CLIENT_IP_HEADER", "CF-Connecting-IP")
+	r = loginRouter()
+	assert.Equal(t, http.StatusOK, proxiedLogin(r, "203.0.113.7"))
+	assert.Equal(t, http.StatusOK, proxiedLogin(r, "198.51.100.20"))
+
+	// Untrusted, forwarding headers are ignored: the proxy's IP is the client
+	t.Setenv("CLIENT_IP_HEADER", "")
+	r = loginRouter()
+	assert.Equal(t, http.StatusOK, proxiedLogin(r, "203.0.113.7"))
+	assert.Equal(t, http.StatusTooManyRequests, proxiedLogin(r, "198.51.100.20"))
+
+	t.Setenv("TRUSTED_PROXIES", "not-an-ip")
+	assert.Error(t, config.LoadTrustedProxies(gin.New()))
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"gorm.io/gorm"
+)
+
+type cachedSession struct {
+	userID  uint
+	expires time.Time
+}
+
+// sessionCache keeps the sessions recently found open by Authentication, so that most requests do
+// not check them in the database. It works like userCache and shares its TTL: revocations made
+// through the API drop the sessions at once (see ForgetSession and ForgetSessions), the TTL only
+// covers revocations made elsewhere. Closed sessions are not cached, since they never reopen.
+var sessionCache = struct {
+	sync.Mutex
+	db         *gorm.DB
+	sessions   map[uint]cachedSession
+	generation uint64
+}{}
+
+// sessionOpen reports whether a session of a user exists and has not been revoked.
+func sessionOpen(sessionID, userID uint) bool {
+	sessionCache.Lock()
+	if sessionCache.db != config.DB {
+		sessionCache.db = config.DB
+		sessionCache.sessions = make(map[uint]cachedSession)
+	}
+	entry, found := sessionCache.sessions[sessionID]
+	generation := sessionCache.generation
+	sessionCache.Unlock()
+
+	if found && time.Now().Before(entry.expires) {
+		return entry.userID == userID
+	}
+
+	var count int64
+	err := config.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Count(&count).Error
+	if err != nil || count == 0 {
+		return false
+	}
+
+	sessionCache.Lock()
+	if sessionCache.db == config.DB && sessionCache.generation == generation {
+		sessionCache.sessions[sessionID] = cachedSession{userID: userID, expires: time.Now().Add(userCacheTTL)}
+	}
+	sessionCache.Unlock()
+	return true
+}
+
+// ForgetSession drops a session from the cache so that its tokens are checked again at once.
+// Call it after committing the revocation of the session.
+func ForgetSession(id uint) {
+	sessionCache.Lock()
+	delete(sessionCache.sessions, id)
+	sessionCache.generation++
+	sessionCache.Unlock()
+}
+
+// ForgetSessions drops all the sessions of a user from the cache, e.g. after they were revoked
+// by a password change or the deactivation of the user.
+func ForgetSessions(userID uint) {
+	sessionCache.Lock()
+	for id, entry := range sessionCache.sessions {
+		if entry.userID == userID {
+			delete(sessionCache.sessions, id)
+		}
+	}
+	sessionCache.generation++
+	sessionCache.Unlock()
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+	"wacdo/config"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// userCacheTTL bounds how long a user read by Authentication is reused. Changes made through the
+// API invalidate the cache at once (see ForgetUser); the TTL only covers changes made elsewhere,
+// such as directly in the database.
+var userCacheTTL = 30 * time.Second
+
+type cachedUser struct {
+	user    models.Users
+	expires time.Time
+}
+
+// userCache keeps the users recently seen by Authentication, with their role, so that most
+// requests do not reload them. Entries belong to the database they were read from: a new
+// connection (or a test opening a fresh database) starts with an empty cache. generation counts
+// invalidations, so that a user read while being changed is not cached with their old state.
+var userCache = struct {
+	sync.Mutex
+	db         *gorm.DB
+	users      map[uint]cachedUser
+	generation uint64
+}{}
+
+// loadUser returns an active, not deleted user with their current role, from the cache when fresh.
+// ok is false when the user no longer exists or has been deactivated.
+func loadUser(id uint) (user models.Users, ok bool) {
+	userCache.Lock()
+	if userCache.db != config.DB {
+		userCache.db = config.DB
+		userCache.users = make(map[uint]cachedUser)
+	}
+	entry, found := userCache.users[id]
+	generation := userCache.generation
+	userCache.Unlock()
+
+	if found && time.Now().Before(entry.expires) {
+		return entry.user, true
+	}
+
+	if err := config.DB.Preload("Role").First(&user, id).Error; err != nil || !user.IsActive {
+		ForgetUser(id)
+		return models.Users{}, false
+	}
+
+	userCache.Lock()
+	if userCache.db == config.DB && userCache.generation == generation {
+		userCache.users[id] = cachedUser{user: user, expires: time.Now().Add(userCacheTTL)}
+	}
+	userCache.Unlock()
+	return user, true
+}
+
+// ForgetUser drops a user from the cache so that their next request sees their current state.
+// Call it after committing a change to the user (role, status, deletion).
+func ForgetUser(id uint) {
+	userCache.Lock()
+	delete(userCache.users, id)
+	userCache.generation++
+	userCache.Unlock()
+}
+
+// ForgetAllUsers empties the cache, e.g. after the permissions of a role change.
+func ForgetAllUsers() {
+	userCache.Lock()
+	userCache.users = make(map[uint]cachedUser)
+	userCache.generation++
+	userCache.Unlock()
+}
+
+// CurrentUser returns the authenticated user, with their role, as loaded by Authentication.
+func CurrentUser(c *gin.Context) (models.Users, bool) {
+	value, exists := c.Get("user")
+	if !exists {
+		return models.Users{}, false
+	}
+	user, ok := value.(models.Users)
+	return user, ok
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Outcomes of a login attempt. Only LoginBadCredentials counts towards throttling and lockout;
+// LoginSucceeded, LoginLocked and LoginUnlocked start the count of an email over.
+const (
+	LoginSucceeded      = "succeeded"
+	LoginBadCredentials = "bad_credentials" // Unknown email or wrong password, which are not told apart
+	LoginDeactivated    = "deactivated"
+	LoginThrottled      = "throttled" // Refused before the password was checked (backoff or lockout)
+	LoginLocked         = "locked"    // Not an attempt: the email reached the failure limit and is locked
+	LoginUnlocked       = "unlocked"  // Not an attempt: an admin lifted the lockout of the email
+)
+
+// LoginAttempt records a call to POST /users/login, keyed by the email it was made for and the
+// client IP. Recent failures per email and per IP decide the login backoff and the account lockout.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Email     string    `gorm:"size:255;index" json:"email"` // As typed, lower-cased; may match no user
+	IP        string    `gorm:"size:45;index" json:"ip"`
+	UserID    *uint     `gorm:"index" json:"user_id"` // Nil when no user has the email
+	Outcome   string    `gorm:"size:20;not null" json:"outcome"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -7,25 +7,39 @@ import (
 // Menu represents a combo meal that bundles multiple products at a fixed price (e.g. "Big Mac Menu").
 // Menus are orderable items just like products, and their price is independent of the individual product prices.
 type Menu struct {
-	ID           uint          `gorm:"primaryKey" json:"id"`
-	Name         string        `gorm:"not null;size:100" json:"name" binding:"required"` // Unique menu name
-	Description  string        `gorm:"size:255" json:"description"`
-	Price        float64       `gorm:"not null" json:"price" binding:"required"`         // Fixed combo price in euros
-	IsAvailable  bool          `gorm:"default:true" json:"is_available"`                 // Unavailable menus cannot be ordered
-	MenuProducts []MenuProduct `gorm:"foreignKey:MenuID" json:"menu_products"`           // Products included in this menu
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+	ID                    uint          `gorm:"primaryKey" json:"id"`
+	Name                  string        `gorm:"not null;size:100" json:"name" binding:"required"` // Unique menu name
+	Description           string        `gorm:"size:255" json:"description"`
+	Price                 Money         `gorm:"not null" json:"price" binding:"required"`  // Fixed combo price (cents, euros in JSON)
+	IsAvailable           bool          `gorm:"default:true" json:"is_available"`          // Unavailable menus cannot be ordered
+	UnavailableReason     string        `gorm:"size:20" json:"unavailable_reason"`         // Why the menu is off: "out_of_stock" (automatic) or "manual"
+	MenuProducts          []MenuProduct `gorm:"foreignKey:MenuID" json:"menu_products"`    // Products included in this menu
+	EffectiveAvailability *Availability `gorm:"-" json:"effective_availability,omitempty"` // Whether it can be ordered now, schedules included (read endpoints only)
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
 }
 
 // MenuProduct is the join table between Menu and Products.
 // It defines which products are part of a menu and in what quantity.
 type MenuProduct struct {
-	ID           uint     `gorm:"primaryKey" json:"id"`
-	MenuID       uint     `gorm:"not null;constraint:OnDelete:CASCADE" json:"menu_id"` // FK to Menu — cascade deletes when menu is removed
-	Menu         Menu     `gorm:"foreignKey:MenuID" json:"-"`
-	ProductID    uint     `gorm:"not null" json:"product_id"`                          // FK to Products
-	Product      Products `gorm:"foreignKey:ProductID" json:"-"`
-	Quantity     uint     `gorm:"not null;default:1" json:"quantity"`                  // How many of this product are in the menu
-	IsOptional   bool     `gorm:"default:false" json:"is_optional"`                   // Whether the product can be swapped out
-	DisplayOrder uint     `gorm:"default:0" json:"display_order"`                     // Controls display order in the frontend
+	ID           uint                    `gorm:"primaryKey" json:"id"`
+	MenuID       uint                    `gorm:"not null;constraint:OnDelete:CASCADE" json:"menu_id"` // FK to Menu — cascade deletes when menu is removed
+	Menu         Menu                    `gorm:"foreignKey:MenuID" json:"-"`
+	ProductID    uint                    `gorm:"not null" json:"product_id"` // FK to Products
+	Product      Products                `gorm:"foreignKey:ProductID" json:"-"`
+	Quantity     uint                    `gorm:"not null;default:1" json:"quantity"`                                                // How many of this product are in the menu
+	IsOptional   bool                    `gorm:"default:false" json:"is_optional"`                                                  // Whether the product can be swapped out (see Substitutes)
+	DisplayOrder uint                    `gorm:"default:0" json:"display_order"`                                                    // Controls display order in the frontend
+	Substitutes  []MenuProductSubstitute `gorm:"foreignKey:MenuProductID;constraint:OnDelete:CASCADE" json:"substitutes,omitempty"` // Allowed replacements (optional components only)
+}
+
+// MenuProductSubstitute is an allowed replacement for an optional menu component, with its price difference
+// (e.g. a salad instead of the fries of a menu, +0.50). The substitutes of a component form its substitution group.
+type MenuProductSubstitute struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	MenuProductID uint        `gorm:"not null;index" json:"menu_product_id"` // FK to MenuProduct — the component that can be replaced
+	MenuProduct   MenuProduct `gorm:"foreignKey:MenuProductID" json:"-"`
+	ProductID     uint        `gorm:"not null" json:"product_id"`                                // FK to Products — the replacement product
+	Product       Products    `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"-"` // Cascade: a substitute means nothing without its product
+	PriceDelta    Money       `gorm:"not null;default:0" json:"price_delta"`                     // Added to the menu price when chosen (may be negative)
 }
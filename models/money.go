@@ -0,0 +1,187 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in euro cents. Prices and totals are stored and added up as integers,
+// so sums are exact (0.10 + 0.20 is 0.30, never 0.30000000000000004).
+// In JSON it reads and writes euros with two decimals (9.99), like the float prices it replaces.
+//
+// Rounding rule: whenever an amount has more than two decimals (a price typed as 1.005,
+// or a percentage of a price), it is rounded to the nearest cent, halves away from zero:
+// 1.005 → 1.01, 1.004 → 1.00, -1.005 → -1.01.
+type Money int64
+
+// ErrInvalidMoney is returned when a text is not a decimal amount.
+var ErrInvalidMoney = errors.New("invalid amount")
+
+// FromEuros converts a euro amount to Money, rounding to the nearest cent (halves away from zero).
+// The float is read through its shortest decimal form, so 1.005 rounds to 1.01 even though
+// the float itself is slightly below 1.005.
+func FromEuros(euros float64) Money {
+	m, _ := ParseMoney(strconv.FormatFloat(euros, 'f', -1, 64))
+	return m
+}
+
+// ParseMoney reads a decimal euro amount such as "9.99", "-0.5" or "12",
+// rounding to the nearest cent (halves away from zero).
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w '%s'", ErrInvalidMoney, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	euros, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || euros >= math.MaxInt64/100 {
+		return 0, fmt.Errorf("%w '%s'", ErrInvalidMoney, s)
+	}
+	cents, _ := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+	cents += euros * 100
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		cents++ // the third decimal decides, halves going away from zero
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// isDigits reports whether s only holds ASCII digits (an empty string does).
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Times multiplies an amount by a quantity.
+func (m Money) Times(quantity uint) Money {
+	return m * Money(quantity)
+}
+
+// divRound divides a by b (b > 0), rounding to the nearest integer, halves away from zero.
+func divRound(a, b int64) int64 {
+	if a < 0 {
+		return -divRound(-a, b)
+	}
+	return (2*a + b) / (2 * b)
+}
+
+// Percent returns the given share of an amount, for a rate in basis points (1000 = 10%),
+// rounded to the cent (halves away from zero).
+func (m Money) Percent(rate uint) Money {
+	return Money(divRound(int64(m)*int64(rate), 10000))
+}
+
+// SplitTax splits an amount that includes VAT into its net amount and its VAT,
+// for a rate in basis points (1000 = 10%). The net amount is rounded to the cent and
+// the VAT is the rest, so net + tax is always the original amount.
+func (m Money) SplitTax(rate uint) (net, tax Money) {
+	net = Money(divRound(int64(m)*10000, 10000+int64(rate)))
+	return net, m - net
+}
+
+// Allocate splits an amount into parts proportional to weights, to the cent. Cents left over
+// by rounding down go to the parts with the largest remainders (the first ones on a tie),
+// so the parts always add up to the amount. Without any positive weight the amount is split evenly.
+func (m Money) Allocate(weights []int64) []Money {
+	parts := make([]Money, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+	if m < 0 {
+		for i, part := range (-m).Allocate(weights) {
+			parts[i] = -part
+		}
+		return parts
+	}
+
+	var total int64
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	if total == 0 {
+		even := make([]int64, len(weights))
+		for i := range even {
+			even[i] = 1
+		}
+		return m.Allocate(even)
+	}
+
+	remainders := make([]int64, len(weights))
+	left := m
+	for i, w := range weights {
+		share := int64(m) * max(w, 0)
+		parts[i] = Money(share / total)
+		remainders[i] = share % total
+		left -= parts[i]
+	}
+	for ; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+	return parts
+}
+
+// String formats the amount in euros with two decimals, e.g. "9.99" or "-0.50".
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON writes the amount as a JSON number in euros, e.g. 9.99.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number (or a string holding one) in euros.
+// The decimal text is parsed directly, never through a float, and rounded to the cent.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	text := strings.Trim(string(data), `"`)
+	if strings.ContainsAny(text, "eE") {
+		// Exponent notation (e.g. 1e2) only comes from machine-generated JSON: go through a float
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("%w '%s'", ErrInvalidMoney, text)
+		}
+		*m = FromEuros(f)
+		return nil
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"9.99":   999,
+		"12":     1200,
+		"0.5":    50,
+		".5":     50,
+		"+3.10":  310,
+		"-0.50":  -50,
+		"1.005":  101, // halves round away from zero
+		"1.0049": 100,
+		"2.675":  268,
+		"-1.005": -101,
+		"0.001":  0,
+	}
+	for text, want := range cases {
+		got, err := ParseMoney(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, want, got, text)
+	}
+
+	for _, text := range []string{"", "-", ".", "abc", "1,50", "1.2.3", "--1", "99999999999999999999"} {
+		_, err := ParseMoney(text)
+		assert.ErrorIs(t, err, ErrInvalidMoney, text)
+	}
+}
+
+func TestFromEuros(t *testing.T) {
+	// These floats sit just below the half cent; they still round up
+	assert.Equal(t, Money(101), FromEuros(1.005))
+	assert.Equal(t, Money(268), FromEuros(2.675))
+	assert.Equal(t, Money(-101), FromEuros(-1.005))
+	assert.Equal(t, Money(1999), FromEuros(19.99))
+	assert.Equal(t, Money(30), FromEuros(0.1+0.2))
+}
+
+func TestMoneySums(t *testing.T) {
+	// Ten 0.10 items make exactly 1.00, which float64 gets wrong
+	var total Money
+	floatTotal := 0.0
+	for i := 0; i < 10; i++ {
+		total += FromEuros(0.10)
+		floatTotal += 0.10
+	}
+	assert.Equal(t, Money(100), total)
+	assert.NotEqual(t, 1.0, floatTotal)
+
+	// 3 x (5.99 + 0.20 + 0.50) and 19.99 x 3
+	assert.Equal(t, "20.07", (FromEuros(5.99) + FromEuros(0.20) + FromEuros(0.50)).Times(3).String())
+	assert.Equal(t, "59.97", FromEuros(19.99).Times(3).String())
+	assert.Equal(t, "-0.05", Money(-5).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var item struct {
+		Price  Money  `json:"price"`
+		Delta  Money  `json:"delta"`
+		Total  Money  `json:"total"`
+		Absent *Money `json:"absent"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 9.99, "delta": "-0.5", "total": 1.005, "absent": null}`), &item))
+	assert.Equal(t, Money(999), item.Price)
+	assert.Equal(t, Money(-50), item.Delta)
+	assert.Equal(t, Money(101), item.Total)
+	assert.Nil(t, item.Absent)
+
+	out, err := json.Marshal(item)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price": 9.99, "delta": -0.50, "total": 1.01, "absent": null}`, string(out))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": true}`), &item))
+}
+
+func TestPercent(t *testing.T) {
+	assert.Equal(t, Money(450), Money(900).Percent(5000)) // half of 9.00
+	assert.Equal(t, Money(100), Money(999).Percent(1000)) // 0.999 rounds up
+	assert.Equal(t, Money(13), Money(25).Percent(5000))   // 0.125 rounds half away from zero
+	assert.Equal(t, Money(-13), Money(-25).Percent(5000))
+	assert.Equal(t, Money(999), Money(999).Percent(10000))
+	assert.Equal(t, Money(0), Money(999).Percent(0))
+}
+
+func TestSplitTax(t *testing.T) {
+	cases := []struct {
+		gross    Money
+		rate     uint
+		net, tax Money
+	}{
+		{1100, 1000, 1000, 100}, // 11.00 at 10%
+		{999, 1000, 908, 91},    // 9.0818… rounds down
+		{1055, 550, 1000, 55},   // 10.55 at 5.5%
+		{5, 2000, 4, 1},         // 0.041666… rounds to 0.04
+		{3, 1000, 3, 0},         // 0.0272… rounds to 0.03: the VAT of a tiny amount can be zero
+		{21, 500, 20, 1},        // 0.20 exactly
+		{1000, 0, 1000, 0},
+		{-1100, 1000, -1000, -100},
+	}
+	for _, tc := range cases {
+		net, tax := tc.gross.SplitTax(tc.rate)
+		assert.Equal(t, tc.net, net, "%v at %d", tc.gross, tc.rate)
+		assert.Equal(t, tc.tax, tax, "%v at %d", tc.gross, tc.rate)
+		assert.Equal(t, tc.gross, net+tax)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	// 10.00 over three equal weights: the leftover cent goes to the first part
+	assert.Equal(t, []Money{334, 333, 333}, Money(1000).Allocate([]int64{1, 1, 1}))
+	// Proportional to component prices 5.00, 2.00 and 2.50
+	assert.Equal(t, []Money{473, 189, 237}, Money(899).Allocate([]int64{500, 200, 250}))
+	// Largest remainder wins the cent
+	assert.Equal(t, []Money{1, 2}, Money(3).Allocate([]int64{2, 3}))
+	// No weight: even split
+	assert.Equal(t, []Money{50, 50}, Money(100).Allocate([]int64{0, 0}))
+	assert.Equal(t, []Money{-334, -333, -333}, Money(-1000).Allocate([]int64{1, 1, 1}))
+	assert.Empty(t, Money(100).Allocate(nil))
+
+	for _, amount := range []Money{1, 7, 899, 1999, 100001} {
+		var sum Money
+		for _, part := range amount.Allocate([]int64{3, 7, 11, 0}) {
+			sum += part
+		}
+		assert.Equal(t, amount, sum, "%v", amount)
+	}
+}
@@ -2,46 +2,119 @@ package models
 
 import "time"
 
+// ServiceMode tells whether an order is eaten on site or taken away. VAT rates depend on it.
+type ServiceMode string
+
+const (
+	ServiceOnSite   ServiceMode = "on_site"
+	ServiceTakeaway ServiceMode = "takeaway"
+)
+
+// Valid reports whether m is one of the known service modes.
+func (m ServiceMode) Valid() bool {
+	return m == ServiceOnSite || m == ServiceTakeaway
+}
+
 // Order represents a customer order taken by a staff member (accueil or admin).
 // Staff members create orders on behalf of customers — there is no self-service kiosk mode.
 // Status follows a state machine: pending → preparing → prepared → delivered (cancel only from pending).
 type Order struct {
-	ID            uint        `gorm:"primaryKey" json:"id"`
-	CustomerID    *uint       `json:"customer_id"`                                                       // Optional FK to Customer — counter orders may have no customer
-	Customer      Customer    `gorm:"foreignKey:CustomerID" json:"customer"`                              // Preloaded customer
-	CreatedByID   uint        `gorm:"not null" json:"created_by_id"`                                     // FK to Users — the staff member who created the order
-	CreatedBy     Users       `gorm:"foreignKey:CreatedByID" json:"created_by"`                           // Preloaded staff user
-	OrderType     string      `gorm:"not null" json:"order_type"`                                        // "counter" (walk-in) or "phone" (call-in)
-	Status        string      `gorm:"not null;default:pending" json:"status"`                             // pending, preparing, prepared, delivered, cancelled
-	Notes         string      `json:"notes"`                                                              // Free-text notes for the kitchen
-	ScheduledTime *time.Time  `json:"scheduled_time"`                                                    // Requested delivery time, used for preparation sorting
-	TotalPrice    float64     `gorm:"not null;default:0" json:"total_price"`                              // Server-computed total (sum of all item totals)
-	OrderItems    []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items"` // Line items in this order
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	CustomerID    *uint           `json:"customer_id"`                                                       // Optional FK to Customer — counter orders may have no customer
+	Customer      Customer        `gorm:"foreignKey:CustomerID" json:"customer"`                             // Preloaded customer
+	CreatedByID   uint            `gorm:"not null" json:"created_by_id"`                                     // FK to Users — the staff member who created the order
+	CreatedBy     Users           `gorm:"foreignKey:CreatedByID" json:"created_by"`                          // Preloaded staff user
+	OrderType     string          `gorm:"not null" json:"order_type"`                                        // "counter" (walk-in) or "phone" (call-in)
+	Status        string          `gorm:"not null;default:pending" json:"status"`                            // pending, preparing, prepared, delivered, cancelled
+	Notes         string          `json:"notes"`                                                             // Free-text notes for the kitchen
+	ScheduledTime *time.Time      `json:"scheduled_time"`                                                    // Requested delivery time, used for preparation sorting
+	ServiceMode   ServiceMode     `gorm:"not null;default:on_site;size:20" json:"service_mode"`              // "on_site" or "takeaway", decides the VAT rates
+	TotalPrice    Money           `gorm:"not null;default:0" json:"total_price"`                             // Server-computed total (item totals minus discounts, VAT included)
+	DiscountTotal Money           `gorm:"not null;default:0" json:"discount_total"`                          // Sum of the discount lines
+	Discounts     []OrderDiscount `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"discounts"`   // Promotions applied to this order
+	TaxLines      []OrderTaxLine  `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"tax_lines"`   // VAT summary per rate
+	OrderItems    []OrderItem     `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order_items"` // Line items in this order
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 // OrderItem is a single line in an order. Each item references either a Product or a Menu (exactly one, never both).
 // Prices are captured at order time so they are immutable even if the product price changes later.
+// Items are prepared independently (e.g. fries at the fryer, burgers at the grill); the order becomes
+// "prepared" once all of its items are done.
 type OrderItem struct {
-	ID               uint              `gorm:"primaryKey" json:"id"`
-	OrderID          uint              `gorm:"not null" json:"order_id"`                                              // FK to Order
-	ProductID        *uint             `json:"product_id"`                                                            // FK to Products (nil if this item is a menu)
-	Product          Products          `gorm:"foreignKey:ProductID" json:"product"`
-	MenuID           *uint             `json:"menu_id"`                                                               // FK to Menu (nil if this item is a product)
-	Menu             Menu              `gorm:"foreignKey:MenuID" json:"menu"`
-	Quantity         uint              `gorm:"not null;default:1" json:"quantity"`                                    // Number of this item ordered
-	UnitPrice        float64           `gorm:"not null" json:"unit_price"`                                           // Price per unit at order time (product price or menu price)
-	ItemTotal        float64           `gorm:"not null" json:"item_total"`                                           // (UnitPrice + option prices) * Quantity
-	OrderItemOptions []OrderItemOption `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"order_item_options"` // Selected options for this item
+	ID               uint                    `gorm:"primaryKey" json:"id"`
+	OrderID          uint                    `gorm:"not null" json:"order_id"` // FK to Order
+	ProductID        *uint                   `json:"product_id"`               // FK to Products (nil if this item is a menu)
+	Product          Products                `gorm:"foreignKey:ProductID" json:"product"`
+	MenuID           *uint                   `json:"menu_id"` // FK to Menu (nil if this item is a product)
+	Menu             Menu                    `gorm:"foreignKey:MenuID" json:"menu"`
+	Quantity         uint                    `gorm:"not null;default:1" json:"quantity"`                                           // Number of this item ordered
+	UnitPrice        Money                   `gorm:"not null" json:"unit_price"`                                                   // Price per unit at order time (product price or menu price)
+	ItemTotal        Money                   `gorm:"not null" json:"item_total"`                                                   // (UnitPrice + option prices) * Quantity, VAT included
+	NetTotal         Money                   `gorm:"not null;default:0" json:"net_total"`                                          // ItemTotal without VAT
+	TaxTotal         Money                   `gorm:"not null;default:0" json:"tax_total"`                                          // VAT included in ItemTotal
+	Discount         Money                   `gorm:"not null;default:0" json:"discount"`                                           // Part of the order's discounts taken off this item
+	Status           string                  `gorm:"not null;default:pending;size:20" json:"status"`                               // Preparation progress of this item: pending, preparing, done
+	OrderItemOptions []OrderItemOption       `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"order_item_options"` // Selected options for this item
+	Substitutions    []OrderItemSubstitution `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"substitutions"`      // Menu components replaced on this item
+	TaxLines         []OrderItemTax          `gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE" json:"tax_lines"`          // VAT breakdown per rate (a menu may mix rates)
 }
 
 // OrderItemOption records a selected option value for an order item (e.g. "Large" size).
 // The price is captured at order time to preserve the price even if the option value changes later.
+// For a menu item, MenuProductID tells which component of the menu the option goes with (e.g. a large Coke).
 type OrderItemOption struct {
 	ID            uint         `gorm:"primaryKey" json:"id"`
-	OrderItemID   uint         `gorm:"not null" json:"order_item_id"`                    // FK to OrderItem
-	OptionValueID uint         `gorm:"not null" json:"option_value_id"`                  // FK to OptionValues
+	OrderItemID   uint         `gorm:"not null" json:"order_item_id"`   // FK to OrderItem
+	OptionValueID uint         `gorm:"not null" json:"option_value_id"` // FK to OptionValues
 	OptionValue   OptionValues `gorm:"foreignKey:OptionValueID" json:"option_value"`
-	PriceApplied  float64      `gorm:"not null" json:"price_applied"`                    // Option price snapshot at order time
+	PriceApplied  Money        `gorm:"not null" json:"price_applied"` // Option price snapshot at order time
+	MenuProductID *uint        `json:"menu_product_id"`               // FK to MenuProduct — the menu component this option applies to (menu items only)
+}
+
+// OrderItemSubstitution records a menu component replaced by another product on a menu order item.
+// The price difference is captured at order time and included in the item total.
+type OrderItemSubstitution struct {
+	ID            uint     `gorm:"primaryKey" json:"id"`
+	OrderItemID   uint     `gorm:"not null;index" json:"order_item_id"` // FK to OrderItem
+	MenuProductID uint     `gorm:"not null" json:"menu_product_id"`     // FK to MenuProduct — the replaced component
+	ProductID     uint     `gorm:"not null" json:"product_id"`          // FK to Products — the product served instead
+	Product       Products `gorm:"foreignKey:ProductID" json:"product"` // Preloaded replacement product
+	PriceDelta    Money    `gorm:"not null" json:"price_delta"`         // Price difference snapshot at order time
+}
+
+// OrderItemTax is the part of an order item's price taxed at one VAT rate, captured at order time.
+// A product has a single line; a menu has one line per rate of its components.
+type OrderItemTax struct {
+	ID          uint  `gorm:"primaryKey" json:"id"`
+	OrderItemID uint  `gorm:"not null;index" json:"order_item_id"` // FK to OrderItem
+	Rate        uint  `gorm:"not null" json:"rate"`                // VAT rate in basis points (1000 = 10%)
+	Net         Money `gorm:"not null" json:"net"`                 // Amount without VAT
+	Tax         Money `gorm:"not null" json:"tax"`                 // VAT amount
+	Gross       Money `gorm:"not null" json:"gross"`               // Amount with VAT (Net + Tax)
+}
+
+// OrderTaxLine is the VAT summary of an order for one rate, as printed on the receipt.
+// It is the sum of the order items' lines for that rate, less the discounts taken off them.
+type OrderTaxLine struct {
+	ID      uint  `gorm:"primaryKey" json:"id"`
+	OrderID uint  `gorm:"not null;index" json:"order_id"` // FK to Order
+	Rate    uint  `gorm:"not null" json:"rate"`           // VAT rate in basis points (1000 = 10%)
+	Net     Money `gorm:"not null" json:"net"`
+	Tax     Money `gorm:"not null" json:"tax"`
+	Gross   Money `gorm:"not null" json:"gross"`
+}
+
+// OrderStatusEvent records one status change of an order: who made it and when.
+// It is written in the same transaction as the change, so the history of an order is complete
+// from its creation (FromStatus empty) to its current status.
+type OrderStatusEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OrderID    uint      `gorm:"not null;index" json:"order_id"`          // FK to Order
+	FromStatus string    `gorm:"size:20" json:"from_status"`              // Status before the change (empty for creation)
+	ToStatus   string    `gorm:"not null;size:20" json:"to_status"`       // Status after the change
+	UserID     *uint     `json:"user_id"`                                 // FK to Users — staff member behind the change
+	User       *Users    `gorm:"foreignKey:UserID" json:"user,omitempty"` // Preloaded staff user
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`                 // When the change happened
 }
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Permissions granted to roles. Routes require one of them (see middlewares.RequirePermission);
+// reading the catalog and the orders only requires being signed in.
+const (
+	PermAll              = "*" // Every permission, including ones added later
+	PermUsersManage      = "users:manage"
+	PermRolesManage      = "roles:manage"
+	PermCatalogWrite     = "catalog:write"
+	PermStockManage      = "stock:manage"
+	PermPromotionsWrite  = "promotions:write"
+	PermPromotionsPrev   = "promotions:preview"
+	PermCustomersManage  = "customers:manage"
+	PermOrdersCreate     = "orders:create"
+	PermOrdersItemStatus = "orders:items:status"
+	PermOrdersStreamAll  = "orders:stream:all"
+	PermKitchenView      = "kitchen:view"
+	PermReportsView      = "reports:view"
+)
+
+// OrderStatusPermission is the permission to move an order to a status (e.g. "orders:status:prepared").
+// The order workflow names it on each transition.
+func OrderStatusPermission(status string) string {
+	return "orders:status:" + status
+}
+
+// PermissionInfo describes a permission for the admin screens.
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// KnownPermissions lists every permission a role can be granted.
+var KnownPermissions = []PermissionInfo{
+	{PermUsersManage, "Create, view, deactivate, delete users and reset their passwords"},
+	{PermRolesManage, "Create and delete roles and set their permissions"},
+	{PermCatalogWrite, "Edit products, categories, options, menus, schedules and prices; import and export the catalog"},
+	{PermStockManage, "Adjust stock and view stock movements"},
+	{PermPromotionsWrite, "Create, edit and delete promotions"},
+	{PermPromotionsPrev, "Preview the discounts of an order"},
+	{PermCustomersManage, "Create, view, edit and delete customers and view their orders"},
+	{PermOrdersCreate, "Create, quote and edit pending orders"},
+	{OrderStatusPermission("preparing"), "Start preparing an order"},
+	{OrderStatusPermission("prepared"), "Mark an order as prepared"},
+	{OrderStatusPermission("delivered"), "Hand an order over to the customer"},
+	{OrderStatusPermission("cancelled"), "Cancel a pending order"},
+	{PermOrdersItemStatus, "Track the preparation of order items"},
+	{PermOrdersStreamAll, "Receive every order event on the live stream"},
+	{PermKitchenView, "View the kitchen queue"},
+	{PermReportsView, "View the operational reports"},
+}
+
+// DefaultRolePermissions maps the three seeded roles to their permissions. Admin has them all;
+// the counter takes and hands over orders, the kitchen prepares them.
+var DefaultRolePermissions = map[string]PermissionList{
+	"admin": {PermAll},
+	"accueil": {
+		PermCustomersManage, PermOrdersCreate, PermPromotionsPrev,
+		OrderStatusPermission("delivered"), OrderStatusPermission("cancelled"),
+	},
+	"preparation": {
+		OrderStatusPermission("preparing"), OrderStatusPermission("prepared"),
+		PermOrdersItemStatus, PermKitchenView,
+	},
+}
+
+// PermissionList is the set of permissions of a role. It is stored as a comma-separated list and
+// written to JSON as an array. Besides exact names it may hold "*" (everything) or a prefix
+// wildcard such as "orders:*" (every permission starting with "orders:").
+type PermissionList []string
+
+// Grants reports whether the list gives a permission, directly or through a wildcard.
+func (l PermissionList) Grants(permission string) bool {
+	for _, granted := range l {
+		if granted == PermAll || granted == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidPermission reports whether p can be granted: a known permission, "*", or a prefix
+// wildcard that covers at least one known permission.
+func ValidPermission(p string) bool {
+	if p == PermAll {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(p, "*")
+	for _, known := range KnownPermissions {
+		if known.Name == p || (wildcard && strings.HasSuffix(prefix, ":") && strings.HasPrefix(known.Name, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value stores the list as comma-separated text.
+func (l PermissionList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+// Scan reads the comma-separated text written by Value. Blank entries are dropped.
+func (l *PermissionList) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot read permissions from %T", value)
+	}
+
+	list := PermissionList{}
+	for _, p := range strings.Split(text, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	*l = list
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"wacdo/workflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionListGrants(t *testing.T) {
+	assert.True(t, PermissionList{PermAll}.Grants(PermRolesManage))
+	assert.True(t, PermissionList{PermKitchenView}.Grants(PermKitchenView))
+	assert.False(t, PermissionList{PermKitchenView}.Grants(PermReportsView))
+	assert.False(t, PermissionList{}.Grants(PermKitchenView))
+
+	orders := PermissionList{"orders:*"}
+	assert.True(t, orders.Grants(OrderStatusPermission("prepared")))
+	assert.True(t, orders.Grants(PermOrdersCreate))
+	assert.False(t, orders.Grants(PermCustomersManage))
+	assert.False(t, PermissionList{"orders:status:*"}.Grants(PermOrdersCreate))
+}
+
+func TestValidPermission(t *testing.T) {
+	for _, p := range []string{PermAll, PermCatalogWrite, OrderStatusPermission("cancelled"), "orders:*", "orders:status:*"} {
+		assert.True(t, ValidPermission(p), p)
+	}
+	for _, p := range []string{"", "catalog", "catalog:read", "orders:status:archived", "nothing:*", "ord*"} {
+		assert.False(t, ValidPermission(p), p)
+	}
+}
+
+func TestPermissionListValueScan(t *testing.T) {
+	list := PermissionList{PermOrdersCreate, PermKitchenView}
+	value, err := list.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "orders:create,kitchen:view", value)
+
+	var scanned PermissionList
+	assert.NoError(t, scanned.Scan([]byte(" orders:create, ,kitchen:view")))
+	assert.Equal(t, list, scanned)
+
+	assert.NoError(t, scanned.Scan(nil))
+	assert.Equal(t, PermissionList{}, scanned)
+	assert.Error(t, scanned.Scan(42))
+}
+
+func TestDefaultRolePermissionsAreKnown(t *testing.T) {
+	for role, permissions := range DefaultRolePermissions {
+		for _, p := range permissions {
+			assert.True(t, ValidPermission(p), "%s: %s", role, p)
+		}
+	}
+	// Every transition of the order workflow can be granted
+	for _, transition := range workflow.Orders.Transitions {
+		assert.True(t, ValidPermission(transition.Permission), transition.Permission)
+	}
+}
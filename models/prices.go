@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PriceChange is one entry of the price history of a product, a menu or an option value (exactly one of
+// ProductID, MenuID and OptionValueID is set): from EffectiveAt on, its price is Price.
+// A price changed through the update endpoints takes effect at once. A scheduled change is copied to the
+// catalog price once it comes due (AppliedAt records when), so order pricing picks it up without anyone
+// touching the catalog on the day.
+type PriceChange struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ProductID     *uint      `gorm:"index" json:"product_id"`
+	MenuID        *uint      `gorm:"index" json:"menu_id"`
+	OptionValueID *uint      `gorm:"index" json:"option_value_id"`
+	Price         Money      `gorm:"not null" json:"price"`              // Price from EffectiveAt on (cents, euros in JSON)
+	EffectiveAt   time.Time  `gorm:"not null;index" json:"effective_at"` // When the price starts to apply
+	AppliedAt     *time.Time `json:"applied_at"`                         // When the catalog price was set to it (null: not due yet)
+	CreatedByID   *uint      `json:"created_by_id"`                      // FK to Users (null for changes recorded by the system)
+	CreatedAt     time.Time  `json:"created_at"`
+}
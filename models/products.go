@@ -6,46 +6,91 @@ import (
 
 // Category groups products for display and filtering (e.g. "Burgers", "Drinks", "Desserts").
 type Category struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Name         string    `json:"name"`                // Unique display name
-	Description  string    `json:"description"`         // Short description for the kiosk UI
-	DisplayOrder uint      `json:"display_order"`       // Controls the display order in the frontend
-	ImageURL     string    `json:"image_url"`           // URL to the category image
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Name            string    `json:"name"`                                          // Unique display name
+	Description     string    `json:"description"`                                   // Short description for the kiosk UI
+	DisplayOrder    uint      `json:"display_order"`                                 // Controls the display order in the frontend
+	ImageURL        string    `json:"image_url"`                                     // URL to the category image
+	TaxRateOnSite   uint      `gorm:"not null;default:1000" json:"tax_rate_on_site"` // VAT rate in basis points (1000 = 10%) when eaten on site
+	TaxRateTakeaway uint      `gorm:"not null;default:550" json:"tax_rate_takeaway"` // VAT rate in basis points (550 = 5.5%) when taken away
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // Products represents a single orderable item (e.g. "Big Mac", "Coca-Cola").
 type Products struct {
-	ID              uint      `gorm:"primaryKey" json:"id"`
-	CategoryID      uint      `json:"category_id"`                         // FK to Category
-	Category        Category  `gorm:"foreignKey:CategoryID" json:"category"` // Preloaded category
-	Name            string    `json:"name"`                                // Unique product name
-	Description     string    `json:"description"`
-	Price           float64   `json:"price"`                               // Unit price in euros, used for order price calculation
-	StockQuantity   uint      `json:"stock_quantity"`                      // Available stock count
-	IsAvailable     bool      `json:"is_available"`                        // Unavailable products cannot be ordered
-	ImageURL        string    `json:"image_url"`                           // URL to the product image
-	PreparationTime uint      `json:"preparation_time"`                    // Estimated prep time in minutes
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                    uint          `gorm:"primaryKey" json:"id"`
+	CategoryID            uint          `json:"category_id"`                           // FK to Category
+	Category              Category      `gorm:"foreignKey:CategoryID" json:"category"` // Preloaded category
+	Name                  string        `json:"name"`                                  // Unique product name
+	Description           string        `json:"description"`
+	Price                 Money         `json:"price"`                                     // Unit price (cents, euros in JSON), used for order price calculation
+	StockQuantity         uint          `json:"stock_quantity"`                            // Available stock count
+	IsAvailable           bool          `json:"is_available"`                              // Unavailable products cannot be ordered
+	UnavailableReason     string        `gorm:"size:20" json:"unavailable_reason"`         // Why the product is off: "out_of_stock" (automatic) or "manual"
+	LowStockThreshold     uint          `json:"low_stock_threshold"`                       // Stock level at or below which the product is reported as low
+	ImageURL              string        `json:"image_url"`                                 // URL to the product image
+	PreparationTime       uint          `json:"preparation_time"`                          // Estimated prep time in minutes
+	TaxRateOnSite         *uint         `json:"tax_rate_on_site"`                          // VAT rate override in basis points (nil = category rate)
+	TaxRateTakeaway       *uint         `json:"tax_rate_takeaway"`                         // VAT rate override in basis points (nil = category rate)
+	EffectiveAvailability *Availability `gorm:"-" json:"effective_availability,omitempty"` // Whether it can be ordered now, schedules included (read endpoints only)
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+}
+
+// VAT rates are in basis points: 1000 = 10%. A new category gets the restaurant food rates,
+// 10% on site and 5.5% taken away.
+const (
+	DefaultTaxRateOnSite   = 1000
+	DefaultTaxRateTakeaway = 550
+	MaxTaxRate             = 10000 // 100%
+)
+
+// TaxRate returns the VAT rate of the product in basis points for a service mode:
+// the product's own rate if it overrides it, otherwise its category's. Category must be loaded.
+func (p Products) TaxRate(mode ServiceMode) uint {
+	if mode == ServiceTakeaway {
+		if p.TaxRateTakeaway != nil {
+			return *p.TaxRateTakeaway
+		}
+		return p.Category.TaxRateTakeaway
+	}
+	if p.TaxRateOnSite != nil {
+		return *p.TaxRateOnSite
+	}
+	return p.Category.TaxRateOnSite
+}
+
+// OptionSelection tells how many values of an option group can be picked for one item.
+type OptionSelection string
+
+const (
+	OptionSingle   OptionSelection = "single"   // Pick at most one value (e.g. a size)
+	OptionMultiple OptionSelection = "multiple" // Pick any number of distinct values (e.g. toppings)
+)
+
+// Valid reports whether s is one of the known selection modes.
+func (s OptionSelection) Valid() bool {
+	return s == OptionSingle || s == OptionMultiple
 }
 
 // ProductOptions defines a customization group for a product (e.g. "Size", "Toppings").
+// Orders must pick a value of every required group, at most one value of a single group,
+// and never the same value twice.
 type ProductOptions struct {
-	ID         uint     `gorm:"primaryKey" json:"id"`
-	ProductID  uint     `json:"product_id"`                  // FK to Products
-	Product    Products `gorm:"foreignKey:ProductID" json:"-"`
-	Name       string   `json:"name"`                        // Option group name (e.g. "Size")
-	IsUnique   string   `json:"is_unique"`                   // "single" = pick one, "multiple" = pick many
-	IsRequired bool     `json:"is_required"`                 // Whether the customer must select a value
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ProductID  uint            `json:"product_id"` // FK to Products
+	Product    Products        `gorm:"foreignKey:ProductID" json:"-"`
+	Name       string          `json:"name"`                     // Option group name (e.g. "Size")
+	IsUnique   OptionSelection `gorm:"size:10" json:"is_unique"` // "single" = pick one, "multiple" = pick many
+	IsRequired bool            `json:"is_required"`              // Whether the customer must select a value
 }
 
 // OptionValues represents one selectable value within a ProductOption (e.g. "Large" for "Size").
 type OptionValues struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
-	OptionID    uint           `json:"option_id"`                    // FK to ProductOptions
+	OptionID    uint           `json:"option_id"` // FK to ProductOptions
 	Option      ProductOptions `gorm:"foreignKey:OptionID" json:"-"`
-	Value       string         `json:"value"`                        // Display label (e.g. "Large")
-	OptionPrice float64        `json:"option_price"`                 // Additional cost added to the product price
+	Value       string         `json:"value"`        // Display label (e.g. "Large")
+	OptionPrice Money          `json:"option_price"` // Additional cost added to the product price
 }
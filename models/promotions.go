@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// PromotionType tells how a promotion computes its discount.
+type PromotionType string
+
+const (
+	PromotionPercentage PromotionType = "percentage"  // Percentage off the qualifying items
+	PromotionFixed      PromotionType = "fixed"       // Amount off each qualifying unit, or off the order when not scoped
+	PromotionBuyXGetY   PromotionType = "buy_x_get_y" // For every BuyQuantity qualifying units, GetQuantity more are discounted
+)
+
+// Valid reports whether t is one of the known promotion types.
+func (t PromotionType) Valid() bool {
+	return t == PromotionPercentage || t == PromotionFixed || t == PromotionBuyXGetY
+}
+
+// Promotion is a discount rule evaluated server-side when an order is priced (e.g. happy hour,
+// "2nd menu half price"). It applies to one product, one category or one menu, or to the whole
+// order when none is given, and only while active and within its time window.
+//
+// Time window: StartsAt/EndsAt bound the dates, and Window the days of the week and the hours
+// of the day (e.g. "17:00" to "19:00"). Empty fields do not restrict.
+type Promotion struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Name        string        `gorm:"not null" json:"name"` // Shown on the order's discount lines
+	Description string        `json:"description"`
+	Type        PromotionType `gorm:"not null;size:20" json:"type"` // percentage, fixed or buy_x_get_y
+	Percentage  uint          `json:"percentage"`                   // Discount in basis points (5000 = 50%); for buy_x_get_y, off the extra units (10000 = free, the default)
+	Amount      Money         `json:"amount"`                       // Fixed discount (fixed promotions only)
+	BuyQuantity uint          `json:"buy_quantity"`                 // buy_x_get_y: units to buy...
+	GetQuantity uint          `json:"get_quantity"`                 // ...to get this many more discounted (the cheapest ones)
+	ProductID   *uint         `json:"product_id"`                   // Scope: a product...
+	CategoryID  *uint         `json:"category_id"`                  // ...or the products of a category...
+	MenuID      *uint         `json:"menu_id"`                      // ...or a menu (none: the whole order)
+	StartsAt    *time.Time    `json:"starts_at"`                    // First moment the promotion applies
+	EndsAt      *time.Time    `json:"ends_at"`                      // Moment it stops applying
+	Window                    // Weekdays and daily hours it applies
+	IsActive    bool          `gorm:"not null" json:"is_active"` // Inactive promotions are never applied
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// OrderDiscount is a discount line of an order: what one promotion took off its total.
+// The promotion name and amount are captured when the order is priced.
+type OrderDiscount struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	OrderID     uint   `gorm:"not null;index" json:"order_id"`     // FK to Order
+	PromotionID uint   `gorm:"not null;index" json:"promotion_id"` // FK to Promotion
+	Name        string `gorm:"not null" json:"name"`               // Promotion name at order time
+	Amount      Money  `gorm:"not null" json:"amount"`             // Amount taken off the order (positive)
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Window limits something to days of the week and hours of the day, e.g. weekdays 07:00 to 10:30.
+// Empty fields do not restrict. A daily window may cross midnight ("22:00" to "02:00"); the day
+// checked is the one the moment falls on. Times are compared in the location of the moment given.
+type Window struct {
+	Weekdays  []time.Weekday `gorm:"serializer:json" json:"weekdays"` // Days it applies (0 = Sunday); empty means every day
+	StartTime string         `gorm:"size:5" json:"start_time"`        // Daily window start "HH:MM"
+	EndTime   string         `gorm:"size:5" json:"end_time"`          // Daily window end "HH:MM" (excluded)
+}
+
+// Contains reports whether a moment falls within the window.
+func (w Window) Contains(at time.Time) bool {
+	if len(w.Weekdays) > 0 && !slices.Contains(w.Weekdays, at.Weekday()) {
+		return false
+	}
+	if w.StartTime == "" || w.EndTime == "" {
+		return true
+	}
+	clock := at.Format("15:04")
+	if w.StartTime <= w.EndTime {
+		return w.StartTime <= clock && clock < w.EndTime
+	}
+	// The window crosses midnight, e.g. 22:00 to 02:00
+	return clock >= w.StartTime || clock < w.EndTime
+}
+
+// ValidClock reports whether s is a time of day written "HH:MM".
+func ValidClock(s string) bool {
+	_, err := time.Parse("15:04", s)
+	return err == nil && len(s) == 5
+}
+
+// ValidDate reports whether s is a day written "YYYY-MM-DD".
+func ValidDate(s string) bool {
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
+
+// AvailabilitySchedule restricts when a product, a menu or the products of a category can be ordered
+// (exactly one of ProductID, MenuID and CategoryID is set). Something with schedules can only be ordered
+// while at least one of them is open; something without any is not restricted.
+// Seasonal items use StartDate/EndDate (inclusive), e.g. a winter menu from "2026-12-01" to "2027-02-28".
+type AvailabilitySchedule struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	ProductID  *uint  `gorm:"index" json:"product_id"`
+	MenuID     *uint  `gorm:"index" json:"menu_id"`
+	CategoryID *uint  `gorm:"index" json:"category_id"`
+	Name       string `gorm:"size:50" json:"name"` // e.g. "Breakfast", "Late night"
+	Window
+	StartDate string    `gorm:"size:10" json:"start_date"` // First day "YYYY-MM-DD" (empty: no start)
+	EndDate   string    `gorm:"size:10" json:"end_date"`   // Last day "YYYY-MM-DD" (empty: no end)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Open reports whether the schedule is open at a moment, read in the moment's location.
+func (s AvailabilitySchedule) Open(at time.Time) bool {
+	day := at.Format(time.DateOnly)
+	if s.StartDate != "" && day < s.StartDate {
+		return false
+	}
+	if s.EndDate != "" && day > s.EndDate {
+		return false
+	}
+	return s.Window.Contains(at)
+}
+
+// SchedulesOpen reports whether something with the given schedules can be ordered at a moment:
+// always without schedules, otherwise while at least one of them is open.
+func SchedulesOpen(schedules []AvailabilitySchedule, at time.Time) bool {
+	if len(schedules) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(schedules, func(s AvailabilitySchedule) bool { return s.Open(at) })
+}
+
+// Why a product or menu cannot be ordered right now although its stored flag may say it can.
+const (
+	UnavailableOutsideSchedule          = "outside_schedule"           // Its own schedules are closed
+	UnavailableCategoryOutsideSchedule  = "category_outside_schedule"  // Its category's schedules are closed
+	UnavailableComponentOutsideSchedule = "component_outside_schedule" // A product of the menu is outside its schedules
+)
+
+// Availability tells whether a product or menu can be ordered at the time it was computed,
+// combining the stored flag (IsAvailable) with the availability schedules.
+type Availability struct {
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // out_of_stock, manual, outside_schedule, category_outside_schedule or component_outside_schedule
+}
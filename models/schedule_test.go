@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidClock(t *testing.T) {
+	assert.True(t, ValidClock("07:30"))
+	assert.True(t, ValidClock("23:59"))
+	assert.False(t, ValidClock("7:30"))
+	assert.False(t, ValidClock("24:00"))
+	assert.False(t, ValidClock("noon"))
+}
+
+func TestValidDate(t *testing.T) {
+	assert.True(t, ValidDate("2026-12-01"))
+	assert.False(t, ValidDate("2026-02-30"))
+	assert.False(t, ValidDate("01/12/2026"))
+}
+
+func TestScheduleOpen(t *testing.T) {
+	// Saturday 28 February 2026, 08:00
+	at := time.Date(2026, 2, 28, 8, 0, 0, 0, time.UTC)
+
+	breakfast := AvailabilitySchedule{Window: Window{StartTime: "07:00", EndTime: "10:30"}}
+	assert.True(t, breakfast.Open(at))
+	assert.False(t, breakfast.Open(at.Add(3*time.Hour)), "11:00")
+
+	winter := AvailabilitySchedule{StartDate: "2025-12-01", EndDate: "2026-02-28"}
+	assert.True(t, winter.Open(at), "the end date is included")
+	assert.True(t, winter.Open(at.Add(15*time.Hour)), "23:00 on the last day")
+	assert.False(t, winter.Open(at.AddDate(0, 0, 1)))
+	assert.False(t, winter.Open(time.Date(2025, 11, 30, 23, 59, 0, 0, time.UTC)))
+
+	weekendBrunch := AvailabilitySchedule{StartDate: "2026-01-01", Window: Window{Weekdays: []time.Weekday{time.Saturday, time.Sunday}, StartTime: "10:00", EndTime: "14:00"}}
+	assert.False(t, weekendBrunch.Open(at), "too early")
+	assert.True(t, weekendBrunch.Open(at.Add(3*time.Hour)))
+	assert.False(t, weekendBrunch.Open(at.AddDate(0, 0, 2).Add(3*time.Hour)), "Monday")
+
+	// The day and time are read in the moment's location
+	paris := time.FixedZone("CET", 3600)
+	assert.False(t, breakfast.Open(time.Date(2026, 2, 28, 9, 45, 0, 0, time.UTC).In(paris)), "10:45 in Paris")
+}
+
+func TestSchedulesOpen(t *testing.T) {
+	at := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	breakfast := AvailabilitySchedule{Window: Window{StartTime: "07:00", EndTime: "10:30"}}
+	lunch := AvailabilitySchedule{Window: Window{StartTime: "11:30", EndTime: "14:00"}}
+
+	assert.True(t, SchedulesOpen(nil, at), "no schedule, no restriction")
+	assert.False(t, SchedulesOpen([]AvailabilitySchedule{breakfast}, at))
+	assert.True(t, SchedulesOpen([]AvailabilitySchedule{breakfast, lunch}, at), "any open schedule is enough")
+}
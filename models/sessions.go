@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Reasons a session was revoked.
+const (
+	SessionLogout          = "logout"
+	SessionUserDeactivated = "user_deactivated"
+	SessionUserDeleted     = "user_deleted"
+	SessionPasswordChanged = "password_changed"
+	SessionPasswordReset   = "password_reset"
+	SessionRefreshReused   = "refresh_reused" // A refresh token was presented twice: it may have been stolen
+)
+
+// Session is a signed-in device of a user, opened at login. Access tokens carry its ID and are refused
+// once it is revoked, so logging out or deactivating a user takes effect at once rather than when the
+// tokens expire.
+type Session struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"` // FK to Users
+	User          Users      `gorm:"foreignKey:UserID" json:"-"`
+	UserAgent     string     `gorm:"size:255" json:"user_agent"`
+	IP            string     `gorm:"size:45" json:"ip"`
+	LastUsedAt    time.Time  `json:"last_used_at"`                  // Last login or refresh
+	RevokedAt     *time.Time `json:"revoked_at"`                    // Nil while the session is open
+	RevokedReason string     `gorm:"size:20" json:"revoked_reason"` // See the Session* constants
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RefreshToken is a single-use token exchanged for a new access token and a new refresh token.
+// Only its SHA-256 hash is stored. Presenting a token that was already used revokes its session.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	SessionID uint       `gorm:"not null;index" json:"session_id"` // FK to Session
+	Session   Session    `gorm:"foreignKey:SessionID" json:"-"`
+	TokenHash string     `gorm:"size:64;uniqueIndex" json:"-"` // Hex SHA-256 of the token
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Set when exchanged
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Stock movement reasons. Delivery, waste and correction are entered by staff;
+// sale, cancellation and order edit are written by the order workflow.
+const (
+	StockReasonDelivery     = "delivery"
+	StockReasonWaste        = "waste"
+	StockReasonSale         = "sale"
+	StockReasonCorrection   = "correction"
+	StockReasonCancellation = "cancellation"
+	StockReasonOrderEdit    = "order_edit" // Stock given back by the items of a pending order being edited
+)
+
+// Reasons recorded when a product or menu is switched off, so staff can tell
+// an item that ran out from one an admin disabled on purpose.
+const (
+	UnavailableOutOfStock = "out_of_stock"
+	UnavailableManual     = "manual"
+)
+
+// StockMovement is one entry of the stock ledger.
+// Every change to Products.StockQuantity writes a movement in the same transaction,
+// so the stock of a product always equals the sum of its movement deltas.
+type StockMovement struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ProductID   uint      `gorm:"not null;index" json:"product_id"` // FK to Products
+	Product     Products  `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"-"`
+	Delta       int       `gorm:"not null" json:"delta"`          // Signed stock change (negative = stock out)
+	Reason      string    `gorm:"not null;size:20" json:"reason"` // delivery, waste, sale, correction, cancellation, order_edit
+	Note        string    `gorm:"size:255" json:"note"`           // Optional free-text explanation
+	UserID      *uint     `json:"user_id"`                        // FK to Users — staff member behind the change (nil for system entries)
+	OrderID     *uint     `gorm:"index" json:"order_id"`          // FK to Order for movements written by orders
+	OrderItemID *uint     `json:"order_item_id"`                  // FK to OrderItem for movements written by orders
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
@@ -13,16 +13,17 @@ import (
 // Email uniqueness is enforced at the application level (not DB unique constraint) so that
 // a deleted user's email can be reused when creating a new account.
 type Users struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Username  string         `json:"username"`                          // Display name
-	Email     string         `gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email" binding:"required,email"` // Unique among non-deleted users
-	Password  string         `json:"-" binding:"required,min=6"`        // Bcrypt-hashed password, hidden from JSON output
-	RolesID   uint           `gorm:"not null" json:"roles_id"`          // FK to Roles — each user has exactly one role
-	Role      Roles          `gorm:"foreignKey:RolesID"`                // Preloaded role relationship
-	IsActive  bool           `gorm:"default:true" json:"is_active"`     // Deactivated users cannot log in
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                    // Soft delete timestamp — nil means active
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Username    string         `json:"username"`                                                                                          // Display name
+	Email       string         `gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email" binding:"required,email"` // Unique among non-deleted users
+	Password    string         `json:"-" binding:"required,min=6"`                                                                        // Bcrypt-hashed password, hidden from JSON output
+	RolesID     uint           `gorm:"not null" json:"roles_id"`                                                                          // FK to Roles — each user has exactly one role
+	Role        Roles          `gorm:"foreignKey:RolesID"`                                                                                // Preloaded role relationship
+	IsActive    bool           `gorm:"default:true" json:"is_active"`                                                                     // Deactivated users cannot log in
+	LockedUntil *time.Time     `json:"locked_until"`                                                                                      // Login refused until then after repeated failures; nil or past when not locked
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete timestamp — nil means active
 }
 
 // UserInput is the request body for creating a user.
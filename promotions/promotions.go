@@ -0,0 +1,157 @@
+// Package promotions evaluates discount rules (models.Promotion) against the items of an order.
+// It has no database or HTTP dependency: callers load the promotions and the priced items,
+// and store the discounts it returns.
+package promotions
+
+import (
+	"sort"
+	"time"
+	"wacdo/models"
+)
+
+// Line is an order item as seen by the promotions: what it is, how many units and what they cost.
+type Line struct {
+	ProductID  *uint
+	CategoryID uint // Category of the product (0 for a menu)
+	MenuID     *uint
+	Quantity   uint
+	Total      models.Money // Item total, VAT included
+}
+
+// Discount is what one promotion takes off an order.
+// Lines holds the part taken off each line, in the order of the lines given to Apply.
+type Discount struct {
+	Promotion models.Promotion
+	Amount    models.Money
+	Lines     []models.Money
+}
+
+// Active reports whether a promotion applies at a given time: it must be active and within its
+// date range, weekdays and daily hours (see models.Window). Times are compared in at's location.
+func Active(p models.Promotion, at time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !at.Before(*p.EndsAt) {
+		return false
+	}
+	return p.Window.Contains(at)
+}
+
+// Matches reports whether a line is in the scope of a promotion. A promotion without
+// product, category or menu covers every line.
+func Matches(p models.Promotion, line Line) bool {
+	switch {
+	case p.ProductID != nil:
+		return line.ProductID != nil && *line.ProductID == *p.ProductID
+	case p.CategoryID != nil:
+		return line.ProductID != nil && line.CategoryID == *p.CategoryID
+	case p.MenuID != nil:
+		return line.MenuID != nil && *line.MenuID == *p.MenuID
+	}
+	return true
+}
+
+// scoped reports whether a promotion is restricted to a product, a category or a menu.
+func scoped(p models.Promotion) bool {
+	return p.ProductID != nil || p.CategoryID != nil || p.MenuID != nil
+}
+
+// Apply evaluates promotions, in the given order, against the lines of an order at a given time.
+// Each promotion works on what the previous ones left, and never takes more off a line than
+// what is left of it, so the order total can never go below zero:
+//   - percentage takes its percentage off each qualifying line
+//   - fixed takes its amount off each qualifying unit, or once off the whole order when not scoped
+//     (shared between the lines in proportion to what is left of them)
+//   - buy_x_get_y discounts, for every BuyQuantity+GetQuantity qualifying units, the GetQuantity
+//     cheapest ones by its percentage
+//
+// Promotions that are inactive at that time or take nothing off are left out.
+func Apply(promos []models.Promotion, lines []Line, at time.Time) []Discount {
+	left := make([]models.Money, len(lines))
+	for i, line := range lines {
+		left[i] = line.Total
+	}
+
+	var discounts []Discount
+	for _, p := range promos {
+		if !Active(p, at) {
+			continue
+		}
+
+		parts := make([]models.Money, len(lines))
+		switch p.Type {
+		case models.PromotionPercentage:
+			for i, line := range lines {
+				if Matches(p, line) {
+					parts[i] = left[i].Percent(p.Percentage)
+				}
+			}
+		case models.PromotionFixed:
+			if scoped(p) {
+				for i, line := range lines {
+					if Matches(p, line) {
+						parts[i] = p.Amount.Times(line.Quantity)
+					}
+				}
+			} else {
+				weights := make([]int64, len(lines))
+				var total models.Money
+				for i := range lines {
+					weights[i] = int64(left[i])
+					total += left[i]
+				}
+				parts = min(p.Amount, total).Allocate(weights)
+			}
+		case models.PromotionBuyXGetY:
+			parts = buyXGetY(p, lines)
+		}
+
+		discount := Discount{Promotion: p, Lines: make([]models.Money, len(lines))}
+		for i, part := range parts {
+			part = max(min(part, left[i]), 0)
+			discount.Lines[i] = part
+			discount.Amount += part
+			left[i] -= part
+		}
+		if discount.Amount > 0 {
+			discounts = append(discounts, discount)
+		}
+	}
+	return discounts
+}
+
+// buyXGetY returns the discount of a buy_x_get_y promotion on each line: the qualifying units are
+// counted across lines, and the cheapest GetQuantity of every BuyQuantity+GetQuantity are discounted.
+func buyXGetY(p models.Promotion, lines []Line) []models.Money {
+	parts := make([]models.Money, len(lines))
+	if p.GetQuantity == 0 {
+		return parts
+	}
+	group := p.BuyQuantity + p.GetQuantity
+
+	type unit struct {
+		line  int
+		price models.Money
+	}
+	var units []unit
+	for i, line := range lines {
+		if line.Quantity == 0 || !Matches(p, line) {
+			continue
+		}
+		price := line.Total / models.Money(line.Quantity)
+		for range line.Quantity {
+			units = append(units, unit{line: i, price: price})
+		}
+	}
+
+	sort.SliceStable(units, func(i, j int) bool { return units[i].price < units[j].price })
+	discounted := uint(len(units)) / group * p.GetQuantity
+	for _, u := range units[:discounted] {
+		parts[u.line] += u.price.Percent(p.Percentage)
+	}
+	return parts
+}
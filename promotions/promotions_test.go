@@ -0,0 +1,139 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+	"wacdo/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr(v uint) *uint { return &v }
+
+// Wednesday 18:30
+var evening = time.Date(2026, 3, 4, 18, 30, 0, 0, time.UTC)
+
+func TestActive(t *testing.T) {
+	p := models.Promotion{IsActive: true}
+	assert.True(t, Active(p, evening))
+
+	p.IsActive = false
+	assert.False(t, Active(p, evening), "inactive")
+
+	happyHour := models.Promotion{IsActive: true, Window: models.Window{StartTime: "17:00", EndTime: "19:00"}}
+	assert.True(t, Active(happyHour, evening))
+	assert.False(t, Active(happyHour, evening.Add(time.Hour)), "19:30")
+	assert.False(t, Active(happyHour, evening.Add(30*time.Minute)), "the end is excluded")
+
+	lateNight := models.Promotion{IsActive: true, Window: models.Window{StartTime: "22:00", EndTime: "02:00"}}
+	assert.False(t, Active(lateNight, evening))
+	assert.True(t, Active(lateNight, evening.Add(4*time.Hour)), "23:30")
+	assert.True(t, Active(lateNight, evening.Add(7*time.Hour)), "01:30")
+
+	weekend := models.Promotion{IsActive: true, Window: models.Window{Weekdays: []time.Weekday{time.Saturday, time.Sunday}}}
+	assert.False(t, Active(weekend, evening))
+	assert.True(t, Active(weekend, evening.AddDate(0, 0, 3)))
+
+	from, to := evening.Add(-time.Hour), evening.Add(time.Hour)
+	dated := models.Promotion{IsActive: true, StartsAt: &from, EndsAt: &to}
+	assert.True(t, Active(dated, evening))
+	assert.False(t, Active(dated, to), "ends_at is excluded")
+	assert.False(t, Active(dated, from.Add(-time.Second)))
+}
+
+func TestApply_Percentage(t *testing.T) {
+	drinks := models.Promotion{ID: 1, Name: "Happy hour", Type: models.PromotionPercentage, Percentage: 2000, CategoryID: ptr(2), IsActive: true}
+	lines := []Line{
+		{ProductID: ptr(1), CategoryID: 1, Quantity: 1, Total: 500},
+		{ProductID: ptr(2), CategoryID: 2, Quantity: 2, Total: 499},
+		{MenuID: ptr(1), Quantity: 1, Total: 900},
+	}
+
+	discounts := Apply([]models.Promotion{drinks}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		assert.Equal(t, models.Money(100), discounts[0].Amount) // 20% of 4.99 = 0.998
+		assert.Equal(t, []models.Money{0, 100, 0}, discounts[0].Lines)
+	}
+
+	// Outside its window the promotion does nothing
+	drinks.Window = models.Window{StartTime: "11:00", EndTime: "14:00"}
+	assert.Empty(t, Apply([]models.Promotion{drinks}, lines, evening))
+}
+
+func TestApply_Fixed(t *testing.T) {
+	lines := []Line{
+		{MenuID: ptr(1), Quantity: 2, Total: 1800},
+		{ProductID: ptr(1), CategoryID: 1, Quantity: 1, Total: 300},
+	}
+
+	// Scoped: off each unit of the menu
+	perMenu := models.Promotion{ID: 1, Type: models.PromotionFixed, Amount: 150, MenuID: ptr(1), IsActive: true}
+	discounts := Apply([]models.Promotion{perMenu}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		assert.Equal(t, []models.Money{300, 0}, discounts[0].Lines)
+	}
+
+	// Not scoped: once off the order, shared in proportion to the lines
+	voucher := models.Promotion{ID: 2, Type: models.PromotionFixed, Amount: 500, IsActive: true}
+	discounts = Apply([]models.Promotion{voucher}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		assert.Equal(t, models.Money(500), discounts[0].Amount)
+		assert.Equal(t, []models.Money{429, 71}, discounts[0].Lines)
+	}
+
+	// Never more than the order is worth
+	voucher.Amount = 5000
+	discounts = Apply([]models.Promotion{voucher}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		assert.Equal(t, models.Money(2100), discounts[0].Amount)
+	}
+}
+
+func TestApply_BuyXGetY(t *testing.T) {
+	// Second menu half price
+	secondHalf := models.Promotion{ID: 1, Type: models.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1, Percentage: 5000, IsActive: true}
+	lines := []Line{
+		{MenuID: ptr(1), Quantity: 2, Total: 2000}, // 10.00 each
+		{MenuID: ptr(2), Quantity: 1, Total: 800},
+	}
+
+	discounts := Apply([]models.Promotion{secondHalf}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		// Three units make one full pair: the cheapest unit (8.00) is half price
+		assert.Equal(t, []models.Money{0, 400}, discounts[0].Lines)
+	}
+
+	lines = append(lines, Line{MenuID: ptr(2), Quantity: 1, Total: 800})
+	discounts = Apply([]models.Promotion{secondHalf}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		// Two pairs: both 8.00 menus are half price
+		assert.Equal(t, []models.Money{0, 400, 400}, discounts[0].Lines)
+	}
+
+	// Buy 2, get 1 free, on one product only
+	free := models.Promotion{ID: 2, Type: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Percentage: 10000, ProductID: ptr(7), IsActive: true}
+	lines = []Line{{ProductID: ptr(7), Quantity: 5, Total: 1000}, {ProductID: ptr(8), Quantity: 3, Total: 300}}
+	discounts = Apply([]models.Promotion{free}, lines, evening)
+	if assert.Len(t, discounts, 1) {
+		assert.Equal(t, []models.Money{200, 0}, discounts[0].Lines)
+	}
+}
+
+func TestApply_Stacking(t *testing.T) {
+	lines := []Line{{ProductID: ptr(1), CategoryID: 1, Quantity: 1, Total: 1000}}
+	promos := []models.Promotion{
+		{ID: 1, Type: models.PromotionPercentage, Percentage: 5000, IsActive: true},
+		{ID: 2, Type: models.PromotionPercentage, Percentage: 1000, IsActive: true},
+		{ID: 3, Type: models.PromotionFixed, Amount: 1000, ProductID: ptr(1), IsActive: true},
+		{ID: 4, Type: models.PromotionFixed, Amount: 100, IsActive: true},
+	}
+
+	discounts := Apply(promos, lines, evening)
+	// 50% of 10.00, then 10% of the remaining 5.00, then the fixed discount takes what is left;
+	// the last promotion finds nothing left and is not listed
+	if assert.Len(t, discounts, 3) {
+		assert.Equal(t, models.Money(500), discounts[0].Amount)
+		assert.Equal(t, models.Money(50), discounts[1].Amount)
+		assert.Equal(t, models.Money(450), discounts[2].Amount)
+	}
+}
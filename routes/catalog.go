@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CatalogRoutes(router *gin.Engine) {
+	// Bulk export and import: catalog editors
+	routesGroup := router.Group("/catalog")
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
+	{
+		routesGroup.GET("/export", controllers.ExportCatalog)
+		routesGroup.POST("/import", controllers.ImportCatalog)
+	}
+}
@@ -3,14 +3,15 @@ package routes
 import (
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func CustomerRoutes(router *gin.Engine) {
-	// Customer management: admin and accueil (accueil takes orders and needs customer data)
+	// Customer management: customers:manage (the counter takes orders and needs customer data)
 	routesGroup := router.Group("/customers")
-	routesGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin", "accueil"))
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCustomersManage))
 	{
 		routesGroup.POST("/", controllers.CreateCustomer)
 		routesGroup.GET("/", controllers.GetCustomers)
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func KitchenRoutes(router *gin.Engine) {
+	// Preparation queue: kitchen:view
+	routesGroup := router.Group("/kitchen")
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermKitchenView))
+	{
+		routesGroup.GET("/queue", controllers.GetKitchenQueue)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,11 +16,12 @@ func MenuRoutes(router *gin.Engine) {
 		readGroup.GET("/", controllers.GetMenus)
 		readGroup.GET("/:id", controllers.GetMenu)
 		readGroup.GET("/:id/products/", controllers.GetMenuProducts)
+		readGroup.GET("/products/:id/substitutes", controllers.GetMenuSubstitutes)
 	}
 
-	// Write access: admin only
+	// Write access: catalog:write
 	writeGroup := router.Group("/menus")
-	writeGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
 	{
 		writeGroup.POST("/", controllers.CreateMenu)
 		writeGroup.PUT("/:id", controllers.UpdateMenu)
@@ -27,5 +29,7 @@ func MenuRoutes(router *gin.Engine) {
 		writeGroup.PATCH("/:id/availability", controllers.ToggleMenuAvailability)
 		writeGroup.POST("/:id/products/", controllers.AddProductToMenu)
 		writeGroup.DELETE("/products/:id", controllers.RemoveProductFromMenu)
+		writeGroup.POST("/products/:id/substitutes", controllers.AddMenuSubstitute)
+		writeGroup.DELETE("/substitutes/:id", controllers.RemoveMenuSubstitute)
 	}
 }
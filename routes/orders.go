@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"wacdo/config"
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,28 +15,49 @@ func OrderRoutes(router *gin.Engine) {
 	viewGroup.Use(middlewares.Authentication())
 	{
 		viewGroup.GET("/", controllers.GetOrders)
+		viewGroup.GET("/stream", controllers.StreamOrders)
+		viewGroup.GET("/workflow", controllers.GetOrderWorkflow)
 		viewGroup.GET("/:id", controllers.GetOrder)
+		viewGroup.GET("/:id/history", controllers.GetOrderHistory)
 	}
 
-	// Create and cancel orders: admin + accueil
+	// Create and edit (while pending) orders: orders:create, with a looser rate limit
 	accueilGroup := router.Group("/orders")
-	accueilGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin", "accueil"))
-	{
+	config.RateLimitGroup(router, accueilGroup, "orders", middlewares.ClientKey, func() {
+		accueilGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermOrdersCreate))
 		accueilGroup.POST("/", controllers.CreateOrder)
-		accueilGroup.PATCH("/:id/cancel", controllers.CancelOrder)
+		accueilGroup.POST("/quote", controllers.QuoteOrder)
+		accueilGroup.PUT("/:id", controllers.UpdateOrder)
+		accueilGroup.POST("/:id/items", controllers.AddOrderItem)
+		accueilGroup.PATCH("/:id/items/:item_id", controllers.UpdateOrderItem)
+		accueilGroup.DELETE("/:id/items/:item_id", controllers.RemoveOrderItem)
+	})
+
+	// Cancel orders: orders:status:cancelled, like the cancelling transition of the workflow
+	cancelGroup := router.Group("/orders")
+	cancelGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.OrderStatusPermission("cancelled")))
+	{
+		cancelGroup.PATCH("/:id/cancel", controllers.CancelOrder)
 	}
 
-	// Update order status (preparing → prepared → delivered): admin + preparation + accueil
-	// Preparation marks as prepared, accueil marks as delivered
+	// Update order status: any authenticated user
+	// Which permission each transition needs is decided by the order workflow (workflow.Orders)
 	statusGroup := router.Group("/orders")
-	statusGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin", "preparation", "accueil"))
+	statusGroup.Use(middlewares.Authentication())
 	{
 		statusGroup.PATCH("/:id/status", controllers.UpdateOrderStatus)
 	}
 
-	// Customer orders: admin + accueil
+	// Update item preparation progress: orders:items:status
+	itemsGroup := router.Group("/orders")
+	itemsGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermOrdersItemStatus))
+	{
+		itemsGroup.PATCH("/:id/items/:item_id/status", controllers.UpdateOrderItemStatus)
+	}
+
+	// Customer orders: customers:manage
 	customersGroup := router.Group("/customers")
-	customersGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin", "accueil"))
+	customersGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCustomersManage))
 	{
 		customersGroup.GET("/:id/orders", controllers.GetOrdersByCustomer)
 	}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PriceRoutes(router *gin.Engine) {
+	// Read access: all roles (the counter may be asked what something cost)
+	readGroup := router.Group("/prices")
+	readGroup.Use(middlewares.Authentication())
+	{
+		readGroup.GET("/", controllers.GetPriceHistory)
+		readGroup.GET("/at", controllers.GetPriceAt)
+	}
+
+	// Write access: catalog:write
+	writeGroup := router.Group("/prices")
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
+	{
+		writeGroup.POST("/", controllers.SchedulePriceChange)
+		writeGroup.DELETE("/:id", controllers.CancelPriceChange)
+	}
+}
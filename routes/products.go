@@ -3,6 +3,7 @@ package routes
 import (
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,15 +18,22 @@ func ProductRoutes(router *gin.Engine) {
 		readGroup.GET("/category/:category_id", controllers.GetProductsByCategory)
 	}
 
-	// Write access: admin only
+	// Write access: catalog:write
 	writeGroup := router.Group("/products")
-	writeGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
 	{
 		writeGroup.POST("/", controllers.CreateProduct)
 		writeGroup.PUT("/:id", controllers.UpdateProduct)
 		writeGroup.DELETE("/:id", controllers.DeleteProduct)
 		writeGroup.PATCH("/:id/availability", controllers.ToggleProductAvailability)
-		writeGroup.PATCH("/:id/stock", controllers.UpdateProductStock)
+	}
+
+	// Stock adjustments: stock:manage
+	stockGroup := router.Group("/products")
+	stockGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermStockManage))
+	{
+		stockGroup.PATCH("/:id/stock", controllers.UpdateProductStock)
+		stockGroup.GET("/:id/stock/movements", controllers.GetProductStockMovements)
 	}
 }
 
@@ -38,9 +46,9 @@ func CategoriesRoutes(router *gin.Engine) {
 		readGroup.GET("/:id", controllers.GetCategory)
 	}
 
-	// Write access: admin only
+	// Write access: catalog:write
 	writeGroup := router.Group("/categories")
-	writeGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
 	{
 		writeGroup.POST("/", controllers.CreateCategory)
 		writeGroup.PUT("/:id", controllers.UpdateCategory)
@@ -58,9 +66,9 @@ func OptionRoutes(router *gin.Engine) {
 		readGroup.GET("/product/:product_id", controllers.GetOptionsByProduct)
 	}
 
-	// Write access: admin only
+	// Write access: catalog:write
 	writeGroup := router.Group("/options")
-	writeGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
 	{
 		writeGroup.POST("/", controllers.CreateOption)
 		writeGroup.PUT("/:id", controllers.UpdateOption)
@@ -77,9 +85,9 @@ func OptionValueRoutes(router *gin.Engine) {
 		readGroup.GET("/values/:id", controllers.GetOptionValue)
 	}
 
-	// Write access: admin only
+	// Write access: catalog:write
 	writeGroup := router.Group("/options")
-	writeGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
 	{
 		writeGroup.POST("/:id/values/", controllers.CreateOptionValue)
 		writeGroup.PUT("/values/:id", controllers.UpdateOptionValue)
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PromotionRoutes(router *gin.Engine) {
+	// Read access: all roles (the counter tells customers about current deals)
+	readGroup := router.Group("/promotions")
+	readGroup.Use(middlewares.Authentication())
+	{
+		readGroup.GET("/", controllers.GetPromotions)
+		readGroup.GET("/:id", controllers.GetPromotion)
+	}
+
+	// Price preview: promotions:preview, held by whoever takes orders
+	previewGroup := router.Group("/promotions")
+	previewGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermPromotionsPrev))
+	{
+		previewGroup.POST("/preview", controllers.PreviewPromotions)
+	}
+
+	// Write access: promotions:write
+	writeGroup := router.Group("/promotions")
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermPromotionsWrite))
+	{
+		writeGroup.POST("/", controllers.CreatePromotion)
+		writeGroup.PUT("/:id", controllers.UpdatePromotion)
+		writeGroup.DELETE("/:id", controllers.DeletePromotion)
+	}
+}
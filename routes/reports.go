@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReportRoutes(router *gin.Engine) {
+	// Operational reports: reports:view
+	routesGroup := router.Group("/reports")
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermReportsView))
+	{
+		routesGroup.GET("/stage-times", controllers.GetStageTimeReport)
+	}
+}
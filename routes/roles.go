@@ -3,18 +3,21 @@ package routes
 import (
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RolesRoutes(router *gin.Engine) {
-	// Role management is admin-only
+	// Role management: roles:manage
 	routesGroup := router.Group("/roles")
-	routesGroup.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermRolesManage))
 	{
 		routesGroup.GET("/", controllers.GetRoles)
+		routesGroup.GET("/permissions", controllers.GetPermissions)
 		routesGroup.GET("/:id", controllers.GetRole)
 		routesGroup.POST("/", controllers.CreateRole)
+		routesGroup.PATCH("/:id/permissions", controllers.UpdateRolePermissions)
 		routesGroup.DELETE("/:id", controllers.DeleteRole)
 	}
 }
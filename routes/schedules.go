@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ScheduleRoutes(router *gin.Engine) {
+	// Read access: all roles (the counter needs to know when items come back)
+	readGroup := router.Group("/schedules")
+	readGroup.Use(middlewares.Authentication())
+	{
+		readGroup.GET("/", controllers.GetSchedules)
+		readGroup.GET("/:id", controllers.GetSchedule)
+	}
+
+	// Write access: catalog:write
+	writeGroup := router.Group("/schedules")
+	writeGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermCatalogWrite))
+	{
+		writeGroup.POST("/", controllers.CreateSchedule)
+		writeGroup.PUT("/:id", controllers.UpdateSchedule)
+		writeGroup.DELETE("/:id", controllers.DeleteSchedule)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"wacdo/controllers"
+	"wacdo/middlewares"
+	"wacdo/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StockRoutes(router *gin.Engine) {
+	// Stock ledger and consistency checks: stock:manage
+	routesGroup := router.Group("/stock")
+	routesGroup.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermStockManage))
+	{
+		routesGroup.GET("/movements", controllers.GetStockMovements)
+		routesGroup.GET("/drift", controllers.GetStockDrift)
+		routesGroup.GET("/low", controllers.GetLowStockProducts)
+	}
+}
@@ -2,34 +2,41 @@
 package routes
 
 import (
+	"wacdo/config"
 	"wacdo/controllers"
 	"wacdo/middlewares"
+	"wacdo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func UsersRoutes(router *gin.Engine) {
+	// Login and token refresh: stricter rate limit, per client
 	public := router.Group("/users")
-	{
+	config.RateLimitGroup(router, public, "login", middlewares.ClientKey, func() {
 		public.POST("/login", controllers.Login)
-	}
+		public.POST("/refresh", controllers.RefreshSession)
+	})
 
-	// Password change — any authenticated user (controller enforces own-password-only for non-admins)
+	// Logout and password change — any authenticated user (controller enforces own-password-only without users:manage)
 	authenticated := router.Group("/users")
 	authenticated.Use(middlewares.Authentication())
 	{
+		authenticated.POST("/logout", controllers.Logout)
 		authenticated.PATCH("/:id/password", controllers.ChangePassword)
 	}
 
-	// User management is admin-only
+	// User management: users:manage
 	protected := router.Group("/users")
-	protected.Use(middlewares.Authentication(), middlewares.Authorization("admin"))
+	protected.Use(middlewares.Authentication(), middlewares.RequirePermission(models.PermUsersManage))
 	{
 		protected.POST("/", controllers.CreateUser)
 		protected.DELETE("/:id", controllers.DeleteUser)
 		protected.GET("/", controllers.GetUsers)
 		protected.GET("/:id", controllers.GetUser)
 		protected.PATCH("/:id/status", controllers.ToggleUserStatus)
+		protected.PATCH("/:id/role", controllers.UpdateUserRole)
+		protected.PATCH("/:id/unlock", controllers.UnlockUser)
 		protected.PATCH("/:id/reset-password", controllers.ResetPassword)
 	}
 }
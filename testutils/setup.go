@@ -16,6 +16,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultStock is the stock quantity given to products created by SeedProduct,
+// high enough that ordinary order tests never run out.
+const DefaultStock = 100
+
 // SetupTestDB creates an in-memory SQLite database and runs migrations.
 // It sets config.DB so controllers work without changes.
 func SetupTestDB() *gorm.DB {
@@ -33,12 +37,30 @@ func SetupTestDB() *gorm.DB {
 		&models.OptionValues{},
 		&models.Menu{},
 		&models.MenuProduct{},
+		&models.MenuProductSubstitute{},
 		&models.Customer{},
 		&models.Order{},
 		&models.OrderItem{},
 		&models.OrderItemOption{},
+		&models.OrderItemSubstitution{},
+		&models.OrderItemTax{},
+		&models.OrderTaxLine{},
+		&models.Promotion{},
+		&models.OrderDiscount{},
+		&models.AvailabilitySchedule{},
+		&models.PriceChange{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.LoginAttempt{},
+		&models.StockMovement{},
+		&models.OrderStatusEvent{},
 	)
 
+	// A single connection keeps every goroutine on the same in-memory database
+	// (each new SQLite connection to ":memory:" would open an empty one).
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
 	config.DB = db
 
 	// Set a test JWT secret
@@ -47,15 +69,22 @@ func SetupTestDB() *gorm.DB {
 	return db
 }
 
+// EnableForeignKeys makes SQLite enforce foreign keys, as PostgreSQL does, for tests that delete rows
+// other tables may reference. SQLite leaves them off by default.
+func EnableForeignKeys(db *gorm.DB) {
+	db.Exec("PRAGMA foreign_keys = ON")
+}
+
 // SetupRouter creates a gin engine in test mode.
 func SetupRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
 }
 
-// SeedRole creates a role in the test DB and returns it.
+// SeedRole creates a role in the test DB and returns it. The seeded role names ("admin", "accueil",
+// "preparation") get their default permissions; other names get none.
 func SeedRole(db *gorm.DB, name string) models.Roles {
-	role := models.Roles{RoleName: name, Description: name + " role"}
+	role := models.Roles{RoleName: name, Description: name + " role", Permissions: models.DefaultRolePermissions[name]}
 	db.Create(&role)
 	return role
 }
@@ -81,25 +110,43 @@ func SeedCategory(db *gorm.DB, name string) models.Category {
 	return cat
 }
 
-// SeedProduct creates a product in the test DB.
+// SeedProduct creates a product in the test DB with DefaultStock units in stock,
+// recorded in the stock ledger as an opening correction. price is in euros.
 func SeedProduct(db *gorm.DB, name string, price float64, categoryID uint, available bool) models.Products {
 	p := models.Products{
-		Name:        name,
-		Price:       price,
-		CategoryID:  categoryID,
-		IsAvailable: available,
+		Name:          name,
+		Price:         models.FromEuros(price),
+		CategoryID:    categoryID,
+		StockQuantity: DefaultStock,
+		IsAvailable:   available,
 	}
 	db.Create(&p)
+	db.Create(&models.StockMovement{ProductID: p.ID, Delta: DefaultStock, Reason: models.StockReasonCorrection})
 	return p
 }
 
-// SeedMenu creates a menu in the test DB.
+// SeedMenu creates a menu in the test DB. price is in euros.
 func SeedMenu(db *gorm.DB, name string, price float64, available bool) models.Menu {
-	m := models.Menu{Name: name, Price: price, IsAvailable: available}
+	m := models.Menu{Name: name, Price: models.FromEuros(price), IsAvailable: available}
 	db.Create(&m)
 	return m
 }
 
+// SeedMenuProduct adds a product to a menu in the test DB.
+func SeedMenuProduct(db *gorm.DB, menuID, productID, quantity uint, optional bool) models.MenuProduct {
+	mp := models.MenuProduct{MenuID: menuID, ProductID: productID, Quantity: quantity, IsOptional: optional}
+	db.Create(&mp)
+	return mp
+}
+
+// SetStock sets the stock quantity of a product in the test DB and records the difference in the ledger.
+func SetStock(db *gorm.DB, productID, quantity uint) {
+	var p models.Products
+	db.First(&p, productID)
+	db.Model(&p).Update("stock_quantity", quantity)
+	db.Create(&models.StockMovement{ProductID: productID, Delta: int(quantity) - int(p.StockQuantity), Reason: models.StockReasonCorrection})
+}
+
 // SeedCustomer creates a customer in the test DB.
 func SeedCustomer(db *gorm.DB, name, phone, email string) models.Customer {
 	c := models.Customer{Name: name, Phone: phone, Email: email}
@@ -129,15 +176,30 @@ func ParseResponse(w *httptest.ResponseRecorder) map[string]interface{} {
 	return result
 }
 
-// AuthMiddleware is a test middleware that sets userID and userRole in the gin context.
+// AuthMiddleware is a test middleware that sets userID and userRole in the gin context, as
+// Authentication() does (see SetAuth).
 func AuthMiddleware(userID int, role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Set("userID", userID)
-		c.Set("userRole", role)
+		SetAuth(c, userID, role)
 		c.Next()
 	}
 }
 
+// SetAuth sets userID, userRole and userPermissions in the gin context. The permissions are read
+// from the role in the test DB, or are the role's defaults (see models.DefaultRolePermissions)
+// when it was not seeded.
+func SetAuth(c *gin.Context, userID int, role string) {
+	permissions := models.DefaultRolePermissions[role]
+	var seeded models.Roles
+	if err := config.DB.Where("role_name = ?", role).Limit(1).Find(&seeded).Error; err == nil && seeded.ID != 0 {
+		permissions = seeded.Permissions
+	}
+
+	c.Set("userID", userID)
+	c.Set("userRole", role)
+	c.Set("userPermissions", permissions)
+}
+
 // IDParam returns the URL with the id substituted (for use in route definitions).
 func IDParam(base string, id uint) string {
 	return fmt.Sprintf("%s/%d", base, id)
@@ -0,0 +1,28 @@
+package workflow
+
+// Orders is the order lifecycle. Adding a state (e.g. ready_for_pickup or refunded)
+// only takes a new State and the Transitions leading in and out of it.
+//
+//	pending → preparing → prepared → delivered
+//	   ↓
+//	cancelled
+//
+// Each transition needs the permission "orders:status:<target status>" (see models.OrderStatusPermission).
+// By default the kitchen (preparation) starts and finishes orders, the counter (accueil) cancels
+// and hands them over; admin can do everything.
+var Orders = Definition{
+	Initial: "pending",
+	States: []State{
+		{Name: "pending", Label: "Pending"},
+		{Name: "preparing", Label: "Preparing"},
+		{Name: "prepared", Label: "Prepared"},
+		{Name: "delivered", Label: "Delivered", Final: true},
+		{Name: "cancelled", Label: "Cancelled", Final: true},
+	},
+	Transitions: []Transition{
+		{From: "pending", To: "preparing", Permission: "orders:status:preparing"},
+		{From: "pending", To: "cancelled", Permission: "orders:status:cancelled"},
+		{From: "preparing", To: "prepared", Permission: "orders:status:prepared"},
+		{From: "prepared", To: "delivered", Permission: "orders:status:delivered"},
+	},
+}
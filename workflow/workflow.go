@@ -0,0 +1,135 @@
+// Package workflow describes state machines declaratively: the states an entity can be in,
+// the transitions between them and the permission needed to trigger each transition.
+// It has no database or HTTP dependency so it can be tested and reasoned about on its own.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Errors returned by Check. Callers typically answer 400 for ErrInvalidTransition
+// and 403 for ErrNotPermitted.
+var (
+	ErrInvalidTransition = errors.New("invalid transition")
+	ErrNotPermitted      = errors.New("permission required")
+)
+
+// Granted tells whether the caller holds a permission. A nil Granted holds them all.
+type Granted func(permission string) bool
+
+func (g Granted) has(permission string) bool {
+	return g == nil || g(permission)
+}
+
+// State is one status of the workflow. A final state has no outgoing transition.
+type State struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Final bool   `json:"final"`
+}
+
+// Transition allows moving from one state to another, for holders of its permission only.
+type Transition struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Permission string `json:"permission"`
+}
+
+// Definition is a complete workflow. Initial is the state new entities start in.
+type Definition struct {
+	Initial     string       `json:"initial"`
+	States      []State      `json:"states"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// HasState reports whether name is a state of the workflow.
+func (d *Definition) HasState(name string) bool {
+	return slices.ContainsFunc(d.States, func(s State) bool { return s.Name == name })
+}
+
+// transition returns the transition from one state to another, if the workflow defines it.
+func (d *Definition) transition(from, to string) (Transition, bool) {
+	for _, t := range d.Transitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// Allows reports whether the workflow defines a transition from one state to another,
+// regardless of who triggers it. Used for changes the system makes on its own
+// (e.g. an order following the progress of its items).
+func (d *Definition) Allows(from, to string) bool {
+	_, ok := d.transition(from, to)
+	return ok
+}
+
+// Check verifies that the caller may move an entity from one state to another.
+// It returns an error wrapping ErrInvalidTransition when the transition does not exist,
+// or ErrNotPermitted when it exists but the caller lacks its permission.
+func (d *Definition) Check(from, to string, granted Granted) error {
+	t, ok := d.transition(from, to)
+	if !ok {
+		return fmt.Errorf("%w from '%s' to '%s'", ErrInvalidTransition, from, to)
+	}
+	if !granted.has(t.Permission) {
+		return fmt.Errorf("%w: moving from '%s' to '%s' requires '%s'", ErrNotPermitted, from, to, t.Permission)
+	}
+	return nil
+}
+
+// Next lists the states the caller may move to from the given state, in definition order.
+// A nil granted lists every reachable state.
+func (d *Definition) Next(from string, granted Granted) []string {
+	next := []string{}
+	for _, t := range d.Transitions {
+		if t.From == from && granted.has(t.Permission) {
+			next = append(next, t.To)
+		}
+	}
+	return next
+}
+
+// Validate checks that the definition is consistent: unique states, a known initial state,
+// transitions between known states with a permission, no duplicate transition,
+// and no transition out of a final state.
+func (d *Definition) Validate() error {
+	seen := make(map[string]State)
+	for _, s := range d.States {
+		if s.Name == "" {
+			return errors.New("state with an empty name")
+		}
+		if _, dup := seen[s.Name]; dup {
+			return fmt.Errorf("duplicate state '%s'", s.Name)
+		}
+		seen[s.Name] = s
+	}
+	if _, ok := seen[d.Initial]; !ok {
+		return fmt.Errorf("unknown initial state '%s'", d.Initial)
+	}
+
+	pairs := make(map[[2]string]bool)
+	for _, t := range d.Transitions {
+		from, ok := seen[t.From]
+		if !ok {
+			return fmt.Errorf("transition from unknown state '%s'", t.From)
+		}
+		if _, ok := seen[t.To]; !ok {
+			return fmt.Errorf("transition to unknown state '%s'", t.To)
+		}
+		if from.Final {
+			return fmt.Errorf("transition out of final state '%s'", t.From)
+		}
+		if t.Permission == "" {
+			return fmt.Errorf("transition '%s' → '%s' has no permission", t.From, t.To)
+		}
+		if pairs[[2]string{t.From, t.To}] {
+			return fmt.Errorf("duplicate transition '%s' → '%s'", t.From, t.To)
+		}
+		pairs[[2]string{t.From, t.To}] = true
+	}
+	return nil
+}
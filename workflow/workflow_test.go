@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDefinition() Definition {
+	return Definition{
+		Initial: "open",
+		States: []State{
+			{Name: "open"},
+			{Name: "ready"},
+			{Name: "closed", Final: true},
+		},
+		Transitions: []Transition{
+			{From: "open", To: "ready", Permission: "tickets:ready"},
+			{From: "ready", To: "closed", Permission: "tickets:close"},
+			{From: "open", To: "closed", Permission: "tickets:close"},
+		},
+	}
+}
+
+// holds returns a Granted holding exactly the given permissions.
+func holds(permissions ...string) Granted {
+	return func(p string) bool { return slices.Contains(permissions, p) }
+}
+
+var (
+	cook    = holds("tickets:ready")
+	waiter  = holds("tickets:close")
+	manager = holds("tickets:ready", "tickets:close")
+)
+
+func TestCheck(t *testing.T) {
+	d := testDefinition()
+
+	assert.NoError(t, d.Check("open", "ready", cook))
+	assert.NoError(t, d.Check("ready", "closed", manager))
+	assert.NoError(t, d.Check("ready", "closed", nil))
+
+	assert.ErrorIs(t, d.Check("open", "ready", waiter), ErrNotPermitted)
+	assert.ErrorIs(t, d.Check("ready", "open", manager), ErrInvalidTransition)
+	assert.ErrorIs(t, d.Check("closed", "open", manager), ErrInvalidTransition)
+	assert.ErrorIs(t, d.Check("open", "unknown", manager), ErrInvalidTransition)
+}
+
+func TestAllows(t *testing.T) {
+	d := testDefinition()
+
+	assert.True(t, d.Allows("open", "ready"))
+	assert.False(t, d.Allows("ready", "open"))
+	assert.False(t, d.Allows("closed", "ready"))
+}
+
+func TestNext(t *testing.T) {
+	d := testDefinition()
+
+	assert.Equal(t, []string{"ready", "closed"}, d.Next("open", nil))
+	assert.Equal(t, []string{"ready", "closed"}, d.Next("open", manager))
+	assert.Equal(t, []string{"closed"}, d.Next("open", waiter))
+	assert.Equal(t, []string{"ready"}, d.Next("open", cook))
+	assert.Empty(t, d.Next("closed", manager))
+}
+
+func TestValidate(t *testing.T) {
+	valid := testDefinition()
+	assert.NoError(t, valid.Validate())
+
+	cases := map[string]func(d *Definition){
+		"unknown initial":  func(d *Definition) { d.Initial = "draft" },
+		"duplicate state":  func(d *Definition) { d.States = append(d.States, State{Name: "open"}) },
+		"empty state name": func(d *Definition) { d.States = append(d.States, State{}) },
+		"unknown from": func(d *Definition) {
+			d.Transitions = append(d.Transitions, Transition{From: "draft", To: "open", Permission: "tickets:ready"})
+		},
+		"unknown to": func(d *Definition) {
+			d.Transitions = append(d.Transitions, Transition{From: "open", To: "draft", Permission: "tickets:ready"})
+		},
+		"out of final": func(d *Definition) {
+			d.Transitions = append(d.Transitions, Transition{From: "closed", To: "open", Permission: "tickets:ready"})
+		},
+		"no permission": func(d *Definition) { d.Transitions[0].Permission = "" },
+		"duplicate pair": func(d *Definition) {
+			d.Transitions = append(d.Transitions, Transition{From: "open", To: "ready", Permission: "tickets:close"})
+		},
+	}
+	for name, mutate := range cases {
+		d := testDefinition()
+		mutate(&d)
+		assert.Error(t, d.Validate(), name)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	assert.NoError(t, Orders.Validate())
+	assert.True(t, Orders.HasState(Orders.Initial))
+
+	// Each transition needs the permission named after its target status
+	kitchen := holds("orders:status:preparing", "orders:status:prepared")
+	counter := holds("orders:status:delivered", "orders:status:cancelled")
+	assert.NoError(t, Orders.Check("pending", "preparing", kitchen))
+	assert.NoError(t, Orders.Check("preparing", "prepared", kitchen))
+	assert.NoError(t, Orders.Check("prepared", "delivered", counter))
+	assert.NoError(t, Orders.Check("pending", "cancelled", counter))
+	assert.ErrorIs(t, Orders.Check("prepared", "delivered", kitchen), ErrNotPermitted)
+	assert.ErrorIs(t, Orders.Check("pending", "preparing", counter), ErrNotPermitted)
+	assert.ErrorIs(t, Orders.Check("pending", "cancelled", kitchen), ErrNotPermitted)
+	assert.ErrorIs(t, Orders.Check("preparing", "cancelled", nil), ErrInvalidTransition)
+
+	for _, transition := range Orders.Transitions {
+		assert.Equal(t, "orders:status:"+transition.To, transition.Permission)
+	}
+}